@@ -0,0 +1,168 @@
+package jwt
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// DefaultActionTTL is the lifespan of one-time tokens when not configured.
+const DefaultActionTTL = 15 * time.Minute
+
+// PurposeClaim is the claim name for the action of one-time tokens.
+const purposeClaim = "purpose"
+
+// One-time token errors.
+var (
+	ErrUsed    = errors.New("jwt: one-time token used before")
+	ErrPurpose = errors.New("jwt: token purpose mismatch")
+)
+
+var errActionAudience = errors.New("jwt: action tokens without audience")
+
+// ConsumeStore records the use of one-time tokens. Implementations may use
+// shared storage, like Redis with SET NX, to consume across instances.
+type ConsumeStore interface {
+	// Consume marks the token ID as used in an atomic manner. The return
+	// is false when the ID was marked before. Records may be discarded
+	// once expired.
+	Consume(ctx context.Context, id string, expires time.Time) (first bool, err error)
+}
+
+// ConsumeMemory is a ConsumeStore in memory.
+//
+// Multiple goroutines may invoke methods on a ConsumeMemory simultaneously.
+type ConsumeMemory struct {
+	// Now is the time source. Nil defaults to time.Now.
+	Now func() time.Time
+
+	mutex sync.Mutex
+	used  map[string]time.Time // expiry per ID
+	swept time.Time
+}
+
+// Consume implements the ConsumeStore interface.
+func (m *ConsumeMemory) Consume(ctx context.Context, id string, expires time.Time) (first bool, err error) {
+	now := time.Now()
+	if m.Now != nil {
+		now = m.Now()
+	}
+
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	if m.used == nil {
+		m.used = make(map[string]time.Time)
+		m.swept = now
+	}
+	if now.Sub(m.swept) >= time.Minute {
+		for k, t := range m.used {
+			if !now.Before(t) {
+				delete(m.used, k)
+			}
+		}
+		m.swept = now
+	}
+
+	if _, ok := m.used[id]; ok {
+		return false, nil
+	}
+	m.used[id] = expires
+	return true, nil
+}
+
+// ActionTokens issues and redeems single-use tokens, like for email
+// verification, password reset and magic links. Each token has a "jti", an
+// "exp", an "aud" and a "purpose" claim. Redemption consumes the token ID, such
+// that any subsequent attempt fails with ErrUsed.
+//
+// Multiple goroutines may invoke methods on an ActionTokens simultaneously.
+type ActionTokens struct {
+	// Issuer signs the tokens. Its Name and Now apply. Its Audiences and
+	// TTL are ignored in favour of the fields below.
+	Issuer *Issuer
+
+	// Audience identifies the endpoint which redeems the tokens, e.g.,
+	// "https://example.com/reset". Tokens for another endpoint fail with
+	// ErrAudience, even when signed with the same key. The empty string
+	// is not allowed.
+	Audience string
+
+	// Keys verifies the signature on redemption.
+	Keys Checker
+
+	// Store tracks token usage.
+	Store ConsumeStore
+
+	// TTL is the lifespan of tokens. Zero defaults to DefaultActionTTL.
+	TTL time.Duration
+}
+
+// Issue returns a new token for the subject, bound to the purpose, e.g.,
+// "password-reset". The extra claims are optional.
+func (a *ActionTokens) Issue(ctx context.Context, purpose, subject string, extraClaims map[string]interface{}) ([]byte, error) {
+	if a.Audience == "" {
+		return nil, errActionAudience
+	}
+	c, s, err := a.Issuer.claims(subject, extraClaims)
+	if err != nil {
+		return nil, err
+	}
+	c.Audiences = []string{a.Audience}
+	ttl := a.TTL
+	if ttl <= 0 {
+		ttl = DefaultActionTTL
+	}
+	c.StampTTL(a.now(), ttl)
+	if c.Set == nil {
+		c.Set = make(map[string]interface{}, 6)
+	}
+	c.Set[purposeClaim] = purpose
+	return a.Issuer.sign(ctx, c, s)
+}
+
+// Redeem verifies the token for the purpose and consumes it. Only the first
+// redemption of a token passes; ErrUsed signals a reuse.
+func (a *ActionTokens) Redeem(ctx context.Context, token []byte, purpose string) (*Claims, error) {
+	if a.Audience == "" {
+		return nil, errActionAudience
+	}
+	c, err := a.Keys.Check(token)
+	if err != nil {
+		return nil, err
+	}
+
+	p := Policy{
+		Audiences: []string{a.Audience},
+		Require:   []string{id, expires},
+	}
+	if a.Issuer.Name != "" {
+		p.Issuers = []string{a.Issuer.Name}
+	}
+	if err := p.Apply(c, a.now()); err != nil {
+		return nil, err
+	}
+	if s, ok := c.Set[purposeClaim].(string); !ok {
+		return nil, fmt.Errorf("%w: %q", ErrClaimMiss, purposeClaim)
+	} else if s != purpose {
+		return nil, fmt.Errorf("%w: got %q, want %q", ErrPurpose, s, purpose)
+	}
+
+	first, err := a.Store.Consume(ctx, c.ID, c.Expires.Time())
+	if err != nil {
+		return nil, err
+	}
+	if !first {
+		return nil, ErrUsed
+	}
+	return c, nil
+}
+
+func (a *ActionTokens) now() time.Time {
+	if a.Issuer.Now != nil {
+		return a.Issuer.Now()
+	}
+	return time.Now()
+}
@@ -0,0 +1,107 @@
+//go:build !jwt_hmac_only
+// +build !jwt_hmac_only
+
+package jwt
+
+import (
+	"context"
+	"crypto/ed25519"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestActionTokens(t *testing.T) {
+	s, err := NewSigner(EdDSA, testKeyEd25519Private)
+	if err != nil {
+		t.Fatal("signer error:", err)
+	}
+	now := time.Now()
+	iss := NewIssuer(s, "")
+	iss.Name = "https://example.com"
+	iss.Now = func() time.Time { return now }
+	a := &ActionTokens{
+		Issuer:   iss,
+		Audience: "https://example.com/reset",
+		Keys:     &KeyRegister{EdDSAs: []ed25519.PublicKey{testKeyEd25519Public}},
+		Store:    new(ConsumeMemory),
+	}
+
+	ctx := context.Background()
+	token, err := a.Issue(ctx, "password-reset", "alice", map[string]interface{}{"email": "alice@example.com"})
+	if err != nil {
+		t.Fatal("issue error:", err)
+	}
+
+	if _, err := a.Redeem(ctx, token, "email-verify"); !errors.Is(err, ErrPurpose) {
+		t.Errorf("got error %v, want %v", err, ErrPurpose)
+	}
+	other := *a
+	other.Audience = "https://example.com/verify"
+	if _, err := other.Redeem(ctx, token, "password-reset"); err != ErrAudience {
+		t.Errorf("got error %v at other endpoint, want %v", err, ErrAudience)
+	}
+	c, err := a.Redeem(ctx, token, "password-reset")
+	if err != nil {
+		t.Fatal("redeem error:", err)
+	}
+	if c.Subject != "alice" || c.ID == "" || c.Set["email"] != "alice@example.com" {
+		t.Errorf("got claims %+v", c)
+	}
+	if got, want := c.Expires.Time(), now.Truncate(time.Second).Add(DefaultActionTTL); !got.Equal(want) {
+		t.Errorf("got expiry %s, want %s", got, want)
+	}
+	if _, err := a.Redeem(ctx, token, "password-reset"); err != ErrUsed {
+		t.Errorf("got error %v on reuse, want %v", err, ErrUsed)
+	}
+	if got := ErrorCode(ErrUsed); got != "token_used" {
+		t.Errorf("got error code %q, want token_used", got)
+	}
+
+	expired, err := a.Issue(ctx, "magic-link", "bob", nil)
+	if err != nil {
+		t.Fatal("issue error:", err)
+	}
+	now = now.Add(DefaultActionTTL + time.Second)
+	if _, err := a.Redeem(ctx, expired, "magic-link"); err != ErrExpired {
+		t.Errorf("got error %v, want %v", err, ErrExpired)
+	}
+}
+
+func TestActionTokensClaimMiss(t *testing.T) {
+	a := &ActionTokens{
+		Issuer:   new(Issuer),
+		Audience: "https://example.com/login",
+		Keys:     &KeyRegister{EdDSAs: []ed25519.PublicKey{testKeyEd25519Public}},
+		Store:    new(ConsumeMemory),
+	}
+
+	var c Claims
+	c.Audiences = []string{a.Audience}
+	c.Expires = NewNumericTime(time.Now().Add(time.Minute))
+	c.Set = map[string]interface{}{"purpose": "login"}
+	token, err := c.EdDSASign(testKeyEd25519Private)
+	if err != nil {
+		t.Fatal("sign error:", err)
+	}
+	if _, err := a.Redeem(context.Background(), token, "login"); !errors.Is(err, ErrClaimMiss) {
+		t.Errorf("got error %v without jti, want %v", err, ErrClaimMiss)
+	}
+}
+
+func TestConsumeMemorySweep(t *testing.T) {
+	now := time.Now()
+	m := &ConsumeMemory{Now: func() time.Time { return now }}
+	ctx := context.Background()
+	if first, err := m.Consume(ctx, "a", now.Add(time.Second)); !first || err != nil {
+		t.Fatalf("got (%t, %v), want (true, nil)", first, err)
+	}
+	if first, _ := m.Consume(ctx, "a", now.Add(time.Second)); first {
+		t.Error("second consume passed")
+	}
+	now = now.Add(time.Hour)
+	m.Consume(ctx, "b", now.Add(time.Second))
+	if _, ok := m.used["a"]; ok {
+		t.Error("expired record not swept")
+	}
+}
@@ -0,0 +1,197 @@
+//go:build !jwt_no_ecdsa && !jwt_hmac_only
+// +build !jwt_no_ecdsa,!jwt_hmac_only
+
+package jwt
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"net/http"
+	"strconv"
+)
+
+// ECDSACheck parses a JWT if, and only if, the signature checks out.
+// The return is an AlgError when the algorithm is not in ECDSAAlgs.
+// Use Valid to complete the verification.
+func ECDSACheck(token []byte, key *ecdsa.PublicKey) (*Claims, error) {
+	var c Claims
+	bodyLen, sig, alg, err := c.scan(token)
+	if err != nil {
+		return nil, err
+	}
+
+	hash, err := hashLookup(alg, ECDSAAlgs)
+	if err != nil {
+		return nil, err
+	}
+	digest := hash.New()
+	digest.Write(token[:bodyLen])
+
+	r := new(big.Int).SetBytes(sig[:len(sig)/2])
+	s := new(big.Int).SetBytes(sig[len(sig)/2:])
+	buf := sig[len(sig):]
+	if !ecdsa.Verify(key, digest.Sum(buf), r, s) {
+		return nil, ErrSigMiss
+	}
+
+	return &c, c.applyPayload()
+}
+
+// ECDSACheckHeader applies ECDSACheck on an HTTP request.
+// Specifically it looks for a bearer token in the Authorization header.
+func ECDSACheckHeader(r *http.Request, key *ecdsa.PublicKey) (*Claims, error) {
+	token, err := tokenFromHeader(r)
+	if err != nil {
+		return nil, err
+	}
+	return ECDSACheck(token, key)
+}
+
+// ECDSASign updates the Raw fields and returns a new JWT.
+// The return is an AlgError when alg is not in ECDSAAlgs.
+// The caller must use the correct key for the respective algorithm (P-256 for
+// ES256, P-384 for ES384 and P-521 for ES512) or risk malformed token production.
+//
+// The JOSE header (content) can be extended with extraHeaders, in the form of
+// JSON objects. Redundant and/or duplicate keys are applied as provided.
+func (c *Claims) ECDSASign(alg string, key *ecdsa.PrivateKey, extraHeaders ...json.RawMessage) (token []byte, err error) {
+	hash, err := hashLookup(alg, ECDSAAlgs)
+	if err != nil {
+		return nil, err
+	}
+	digest := hash.New()
+
+	// signature contains pair (r, s) as per RFC 7518, subsection 3.4
+	paramLen := (key.Curve.Params().BitSize + 7) / 8
+	token, err = c.newToken(alg, encoding.EncodedLen(paramLen*2), extraHeaders)
+	if err != nil {
+		return nil, err
+	}
+	digest.Write(token)
+
+	buf := token[len(token):]
+	r, s, err := ecdsa.Sign(rand.Reader, key, digest.Sum(buf))
+	if err != nil {
+		return nil, err
+	}
+
+	token = append(token, '.')
+	sig := token[len(token):cap(token)]
+	// serialize r and s, using sig as a buffer
+	i := len(sig)
+	for _, word := range s.Bits() {
+		for bitCount := strconv.IntSize; bitCount > 0; bitCount -= 8 {
+			i--
+			sig[i] = byte(word)
+			word >>= 8
+		}
+	}
+	// i might have exceeded paramLen due to the word size
+	i = len(sig) - paramLen
+	for _, word := range r.Bits() {
+		for bitCount := strconv.IntSize; bitCount > 0; bitCount -= 8 {
+			i--
+			sig[i] = byte(word)
+			word >>= 8
+		}
+	}
+
+	// encoder won't overhaul source space
+	encoding.Encode(sig, sig[len(sig)-2*paramLen:])
+	c.audit(context.Background(), alg)
+	return token[:cap(token)], nil
+}
+
+// ECDSASignHeader applies ECDSASign on an HTTP request.
+// Specifically it sets a bearer token in the Authorization header.
+func (c *Claims) ECDSASignHeader(r *http.Request, alg string, key *ecdsa.PrivateKey) error {
+	token, err := c.ECDSASign(alg, key)
+	if err != nil {
+		return err
+	}
+	r.Header.Set("Authorization", "Bearer "+string(token))
+	return nil
+}
+
+// CheckECDSA is the ECDSA part of KeyRegister.Check.
+func (keys *KeyRegister) checkECDSA(c *Claims, alg string, body, sig []byte, trace *Trace) (*Claims, error) {
+	hash, err := hashLookup(alg, ECDSAAlgs)
+	if err != nil {
+		return nil, err
+	}
+	r := new(big.Int).SetBytes(sig[:len(sig)/2])
+	s := new(big.Int).SetBytes(sig[len(sig)/2:])
+	digest := hash.New()
+	digest.Write(body)
+	digestSum := digest.Sum(sig[len(sig):])
+
+	lo, hi := keyRange(keys.ECDSAIDs, c.KeyID, len(keys.ECDSAs))
+	for i := lo; i < hi; i++ {
+		start := trace.start()
+		match := ecdsa.Verify(keys.ECDSAs[i], digestSum, r, s)
+		trace.addKey(keys.ECDSAIDs, i, keys.ECDSAs[i], match, start)
+		if match {
+			return c.complete(trace)
+		}
+	}
+	return nil, ErrSigMiss
+}
+
+// EcJWK returns the public key of an "EC" JWK.
+func ecJWK(j *jwk) (interface{}, error) {
+	var curve elliptic.Curve
+	switch j.Crv {
+	case "P-256":
+		curve = elliptic.P256()
+	case "P-384":
+		curve = elliptic.P384()
+	case "P-521":
+		curve = elliptic.P521()
+	default:
+		return nil, fmt.Errorf("jwt: JWK with unsupported elliptic curve %q", j.Crv)
+	}
+
+	x, err := intParam(j.X)
+	if err != nil {
+		return nil, err
+	}
+	y, err := intParam(j.Y)
+	if err != nil {
+		return nil, err
+	}
+
+	size := (curve.Params().BitSize + 7) / 8
+	xSize, ySize := (x.BitLen()+7)/8, (y.BitLen()+7)/8
+	if xSize != size || ySize != size {
+		return nil, errJWKCurveSize
+	}
+
+	if !curve.IsOnCurve(x, y) {
+		return nil, errJWKCurveMiss
+	}
+
+	return &ecdsa.PublicKey{Curve: curve, X: x, Y: y}, nil
+}
+
+// EcPrivateJWK returns the private key of an "EC" JWK.
+func ecPrivateJWK(j *jwk, pub *ecdsa.PublicKey) (interface{}, error) {
+	d, err := intParam(j.D)
+	if err != nil {
+		return nil, err
+	}
+	x, y := pub.Curve.ScalarBaseMult(d.Bytes())
+	if x.Cmp(pub.X) != 0 || y.Cmp(pub.Y) != 0 {
+		return nil, errJWKPrivate
+	}
+	return &ecdsa.PrivateKey{PublicKey: *pub, D: d}, nil
+}
+
+// CheckECDSAKey is the ECDSA part of Check.
+func checkECDSAKey(token []byte, key *ecdsa.PublicKey) (*Claims, error) {
+	return ECDSACheck(token, key)
+}
@@ -0,0 +1,125 @@
+//go:build !jwt_hmac_only
+// +build !jwt_hmac_only
+
+package jwt
+
+import (
+	"bytes"
+	"context"
+	"crypto/ed25519"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+)
+
+// EdDSAInUse is cleared by build tag jwt_hmac_only.
+const edDSAInUse = true
+
+// EdDSACheck parses a JWT if, and only if, the signature checks out.
+// Use Valid to complete the verification.
+func EdDSACheck(token []byte, key ed25519.PublicKey) (*Claims, error) {
+	var c Claims
+	bodyLen, sig, alg, err := c.scan(token)
+	if err != nil {
+		return nil, err
+	}
+
+	if alg != EdDSA {
+		return nil, AlgError(alg)
+	}
+
+	if !ed25519.Verify(key, token[:bodyLen], sig) {
+		return nil, ErrSigMiss
+	}
+
+	return &c, c.applyPayload()
+}
+
+// EdDSACheckHeader applies EdDSACheck on an HTTP request.
+// Specifically it looks for a bearer token in the Authorization header.
+func EdDSACheckHeader(r *http.Request, key ed25519.PublicKey) (*Claims, error) {
+	token, err := tokenFromHeader(r)
+	if err != nil {
+		return nil, err
+	}
+	return EdDSACheck(token, key)
+}
+
+// EdDSASign updates the Raw fields and returns a new JWT.
+//
+// The JOSE header (content) can be extended with extraHeaders, in the form of
+// JSON objects. Redundant and/or duplicate keys are applied as provided.
+func (c *Claims) EdDSASign(key ed25519.PrivateKey, extraHeaders ...json.RawMessage) (token []byte, err error) {
+	token, err = c.newToken(EdDSA, encoding.EncodedLen(ed25519.SignatureSize), extraHeaders)
+	if err != nil {
+		return nil, err
+	}
+
+	sig := ed25519.Sign(key, token)
+
+	token = append(token, '.')
+	encoding.Encode(token[len(token):cap(token)], sig)
+	c.audit(context.Background(), EdDSA)
+	return token[:cap(token)], nil
+}
+
+// EdDSASignHeader applies ECDSASign on an HTTP request.
+// Specifically it sets a bearer token in the Authorization header.
+func (c *Claims) EdDSASignHeader(r *http.Request, key ed25519.PrivateKey) error {
+	token, err := c.EdDSASign(key)
+	if err != nil {
+		return err
+	}
+	r.Header.Set("Authorization", "Bearer "+string(token))
+	return nil
+}
+
+// CheckEdDSA is the EdDSA part of KeyRegister.Check.
+func (keys *KeyRegister) checkEdDSA(c *Claims, body, sig []byte, trace *Trace) (*Claims, error) {
+	lo, hi := keyRange(keys.EdDSAIDs, c.KeyID, len(keys.EdDSAs))
+	for i := lo; i < hi; i++ {
+		start := trace.start()
+		match := ed25519.Verify(keys.EdDSAs[i], body, sig)
+		trace.addKey(keys.EdDSAIDs, i, keys.EdDSAs[i], match, start)
+		if match {
+			return c.complete(trace)
+		}
+	}
+	return nil, ErrSigMiss
+}
+
+// OkpPrivateJWK returns the private key of an "OKP" JWK.
+func okpPrivateJWK(j *jwk, pub ed25519.PublicKey) (interface{}, error) {
+	seed, err := dataParam(j.D)
+	if err != nil {
+		return nil, err
+	}
+	if len(seed) != ed25519.SeedSize {
+		return nil, errors.New("jwt: JWK Ed25519 private key with wrong size")
+	}
+	private := ed25519.NewKeyFromSeed(seed)
+	if !bytes.Equal(private.Public().(ed25519.PublicKey), pub) {
+		return nil, errJWKPrivate
+	}
+	return private, nil
+}
+
+// CheckEdDSAKey is the EdDSA part of Check.
+func checkEdDSAKey(token []byte, key ed25519.PublicKey) (*Claims, error) {
+	return EdDSACheck(token, key)
+}
+
+// OkpJWK returns the public key of an "OKP" JWK.
+func okpJWK(j *jwk) (interface{}, error) {
+	switch j.Crv {
+	case "Ed25519":
+		bytes, err := dataParam(j.X)
+		if err != nil {
+			return nil, err
+		}
+		return ed25519.PublicKey(bytes), nil
+	default:
+		return nil, fmt.Errorf("jwt: JWK with unsupported elliptic curve %q", j.Crv)
+	}
+}
@@ -0,0 +1,29 @@
+//go:build jwt_hmac_only
+// +build jwt_hmac_only
+
+package jwt
+
+import (
+	"crypto/ed25519"
+	"fmt"
+)
+
+// Build tag jwt_hmac_only compiles out all public-key algorithms, including the
+// RSA and ECDSA ones from algs_no_rsa.go and algs_no_ecdsa.go.
+const edDSAInUse = false
+
+func checkEdDSAKey(token []byte, key ed25519.PublicKey) (*Claims, error) {
+	return nil, fmt.Errorf("jwt: unsupported key type %T", key)
+}
+
+func (keys *KeyRegister) checkEdDSA(c *Claims, body, sig []byte, trace *Trace) (*Claims, error) {
+	return nil, AlgError(EdDSA)
+}
+
+func okpJWK(j *jwk) (interface{}, error) {
+	return nil, fmt.Errorf("jwt: JWK with unsupported key type %q", *j.Kty)
+}
+
+func okpPrivateJWK(j *jwk, pub ed25519.PublicKey) (interface{}, error) {
+	return nil, fmt.Errorf("jwt: JWK with unsupported key type %q", *j.Kty)
+}
@@ -0,0 +1,33 @@
+//go:build jwt_no_ecdsa || jwt_hmac_only
+// +build jwt_no_ecdsa jwt_hmac_only
+
+package jwt
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"fmt"
+)
+
+// Build tag jwt_no_ecdsa compiles out the ECDSA algorithms. Package
+// crypto/ecdsa remains imported for its types, as the exported API refers to
+// them.
+func init() {
+	ECDSAAlgs = map[string]crypto.Hash{}
+}
+
+func checkECDSAKey(token []byte, key *ecdsa.PublicKey) (*Claims, error) {
+	return nil, fmt.Errorf("jwt: unsupported key type %T", key)
+}
+
+func (keys *KeyRegister) checkECDSA(c *Claims, alg string, body, sig []byte, trace *Trace) (*Claims, error) {
+	return nil, AlgError(alg)
+}
+
+func ecJWK(j *jwk) (interface{}, error) {
+	return nil, fmt.Errorf("jwt: JWK with unsupported key type %q", *j.Kty)
+}
+
+func ecPrivateJWK(j *jwk, pub *ecdsa.PublicKey) (interface{}, error) {
+	return nil, fmt.Errorf("jwt: JWK with unsupported key type %q", *j.Kty)
+}
@@ -0,0 +1,32 @@
+//go:build jwt_no_rsa || jwt_hmac_only
+// +build jwt_no_rsa jwt_hmac_only
+
+package jwt
+
+import (
+	"crypto"
+	"crypto/rsa"
+	"fmt"
+)
+
+// Build tag jwt_no_rsa compiles out the RSA algorithms. Package crypto/rsa
+// remains imported for its types, as the exported API refers to them.
+func init() {
+	RSAAlgs = map[string]crypto.Hash{}
+}
+
+func checkRSAKey(token []byte, key *rsa.PublicKey) (*Claims, error) {
+	return nil, fmt.Errorf("jwt: unsupported key type %T", key)
+}
+
+func (keys *KeyRegister) checkRSA(c *Claims, alg string, body, sig []byte, trace *Trace) (*Claims, error) {
+	return nil, AlgError(alg)
+}
+
+func rsaJWK(j *jwk) (interface{}, error) {
+	return nil, fmt.Errorf("jwt: JWK with unsupported key type %q", *j.Kty)
+}
+
+func rsaPrivateJWK(j *jwk, pub *rsa.PublicKey) (interface{}, error) {
+	return nil, fmt.Errorf("jwt: JWK with unsupported key type %q", *j.Kty)
+}
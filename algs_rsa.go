@@ -0,0 +1,192 @@
+//go:build !jwt_no_rsa && !jwt_hmac_only
+// +build !jwt_no_rsa,!jwt_hmac_only
+
+package jwt
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"net/http"
+)
+
+// “The size of the salt value is the same size as the hash function output.”
+// — “JSON Web Algorithms (JWA)” RFC 7518, subsection 3.5
+var pSSOptions = rsa.PSSOptions{SaltLength: rsa.PSSSaltLengthEqualsHash}
+
+// RSACheck parses a JWT if, and only if, the signature checks out.
+// The return is an AlgError when the algorithm is not in RSAAlgs.
+// Use Valid to complete the verification.
+func RSACheck(token []byte, key *rsa.PublicKey) (*Claims, error) {
+	var c Claims
+	bodyLen, sig, alg, err := c.scan(token)
+	if err != nil {
+		return nil, err
+	}
+
+	hash, err := hashLookup(alg, RSAAlgs)
+	if err != nil {
+		return nil, err
+	}
+	digest := hash.New()
+	digest.Write(token[:bodyLen])
+
+	buf := sig[len(sig):]
+	if alg != "" && alg[0] == 'P' {
+		err = rsa.VerifyPSS(key, hash, digest.Sum(buf), sig, &pSSOptions)
+	} else {
+		err = rsa.VerifyPKCS1v15(key, hash, digest.Sum(buf), sig)
+	}
+	if err != nil {
+		return nil, ErrSigMiss
+	}
+
+	return &c, c.applyPayload()
+}
+
+// RSACheckHeader applies RSACheck on an HTTP request.
+// Specifically it looks for a bearer token in the Authorization header.
+func RSACheckHeader(r *http.Request, key *rsa.PublicKey) (*Claims, error) {
+	token, err := tokenFromHeader(r)
+	if err != nil {
+		return nil, err
+	}
+	return RSACheck(token, key)
+}
+
+// RSASign updates the Raw fields and returns a new JWT.
+// The return is an AlgError when alg is not in RSAAlgs.
+//
+// The JOSE header (content) can be extended with extraHeaders, in the form of
+// JSON objects. Redundant and/or duplicate keys are applied as provided.
+func (c *Claims) RSASign(alg string, key *rsa.PrivateKey, extraHeaders ...json.RawMessage) (token []byte, err error) {
+	hash, err := hashLookup(alg, RSAAlgs)
+	if err != nil {
+		return nil, err
+	}
+	digest := hash.New()
+
+	token, err = c.newToken(alg, encoding.EncodedLen(key.Size()), extraHeaders)
+	if err != nil {
+		return nil, err
+	}
+	digest.Write(token)
+
+	var sig []byte
+	buf := token[len(token):]
+	if alg != "" && alg[0] == 'P' {
+		sig, err = rsa.SignPSS(rand.Reader, key, hash, digest.Sum(buf), &pSSOptions)
+	} else {
+		sig, err = rsa.SignPKCS1v15(rand.Reader, key, hash, digest.Sum(buf))
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	token = append(token, '.')
+	encoding.Encode(token[len(token):cap(token)], sig)
+	c.audit(context.Background(), alg)
+	return token[:cap(token)], nil
+}
+
+// RSASignHeader applies RSASign on an HTTP request.
+// Specifically it sets a bearer token in the Authorization header.
+func (c *Claims) RSASignHeader(r *http.Request, alg string, key *rsa.PrivateKey) error {
+	token, err := c.RSASign(alg, key)
+	if err != nil {
+		return err
+	}
+	r.Header.Set("Authorization", "Bearer "+string(token))
+	return nil
+}
+
+// CheckRSA is the RSA part of KeyRegister.Check.
+func (keys *KeyRegister) checkRSA(c *Claims, alg string, body, sig []byte, trace *Trace) (*Claims, error) {
+	hash, err := hashLookup(alg, RSAAlgs)
+	if err != nil {
+		return nil, err
+	}
+	digest := hash.New()
+	digest.Write(body)
+	digestSum := digest.Sum(sig[len(sig):])
+
+	lo, hi := keyRange(keys.RSAIDs, c.KeyID, len(keys.RSAs))
+	for i := lo; i < hi; i++ {
+		start := trace.start()
+		key := keys.RSAs[i]
+		if alg != "" && alg[0] == 'P' {
+			err = rsa.VerifyPSS(key, hash, digestSum, sig, &pSSOptions)
+		} else {
+			err = rsa.VerifyPKCS1v15(key, hash, digestSum, sig)
+		}
+		trace.addKey(keys.RSAIDs, i, key, err == nil, start)
+		if err == nil {
+			return c.complete(trace)
+		}
+	}
+	return nil, ErrSigMiss
+}
+
+// RSAJWK returns the public key of an "RSA" JWK.
+func rsaJWK(j *jwk) (interface{}, error) {
+	n, err := intParam(j.N)
+	if err != nil {
+		return nil, err
+	}
+	e, err := intParam(j.E)
+	if err != nil {
+		return nil, err
+	}
+
+	return &rsa.PublicKey{N: n, E: int(e.Int64())}, nil
+}
+
+// RSAPrivateJWK returns the private key of an "RSA" JWK.
+func rsaPrivateJWK(j *jwk, pub *rsa.PublicKey) (interface{}, error) {
+	d, err := intParam(j.D)
+	if err != nil {
+		return nil, err
+	}
+	p, err := intParam(j.P)
+	if err != nil {
+		return nil, err
+	}
+	q, err := intParam(j.Q)
+	if err != nil {
+		return nil, err
+	}
+	private := &rsa.PrivateKey{PublicKey: *pub, D: d, Primes: []*big.Int{p, q}}
+	if err := private.Validate(); err != nil {
+		return nil, fmt.Errorf("jwt: JWK RSA private key unusable: %w", err)
+	}
+	private.Precompute()
+	// optional CRT parameters must match the computed ones
+	for _, param := range []struct {
+		p    *string
+		want *big.Int
+	}{
+		{j.DP, private.Precomputed.Dp},
+		{j.DQ, private.Precomputed.Dq},
+		{j.QI, private.Precomputed.Qinv},
+	} {
+		if param.p == nil {
+			continue
+		}
+		got, err := intParam(param.p)
+		if err != nil {
+			return nil, err
+		}
+		if got.Cmp(param.want) != 0 {
+			return nil, errJWKPrivate
+		}
+	}
+	return private, nil
+}
+
+// CheckRSAKey is the RSA part of Check.
+func checkRSAKey(token []byte, key *rsa.PublicKey) (*Claims, error) {
+	return RSACheck(token, key)
+}
@@ -0,0 +1,61 @@
+//go:build jwt_hmac_only
+// +build jwt_hmac_only
+
+package jwt
+
+import (
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"testing"
+)
+
+func TestHMACOnly(t *testing.T) {
+	var c Claims
+	c.Subject = "hmac"
+	token, err := c.HMACSign(HS256, []byte("guest"))
+	if err != nil {
+		t.Fatal("sign error:", err)
+	}
+	keys := KeyRegister{
+		Secrets: [][]byte{[]byte("guest")},
+		ECDSAs:  []*ecdsa.PublicKey{&testKeyEC256.PublicKey},
+		EdDSAs:  []ed25519.PublicKey{testKeyEd25519Public},
+		RSAs:    []*rsa.PublicKey{&testKeyRSA2048.PublicKey},
+	}
+	if got, err := keys.Check(token); err != nil {
+		t.Error("check error:", err)
+	} else if got.Subject != "hmac" {
+		t.Errorf("got subject %q, want %q", got.Subject, "hmac")
+	}
+
+	for _, alg := range []string{EdDSA, ES256, PS256, RS256} {
+		unsigned, err := c.FormatWithoutSign(alg)
+		if err != nil {
+			t.Fatal("format error:", err)
+		}
+		token := append(unsigned, ".c2ln"...)
+		if _, err := keys.Check(token); err != AlgError(alg) {
+			t.Errorf("%s: got error %v, want %v", alg, err, AlgError(alg))
+		}
+	}
+
+	for _, key := range []interface{}{testKeyEC256, testKeyEd25519Private, testKeyRSA2048} {
+		if _, err := NewSigner(EdDSA, key); err == nil {
+			t.Errorf("got signer for %T", key)
+		}
+	}
+	if _, err := Check(token, testKeyEd25519Public); err == nil {
+		t.Error("check with Ed25519 key: no error")
+	}
+
+	for _, jwk := range []string{
+		`{"kty":"EC","crv":"P-256","x":"AA","y":"AA"}`,
+		`{"kty":"OKP","crv":"Ed25519","x":"AA"}`,
+		`{"kty":"RSA","n":"AA","e":"AQAB"}`,
+	} {
+		if _, _, err := ParseJWK([]byte(jwk)); err == nil {
+			t.Errorf("JWK %s parsed", jwk)
+		}
+	}
+}
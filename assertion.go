@@ -0,0 +1,189 @@
+package jwt
+
+import (
+	"context"
+	"errors"
+	"net/url"
+	"sync"
+	"time"
+)
+
+// ClientAssertionType is the client_assertion_type value for authentication
+// with a JWT, as defined by RFC 7523, subsection 2.2.
+const ClientAssertionType = "urn:ietf:params:oauth:client-assertion-type:jwt-bearer"
+
+var errAssertionTTL = errors.New("jwt: assertion expiry too far in the future")
+
+// DefaultAssertionTTL is the time to live for assertions when not specified.
+const DefaultAssertionTTL = time.Minute
+
+// ClientAssertion produces JWTs for OAuth 2.0 client authentication, a.k.a.
+// private_key_jwt in OpenID Connect. See RFC 7523, section 3 for the claims.
+//
+// Multiple goroutines may invoke methods on a ClientAssertion simultaneously.
+// The exported fields must not be modified after first use.
+type ClientAssertion struct {
+	// ClientID is the issuer and the subject.
+	ClientID string
+
+	// TokenEndpoint is the audience.
+	TokenEndpoint string
+
+	// Signer has the client key, identified by KeyID. The empty string
+	// omits the key ID.
+	Signer Signer
+	KeyID  string
+
+	// TTL is the time to live. Zero defaults to DefaultAssertionTTL.
+	TTL time.Duration
+
+	// MaxReuse is the amount of time an assertion may be reused. Reuse
+	// ends before the last quarter of TTL at the latest, such that the
+	// server has time to accept. Zero causes a new assertion, with a
+	// unique "jti" claim, on each request. Servers may reject reuse.
+	MaxReuse time.Duration
+
+	mutex   sync.Mutex
+	token   string
+	renewAt time.Time
+}
+
+// Token returns an assertion, which is either new or reused conform MaxReuse.
+// Signatures run without lock, i.e., concurrent requests may each get a new
+// assertion when none is available for reuse.
+func (a *ClientAssertion) Token(ctx context.Context) (string, error) {
+	now := time.Now()
+	a.mutex.Lock()
+	if a.token != "" && now.Before(a.renewAt) {
+		token := a.token
+		a.mutex.Unlock()
+		return token, nil
+	}
+	a.mutex.Unlock()
+
+	c := &Claims{KeyID: a.KeyID}
+	c.Issuer = a.ClientID
+	c.Subject = a.ClientID
+	c.Audiences = []string{a.TokenEndpoint}
+	token, err := signAssertion(ctx, c, a.Signer, now, a.TTL)
+	if err != nil {
+		return "", err
+	}
+
+	if a.MaxReuse > 0 {
+		a.mutex.Lock()
+		renewAt := now.Add(a.MaxReuse)
+		ttl := c.Expires.Time().Sub(c.Issued.Time())
+		if limit := c.Expires.Time().Add(-ttl / 4); renewAt.After(limit) {
+			renewAt = limit
+		}
+		// keep the most recent when concurrent
+		if renewAt.After(a.renewAt) {
+			a.token = string(token)
+			a.renewAt = renewAt
+		}
+		a.mutex.Unlock()
+	}
+	return string(token), nil
+}
+
+// SetForm adds the client_assertion and the client_assertion_type to a token
+// request. The client_id parameter is included too.
+func (a *ClientAssertion) SetForm(ctx context.Context, form url.Values) error {
+	token, err := a.Token(ctx)
+	if err != nil {
+		return err
+	}
+	form.Set("client_id", a.ClientID)
+	form.Set("client_assertion_type", ClientAssertionType)
+	form.Set("client_assertion", token)
+	return nil
+}
+
+// SignAssertion completes c with the time claims and a unique "jti".
+func signAssertion(ctx context.Context, c *Claims, s Signer, now time.Time, ttl time.Duration) ([]byte, error) {
+	if ttl <= 0 {
+		ttl = DefaultAssertionTTL
+	}
+	c.StampTTL(now, ttl)
+	if err := c.GenerateID(); err != nil {
+		return nil, err
+	}
+	return c.SignContext(ctx, s)
+}
+
+// JWTBearerGrantType is the grant_type value for authorization grants with a
+// JWT, as defined by RFC 7523, subsection 2.1.
+const JWTBearerGrantType = "urn:ietf:params:oauth:grant-type:jwt-bearer"
+
+// BearerGrant produces JWTs for OAuth 2.0 authorization grants, as used by
+// Google service accounts among others. See RFC 7523, section 3 for the
+// claims.
+type BearerGrant struct {
+	// Issuer identifies the party which signs, e.g., the email address
+	// of a Google service account.
+	Issuer string
+
+	// Subject is the principal to authorize, if other than Issuer. RFC
+	// 7523 requires a subject, yet Google omits it without delegation.
+	Subject string
+
+	// TokenEndpoint is the audience.
+	TokenEndpoint string
+
+	// Scope is the optional "scope" claim, as required by Google.
+	Scope string
+
+	// Signer has the key, identified by KeyID. The empty string omits
+	// the key ID.
+	Signer Signer
+	KeyID  string
+
+	// TTL is the time to live. Zero defaults to DefaultAssertionTTL.
+	TTL time.Duration
+}
+
+// Token returns a new assertion.
+func (g *BearerGrant) Token(ctx context.Context) (string, error) {
+	c := &Claims{KeyID: g.KeyID}
+	c.Issuer = g.Issuer
+	c.Subject = g.Subject
+	c.Audiences = []string{g.TokenEndpoint}
+	if g.Scope != "" {
+		c.Set = map[string]interface{}{"scope": g.Scope}
+	}
+	token, err := signAssertion(ctx, c, g.Signer, time.Now(), g.TTL)
+	if err != nil {
+		return "", err
+	}
+	return string(token), nil
+}
+
+// SetForm adds the grant_type and a new assertion to a token request.
+func (g *BearerGrant) SetForm(ctx context.Context, form url.Values) error {
+	token, err := g.Token(ctx)
+	if err != nil {
+		return err
+	}
+	form.Set("grant_type", JWTBearerGrantType)
+	form.Set("assertion", token)
+	return nil
+}
+
+// BearerGrantPolicy returns the constraints of RFC 7523, section 3 for the
+// authorization server with tokenEndpoint. Assertions must name the token
+// endpoint, or any of moreAudiences, like the issuer identifier of the server,
+// as their audience. The issuers remain open for the caller to set. Assertions
+// with a time to live beyond maxTTL are rejected, with zero for no limit.
+func BearerGrantPolicy(maxTTL time.Duration, tokenEndpoint string, moreAudiences ...string) Policy {
+	return Policy{
+		Audiences: append([]string{tokenEndpoint}, moreAudiences...),
+		Require:   []string{issuer, subject, expires},
+		Func: func(c *Claims, now time.Time) error {
+			if maxTTL > 0 && c.Expires.Time().Sub(now) > maxTTL {
+				return errAssertionTTL
+			}
+			return nil
+		},
+	}
+}
@@ -0,0 +1,174 @@
+//go:build !jwt_hmac_only && !jwt_no_rsa && !jwt_no_ecdsa
+// +build !jwt_hmac_only,!jwt_no_rsa,!jwt_no_ecdsa
+
+package jwt
+
+import (
+	"context"
+	"crypto/rsa"
+	"errors"
+	"net/url"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestClientAssertion(t *testing.T) {
+	s, err := NewSigner(ES256, testKeyEC256)
+	if err != nil {
+		t.Fatal("signer error:", err)
+	}
+	a := ClientAssertion{
+		ClientID:      "s6BhdRkqt3",
+		TokenEndpoint: "https://server.example.com/token",
+		Signer:        s,
+		KeyID:         "k1",
+	}
+
+	form := make(url.Values)
+	if err := a.SetForm(context.Background(), form); err != nil {
+		t.Fatal("set form error:", err)
+	}
+	if got := form.Get("client_assertion_type"); got != ClientAssertionType {
+		t.Errorf("got client_assertion_type %q", got)
+	}
+	if got := form.Get("client_id"); got != "s6BhdRkqt3" {
+		t.Errorf("got client_id %q", got)
+	}
+	c, err := ECDSACheck([]byte(form.Get("client_assertion")), &testKeyEC256.PublicKey)
+	if err != nil {
+		t.Fatal("check error:", err)
+	}
+	if c.Issuer != a.ClientID || c.Subject != a.ClientID || !c.AcceptAudience(a.TokenEndpoint) || c.KeyID != "k1" {
+		t.Errorf("got claims %s", c.Raw)
+	}
+	if d := c.Expires.Time().Sub(c.Issued.Time()); d != DefaultAssertionTTL {
+		t.Errorf("got time to live %s, want %s", d, DefaultAssertionTTL)
+	}
+
+	first, err := a.Token(context.Background())
+	if err != nil {
+		t.Fatal("token error:", err)
+	}
+	second, err := a.Token(context.Background())
+	if err != nil {
+		t.Fatal("token error:", err)
+	}
+	if first == second {
+		t.Error("assertion reused without MaxReuse")
+	}
+
+	a.MaxReuse = 30 * time.Second
+	first, err = a.Token(context.Background())
+	if err != nil {
+		t.Fatal("token error:", err)
+	}
+	second, err = a.Token(context.Background())
+	if err != nil {
+		t.Fatal("token error:", err)
+	}
+	if first != second {
+		t.Error("assertion not reused within MaxReuse")
+	}
+}
+
+func TestClientAssertionReuseBeyondTTL(t *testing.T) {
+	s, err := NewSigner(ES256, testKeyEC256)
+	if err != nil {
+		t.Fatal("signer error:", err)
+	}
+	a := ClientAssertion{ClientID: "s6BhdRkqt3", Signer: s, TTL: time.Minute, MaxReuse: time.Hour}
+	token, err := a.Token(context.Background())
+	if err != nil {
+		t.Fatal("token error:", err)
+	}
+	c, err := ECDSACheck([]byte(token), &testKeyEC256.PublicKey)
+	if err != nil {
+		t.Fatal("check error:", err)
+	}
+	if limit := c.Expires.Time().Add(-15 * time.Second); a.renewAt.After(limit) {
+		t.Errorf("got reuse until %s, want before %s with expiry %s", a.renewAt, limit, c.Expires.Time())
+	}
+}
+
+func TestClientAssertionConcurrent(t *testing.T) {
+	s, err := NewSigner(ES256, testKeyEC256)
+	if err != nil {
+		t.Fatal("signer error:", err)
+	}
+	block := &blockSigner{Signer: s, release: make(chan struct{})}
+	a := ClientAssertion{ClientID: "s6BhdRkqt3", Signer: block}
+
+	done := make(chan error)
+	for i := 0; i < 2; i++ {
+		go func() {
+			_, err := a.Token(context.Background())
+			done <- err
+		}()
+	}
+	// both sign at once, without lock
+	for atomic.LoadInt32(&block.calls) != 2 {
+		time.Sleep(time.Millisecond)
+	}
+	close(block.release)
+	for i := 0; i < 2; i++ {
+		if err := <-done; err != nil {
+			t.Error("token error:", err)
+		}
+	}
+}
+
+func TestBearerGrant(t *testing.T) {
+	s, err := NewSigner(RS256, testKeyRSA2048)
+	if err != nil {
+		t.Fatal("signer error:", err)
+	}
+	g := BearerGrant{
+		Issuer:        "svc@project.iam.gserviceaccount.com",
+		Subject:       "alice@example.com",
+		TokenEndpoint: "https://oauth2.googleapis.com/token",
+		Scope:         "https://www.googleapis.com/auth/drive.readonly",
+		Signer:        s,
+		TTL:           time.Hour,
+	}
+	form := make(url.Values)
+	if err := g.SetForm(context.Background(), form); err != nil {
+		t.Fatal("set form error:", err)
+	}
+	if got := form.Get("grant_type"); got != JWTBearerGrantType {
+		t.Errorf("got grant_type %q", got)
+	}
+
+	v := Verifier{
+		Keys:   &KeyRegister{RSAs: []*rsa.PublicKey{&testKeyRSA2048.PublicKey}},
+		Policy: BearerGrantPolicy(time.Hour+time.Minute, g.TokenEndpoint),
+	}
+	v.Issuers = []string{g.Issuer}
+	c, err := v.Check([]byte(form.Get("assertion")))
+	if err != nil {
+		t.Fatal("check error:", err)
+	}
+	if scope, _ := c.String("scope"); scope != g.Scope || c.Subject != g.Subject {
+		t.Errorf("got claims %s", c.Raw)
+	}
+
+	v.Policy = BearerGrantPolicy(time.Minute, g.TokenEndpoint)
+	if _, err := v.Check([]byte(form.Get("assertion"))); err != errAssertionTTL {
+		t.Errorf("got error %v, want %v", err, errAssertionTTL)
+	}
+	v.Policy = BearerGrantPolicy(0, "https://other.example.com/token")
+	if _, err := v.Check([]byte(form.Get("assertion"))); !errors.Is(err, ErrAudience) {
+		t.Errorf("got error %v for other token endpoint, want %v", err, ErrAudience)
+	}
+
+	// no subject
+	g.Subject = ""
+	token, err := g.Token(context.Background())
+	if err != nil {
+		t.Fatal("token error:", err)
+	}
+	v.Policy = BearerGrantPolicy(0, g.TokenEndpoint)
+	if _, err := v.Check([]byte(token)); !errors.Is(err, ErrClaimMiss) {
+		t.Errorf("got error %v, want %v", err, ErrClaimMiss)
+	}
+}
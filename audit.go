@@ -0,0 +1,32 @@
+package jwt
+
+import "context"
+
+// SignEvent describes the production of a token, for audits on key usage.
+type SignEvent struct {
+	Alg       string       // algorithm
+	KeyID     string       // "kid" header, if any
+	Subject   string       // "sub" claim, if any
+	Audiences []string     // "aud" claim, if any
+	Expires   *NumericTime // "exp" claim, if any
+	Issued    *NumericTime // "iat" claim, if any
+	ID        string       // "jti" claim, if any
+
+	// RequestID is the value from WithRequestID, if any. Only
+	// Claims.SignContext has a context to read from.
+	RequestID string
+}
+
+// AuditSign is invoked for each token which the Sign methods produce, after the
+// signature completed. Failed signatures and FormatWithoutSign are not passed.
+// Any modifications should be made before first use to prevent data races,
+// i.e., set from either main or init. Implementations must not block, as
+// signing waits for them to return.
+var AuditSign func(SignEvent)
+
+type requestIDKey struct{}
+
+// WithRequestID returns a context with a request ID for AuditSign.
+func WithRequestID(ctx context.Context, requestID string) context.Context {
+	return context.WithValue(ctx, requestIDKey{}, requestID)
+}
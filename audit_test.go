@@ -0,0 +1,53 @@
+//go:build !jwt_hmac_only
+// +build !jwt_hmac_only
+
+package jwt
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestAuditSign(t *testing.T) {
+	var got []SignEvent
+	AuditSign = func(e SignEvent) { got = append(got, e) }
+	defer func() { AuditSign = nil }()
+
+	c := &Claims{KeyID: "k1"}
+	c.Subject = "u1"
+	c.Audiences = []string{"api"}
+	c.Expires = NewNumericTime(time.Unix(1600000000, 0))
+	c.Issued = NewNumericTime(time.Unix(1599996400, 0))
+	c.ID = "t1"
+	if _, err := c.HMACSign(HS256, []byte("guest")); err != nil {
+		t.Fatal("sign error:", err)
+	}
+	s, err := NewSigner(EdDSA, testKeyEd25519Private)
+	if err != nil {
+		t.Fatal("signer error:", err)
+	}
+	ctx := WithRequestID(context.Background(), "req-7")
+	if _, err := c.SignContext(ctx, s); err != nil {
+		t.Fatal("sign error:", err)
+	}
+	if _, err := c.HMACSign(HS256, []byte("guest"), []byte("[]")); err == nil {
+		t.Fatal("no error for malformed header")
+	}
+	if _, err := c.FormatWithoutSign(HS256); err != nil {
+		t.Fatal("format error:", err)
+	}
+	if _, err := c.SignContext(ctx, failSigner{s, errors.New("KMS down")}); err == nil {
+		t.Fatal("no error for failed signature")
+	}
+
+	want := []SignEvent{
+		{HS256, "k1", "u1", []string{"api"}, c.Expires, c.Issued, "t1", ""},
+		{EdDSA, "k1", "u1", []string{"api"}, c.Expires, c.Issued, "t1", "req-7"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got events %+v, want %+v", got, want)
+	}
+}
@@ -0,0 +1,194 @@
+package jwt
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strings"
+)
+
+// ErrDenied signals an authorization decision against the request.
+var ErrDenied = errors.New("jwt: access denied by policy")
+
+// Authorizer decides on requests with verified claims. Any error rejects the
+// request. Implementations should wrap ErrDenied for policy decisions, as
+// opposed to evaluation failures.
+type Authorizer interface {
+	Authorize(r *http.Request, c *Claims) error
+}
+
+// AuthorizerFunc is an Authorizer.
+type AuthorizerFunc func(r *http.Request, c *Claims) error
+
+// Authorize implements the Authorizer interface.
+func (f AuthorizerFunc) Authorize(r *http.Request, c *Claims) error { return f(r, c) }
+
+// OPA is an Authorizer with an Open Policy Agent over HTTP, typically run as a
+// sidecar. Embedded evaluation, with the Go SDK of OPA, is not supported as it
+// would add dependencies. The input document has the claims, and the HTTP
+// method and path of the request, e.g.,
+// {"claims":{"sub":"u1"},"method":"GET","path":["orders","7"]}. The policy
+// decision must be a boolean.
+//
+//	package httpapi.authz
+//
+//	default allow := false
+//
+//	allow if {
+//		input.method == "GET"
+//		input.path = ["orders", _]
+//		"orders:read" in split(input.claims.scope, " ")
+//	}
+type OPA struct {
+	// URL locates the decision with the Data API, e.g.,
+	// "http://localhost:8181/v1/data/httpapi/authz/allow".
+	URL string
+
+	// Client is used for the HTTP requests. Nil defaults to
+	// http.DefaultClient.
+	Client *http.Client
+}
+
+// Authorize implements the Authorizer interface.
+func (opa *OPA) Authorize(r *http.Request, c *Claims) error {
+	var input struct {
+		Input struct {
+			Claims json.RawMessage `json:"claims"`
+			Method string          `json:"method"`
+			Path   []string        `json:"path"`
+		} `json:"input"`
+	}
+	input.Input.Claims = c.Raw
+	if len(c.Raw) == 0 {
+		input.Input.Claims = json.RawMessage("{}")
+	}
+	input.Input.Method = r.Method
+	input.Input.Path = strings.Split(strings.Trim(r.URL.Path, "/"), "/")
+	body, err := json.Marshal(&input)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(r.Context(), http.MethodPost, opa.URL, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	client := opa.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return fmt.Errorf("jwt: OPA unavailable: %w", err)
+	}
+	defer resp.Body.Close()
+	defer io.Copy(ioutil.Discard, io.LimitReader(resp.Body, jwksLimit))
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("jwt: OPA %q got HTTP %q", opa.URL, resp.Status)
+	}
+	var decision struct {
+		Result *bool `json:"result"`
+	}
+	if err := json.NewDecoder(io.LimitReader(resp.Body, jwksLimit)).Decode(&decision); err != nil {
+		return fmt.Errorf("jwt: OPA %q response: %w", opa.URL, err)
+	}
+	if decision.Result == nil {
+		// undefined decision
+		return fmt.Errorf("jwt: OPA %q without result", opa.URL)
+	}
+	if !*decision.Result {
+		return ErrDenied
+	}
+	return nil
+}
+
+// CasbinEnforcer has the enforcement method of *casbin.Enforcer, from package
+// github.com/casbin/casbin/v2.
+type CasbinEnforcer interface {
+	Enforce(rvals ...interface{}) (bool, error)
+}
+
+// Casbin is an Authorizer with Casbin enforcement requests. The object is the
+// URL path and the action is the HTTP method. The subject is the "sub" claim,
+// followed by each of the roles until one passes. The request tuple is either
+// (subject, object, action), or (subject, domain, object, action) when
+// TenantClaim is set. Denials include the last tuple in the error message, for
+// logging. Handler keeps such details out of the response, and passes them to
+// OnDeny instead.
+//
+// For gRPC, AuthorizeMethod applies the same enforcement on a method name. See
+// example/grpc for unary and stream interceptors, which are not part of this
+// package to keep it free of dependencies.
+type Casbin struct {
+	Enforcer CasbinEnforcer
+
+	// RolesClaim names the claim with the roles, as a JSON array of
+	// strings, or as a space separated string. The empty string omits
+	// the roles.
+	RolesClaim string
+
+	// TenantClaim names the claim with the domain, if any.
+	TenantClaim string
+}
+
+// Authorize implements the Authorizer interface.
+func (cb *Casbin) Authorize(r *http.Request, c *Claims) error {
+	return cb.enforce(c, r.URL.Path, r.Method)
+}
+
+// AuthorizeMethod applies the enforcement on a gRPC method, in the form of
+// "/package.Service/Method", as found in grpc.UnaryServerInfo and the likes.
+// The object is the service name, e.g., "package.Service", and the action is
+// the method name.
+func (cb *Casbin) AuthorizeMethod(fullMethod string, c *Claims) error {
+	i := strings.LastIndexByte(fullMethod, '/')
+	if i <= 0 || fullMethod[0] != '/' {
+		return fmt.Errorf("jwt: malformed gRPC method %q", fullMethod)
+	}
+	return cb.enforce(c, fullMethod[1:i], fullMethod[i+1:])
+}
+
+func (cb *Casbin) enforce(c *Claims, object, action string) error {
+	subjects := []string{c.Subject}
+	if cb.RolesClaim != "" {
+		if s, ok := c.String(cb.RolesClaim); ok {
+			subjects = append(subjects, strings.Fields(s)...)
+		} else {
+			subjects = append(subjects, stringsFromArray(c.Set[cb.RolesClaim])...)
+		}
+	}
+
+	var rvals []interface{}
+	for _, sub := range subjects {
+		if sub == "" {
+			continue
+		}
+		if cb.TenantClaim == "" {
+			rvals = []interface{}{sub, object, action}
+		} else {
+			tenant, ok := c.String(cb.TenantClaim)
+			if !ok {
+				return fmt.Errorf("%w: no %q claim", ErrDenied, cb.TenantClaim)
+			}
+			rvals = []interface{}{sub, tenant, object, action}
+		}
+
+		pass, err := cb.Enforcer.Enforce(rvals...)
+		if err != nil {
+			return fmt.Errorf("jwt: Casbin enforcement: %w", err)
+		}
+		if pass {
+			return nil
+		}
+	}
+	if rvals == nil {
+		return fmt.Errorf("%w: no subject", ErrDenied)
+	}
+	return fmt.Errorf("%w: Casbin request %q", ErrDenied, rvals)
+}
@@ -0,0 +1,180 @@
+//go:build !jwt_hmac_only
+// +build !jwt_hmac_only
+
+package jwt
+
+import (
+	"crypto/ed25519"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestOPA(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body struct {
+			Input struct {
+				Claims map[string]interface{} `json:"claims"`
+				Method string                 `json:"method"`
+				Path   []string               `json:"path"`
+			} `json:"input"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			t.Error("OPA request body:", err)
+		}
+		if want := []string{"orders", "7"}; !reflect.DeepEqual(body.Input.Path, want) {
+			t.Errorf("got input path %q, want %q", body.Input.Path, want)
+		}
+		allow := body.Input.Method == "GET" && body.Input.Claims["sub"] == "u1"
+		json.NewEncoder(w).Encode(map[string]bool{"result": allow})
+	}))
+	defer srv.Close()
+
+	handler := Handler{
+		Target:     http.HandlerFunc(func(http.ResponseWriter, *http.Request) {}),
+		Keys:       &KeyRegister{EdDSAs: []ed25519.PublicKey{testKeyEd25519Public}},
+		Authorizer: &OPA{URL: srv.URL + "/v1/data/httpapi/authz/allow"},
+	}
+
+	golden := []struct {
+		method, subject string
+		wantCode        int
+	}{
+		{"GET", "u1", http.StatusOK},
+		{"DELETE", "u1", http.StatusForbidden},
+		{"GET", "u2", http.StatusForbidden},
+	}
+	for _, gold := range golden {
+		c := &Claims{Registered: Registered{Subject: gold.subject}}
+		req := httptest.NewRequest(gold.method, "/orders/7", nil)
+		if err := c.EdDSASignHeader(req, testKeyEd25519Private); err != nil {
+			t.Fatal("sign error:", err)
+		}
+		resp := httptest.NewRecorder()
+		handler.ServeHTTP(resp, req)
+		if resp.Code != gold.wantCode {
+			t.Errorf("%s by %q: got HTTP %d, want %d", gold.method, gold.subject, resp.Code, gold.wantCode)
+		}
+		if gold.wantCode == http.StatusForbidden && strings.Contains(resp.Body.String(), gold.subject) {
+			t.Errorf("%s by %q: got response %q, want no claims", gold.method, gold.subject, resp.Body.String())
+		}
+	}
+
+	// undefined decision
+	handler.Authorizer = &OPA{URL: srv.URL + "/v1/data/absent"}
+	srv.Config.Handler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("{}"))
+	})
+	req := httptest.NewRequest("GET", "/orders/7", nil)
+	if err := new(Claims).EdDSASignHeader(req, testKeyEd25519Private); err != nil {
+		t.Fatal("sign error:", err)
+	}
+	resp := httptest.NewRecorder()
+	handler.ServeHTTP(resp, req)
+	if resp.Code != http.StatusInternalServerError {
+		t.Errorf("got HTTP %d for undefined decision, want 500", resp.Code)
+	}
+	if strings.Contains(resp.Body.String(), srv.URL) {
+		t.Errorf("got response %q, want no OPA address", resp.Body.String())
+	}
+}
+
+// CasbinTable enforces a fixed list of request tuples.
+type casbinTable [][]interface{}
+
+func (table casbinTable) Enforce(rvals ...interface{}) (bool, error) {
+	for _, row := range table {
+		if reflect.DeepEqual(row, rvals) {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func TestCasbin(t *testing.T) {
+	table := casbinTable{
+		{"u1", "/orders", "GET"},
+		{"admin", "/orders", "DELETE"},
+		{"u2", "acme", "/orders", "GET"},
+	}
+
+	golden := []struct {
+		casbin Casbin
+		claims string
+		method string
+		err    string
+	}{
+		{Casbin{}, `{"sub":"u1"}`, "GET", ""},
+		{Casbin{}, `{"sub":"u1"}`, "DELETE", `jwt: access denied by policy: Casbin request ["u1" "/orders" "DELETE"]`},
+		{Casbin{RolesClaim: "roles"}, `{"sub":"u1","roles":["staff","admin"]}`, "DELETE", ""},
+		{Casbin{RolesClaim: "roles"}, `{"sub":"u1","roles":"staff admin"}`, "DELETE", ""},
+		{Casbin{RolesClaim: "roles"}, `{"roles":["staff"]}`, "DELETE", `jwt: access denied by policy: Casbin request ["staff" "/orders" "DELETE"]`},
+		{Casbin{}, `{}`, "GET", "jwt: access denied by policy: no subject"},
+		{Casbin{TenantClaim: "tid"}, `{"sub":"u2","tid":"acme"}`, "GET", ""},
+		{Casbin{TenantClaim: "tid"}, `{"sub":"u2"}`, "GET", `jwt: access denied by policy: no "tid" claim`},
+	}
+	for _, gold := range golden {
+		c := new(Claims)
+		c.Raw = json.RawMessage(gold.claims)
+		if err := c.applyPayload(); err != nil {
+			t.Fatal(err)
+		}
+		gold.casbin.Enforcer = table
+		err := gold.casbin.Authorize(httptest.NewRequest(gold.method, "/orders", nil), c)
+		switch {
+		case gold.err == "" && err != nil:
+			t.Errorf("%s %s: got error %v", gold.method, gold.claims, err)
+		case gold.err != "" && (err == nil || err.Error() != gold.err):
+			t.Errorf("%s %s: got error %v, want %s", gold.method, gold.claims, err, gold.err)
+		}
+	}
+}
+
+func TestCasbinMethod(t *testing.T) {
+	cb := Casbin{Enforcer: casbinTable{{"u1", "orders.v1.Orders", "GetOrder"}}}
+	c := &Claims{Registered: Registered{Subject: "u1"}}
+	if err := cb.AuthorizeMethod("/orders.v1.Orders/GetOrder", c); err != nil {
+		t.Error("got error:", err)
+	}
+	const want = `jwt: access denied by policy: Casbin request ["u1" "orders.v1.Orders" "DeleteOrder"]`
+	if err := cb.AuthorizeMethod("/orders.v1.Orders/DeleteOrder", c); err == nil || err.Error() != want {
+		t.Errorf("got error %v, want %s", err, want)
+	}
+	if err := cb.AuthorizeMethod("GetOrder", c); err == nil || errors.Is(err, ErrDenied) {
+		t.Errorf("got error %v for malformed method, want evaluation failure", err)
+	}
+}
+
+func TestCasbinOnDeny(t *testing.T) {
+	var denied error
+	handler := Handler{
+		Target:     http.HandlerFunc(func(http.ResponseWriter, *http.Request) {}),
+		Keys:       &KeyRegister{EdDSAs: []ed25519.PublicKey{testKeyEd25519Public}},
+		Authorizer: &Casbin{Enforcer: casbinTable{{"u1", "/orders", "GET"}}},
+		OnDeny: func(r *http.Request, c *Claims, err error) {
+			denied = err
+		},
+	}
+
+	c := &Claims{Registered: Registered{Subject: "u1"}}
+	req := httptest.NewRequest("DELETE", "/orders", nil)
+	if err := c.EdDSASignHeader(req, testKeyEd25519Private); err != nil {
+		t.Fatal("sign error:", err)
+	}
+	resp := httptest.NewRecorder()
+	handler.ServeHTTP(resp, req)
+	if resp.Code != http.StatusForbidden {
+		t.Errorf("got HTTP %d, want 403", resp.Code)
+	}
+	const want = `jwt: access denied by policy: Casbin request ["u1" "/orders" "DELETE"]`
+	if denied == nil || denied.Error() != want {
+		t.Errorf("got OnDeny error %v, want %s", denied, want)
+	}
+	if strings.Contains(resp.Body.String(), "Casbin") {
+		t.Errorf("got response %q, want no request tuple", resp.Body.String())
+	}
+}
@@ -1,6 +1,10 @@
+//go:build !jwt_hmac_only && !jwt_no_rsa && !jwt_no_ecdsa
+// +build !jwt_hmac_only,!jwt_no_rsa,!jwt_no_ecdsa
+
 package jwt
 
 import (
+	"context"
 	"crypto/ecdsa"
 	"crypto/rsa"
 	"fmt"
@@ -182,3 +186,34 @@ func BenchmarkRSA(b *testing.B) {
 		})
 	}
 }
+
+func BenchmarkTemplate(b *testing.B) {
+	s, err := NewSigner(HS256, []byte("guest"))
+	if err != nil {
+		b.Fatal(err)
+	}
+	iss := NewIssuer(s, "")
+	iss.Name = "benchmark"
+	iss.TTL = time.Hour
+	extra := map[string]interface{}{"fleet": "eu-west", "firmware": "4.2.1"}
+
+	b.Run("issuer", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			if _, err := iss.Issue("device", extra); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+	b.Run("template", func(b *testing.B) {
+		tmpl, err := iss.Template(extra)
+		if err != nil {
+			b.Fatal(err)
+		}
+		ctx := context.Background()
+		for i := 0; i < b.N; i++ {
+			if _, err := tmpl.Issue(ctx, "device"); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+}
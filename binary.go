@@ -0,0 +1,271 @@
+package jwt
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"math"
+	"sort"
+)
+
+// BinaryVersion identifies the encoding of MarshalBinary.
+const binaryVersion = 2
+
+var errBinary = errors.New("jwt: malformed binary claims")
+
+// Value tags of the binary encoding.
+const (
+	tagNull = iota
+	tagFalse
+	tagTrue
+	tagNumber
+	tagString
+	tagArray
+	tagObject
+)
+
+// MarshalBinary honors the encoding.BinaryMarshaler interface. The encoding is
+// compact, and decoding needs no JSON parsing, which suits caches for verified
+// claims, like Redis or memcached. The format is not stable across versions of
+// this package; data from other versions is rejected by UnmarshalBinary.
+func (c *Claims) MarshalBinary() ([]byte, error) {
+	buf := []byte{binaryVersion}
+	for _, s := range []string{c.Issuer, c.Subject, c.ID, c.SessionID, c.Nonce, c.KeyID} {
+		buf = appendBinaryString(buf, s)
+	}
+	if c.Audiences == nil {
+		buf = append(buf, 0)
+	} else {
+		buf = append(buf, 1)
+		buf = appendUvarint(buf, uint64(len(c.Audiences)))
+		for _, s := range c.Audiences {
+			buf = appendBinaryString(buf, s)
+		}
+	}
+	for _, n := range []*NumericTime{c.Expires, c.NotBefore, c.Issued} {
+		if n == nil {
+			buf = append(buf, tagNull)
+		} else {
+			buf = appendBinaryValue(buf, float64(*n))
+		}
+	}
+	if c.Events == nil {
+		buf = append(buf, tagNull)
+	} else {
+		events := make(map[string]interface{}, len(c.Events))
+		for name, payload := range c.Events {
+			events[name] = payload
+		}
+		buf = appendBinaryValue(buf, events)
+	}
+	if c.Set == nil {
+		buf = append(buf, tagNull)
+	} else {
+		buf = appendBinaryValue(buf, c.Set)
+	}
+	for _, raw := range [][]byte{c.Raw, c.RawHeader, c.RawSignature} {
+		buf = appendUvarint(buf, uint64(len(raw)))
+		buf = append(buf, raw...)
+	}
+	buf = appendUvarint(buf, uint64(c.Compress))
+	return buf, nil
+}
+
+func appendUvarint(buf []byte, x uint64) []byte {
+	var tmp [binary.MaxVarintLen64]byte
+	n := binary.PutUvarint(tmp[:], x)
+	return append(buf, tmp[:n]...)
+}
+
+func appendBinaryString(buf []byte, s string) []byte {
+	buf = appendUvarint(buf, uint64(len(s)))
+	return append(buf, s...)
+}
+
+// AppendBinaryValue supports the types from encoding/json.
+func appendBinaryValue(buf []byte, v interface{}) []byte {
+	switch v := v.(type) {
+	case nil:
+		return append(buf, tagNull)
+	case bool:
+		if v {
+			return append(buf, tagTrue)
+		}
+		return append(buf, tagFalse)
+	case float64:
+		var bits [8]byte
+		binary.BigEndian.PutUint64(bits[:], math.Float64bits(v))
+		return append(append(buf, tagNumber), bits[:]...)
+	case string:
+		return appendBinaryString(append(buf, tagString), v)
+	case []interface{}:
+		buf = appendUvarint(append(buf, tagArray), uint64(len(v)))
+		for _, o := range v {
+			buf = appendBinaryValue(buf, o)
+		}
+		return buf
+	case map[string]interface{}:
+		// sorted for deterministic output
+		names := make([]string, 0, len(v))
+		for name := range v {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		buf = appendUvarint(append(buf, tagObject), uint64(len(v)))
+		for _, name := range names {
+			buf = appendBinaryString(buf, name)
+			buf = appendBinaryValue(buf, v[name])
+		}
+		return buf
+	default:
+		// other types fall back to their JSON representation
+		bytes, err := json.Marshal(v)
+		if err != nil {
+			return append(buf, tagNull)
+		}
+		var o interface{}
+		if json.Unmarshal(bytes, &o) != nil || o == nil {
+			return append(buf, tagNull)
+		}
+		return appendBinaryValue(buf, o)
+	}
+}
+
+// UnmarshalBinary honors the encoding.BinaryUnmarshaler interface.
+func (c *Claims) UnmarshalBinary(data []byte) error {
+	if len(data) == 0 || data[0] != binaryVersion {
+		return errBinary
+	}
+	d := binaryDecoder{data[1:], nil}
+
+	*c = Claims{}
+	for _, p := range []*string{&c.Issuer, &c.Subject, &c.ID, &c.SessionID, &c.Nonce, &c.KeyID} {
+		*p = d.string()
+	}
+	if d.byte() != 0 {
+		n := d.length()
+		c.Audiences = make([]string, n)
+		for i := range c.Audiences {
+			c.Audiences[i] = d.string()
+		}
+	}
+	for _, p := range []**NumericTime{&c.Expires, &c.NotBefore, &c.Issued} {
+		if f, ok := d.value().(float64); ok {
+			*p = (*NumericTime)(&f)
+		}
+	}
+	if events, ok := d.value().(map[string]interface{}); ok {
+		c.Events = make(map[string]map[string]interface{}, len(events))
+		for name, payload := range events {
+			c.Events[name], _ = payload.(map[string]interface{})
+		}
+	}
+	c.Set, _ = d.value().(map[string]interface{})
+	for _, p := range []*[]byte{(*[]byte)(&c.Raw), (*[]byte)(&c.RawHeader), &c.RawSignature} {
+		if b := d.bytes(); len(b) != 0 {
+			*p = append([]byte(nil), b...)
+		}
+	}
+	c.Compress = d.count()
+
+	if d.err == nil && len(d.buf) != 0 {
+		d.err = errBinary
+	}
+	return d.err
+}
+
+// BinaryDecoder reads the encoding of MarshalBinary. Any error is sticky.
+type binaryDecoder struct {
+	buf []byte
+	err error
+}
+
+func (d *binaryDecoder) fail() {
+	d.err = errBinary
+	d.buf = nil
+}
+
+func (d *binaryDecoder) byte() byte {
+	if len(d.buf) == 0 {
+		d.fail()
+		return 0
+	}
+	b := d.buf[0]
+	d.buf = d.buf[1:]
+	return b
+}
+
+// Length reads a count, which is bound by the remaining data.
+func (d *binaryDecoder) length() int {
+	x, n := binary.Uvarint(d.buf)
+	if n <= 0 || x > uint64(len(d.buf)) {
+		d.fail()
+		return 0
+	}
+	d.buf = d.buf[n:]
+	return int(x)
+}
+
+// Count reads a non-negative int.
+func (d *binaryDecoder) count() int {
+	x, n := binary.Uvarint(d.buf)
+	if n <= 0 || x > math.MaxInt32 {
+		d.fail()
+		return 0
+	}
+	d.buf = d.buf[n:]
+	return int(x)
+}
+
+func (d *binaryDecoder) bytes() []byte {
+	n := d.length()
+	if n > len(d.buf) {
+		d.fail()
+		return nil
+	}
+	b := d.buf[:n]
+	d.buf = d.buf[n:]
+	return b
+}
+
+func (d *binaryDecoder) string() string {
+	return string(d.bytes())
+}
+
+func (d *binaryDecoder) value() interface{} {
+	switch d.byte() {
+	case tagNull:
+		return nil
+	case tagFalse:
+		return false
+	case tagTrue:
+		return true
+	case tagNumber:
+		if len(d.buf) < 8 {
+			d.fail()
+			return nil
+		}
+		f := math.Float64frombits(binary.BigEndian.Uint64(d.buf))
+		d.buf = d.buf[8:]
+		return f
+	case tagString:
+		return d.string()
+	case tagArray:
+		a := make([]interface{}, d.length())
+		for i := range a {
+			a[i] = d.value()
+		}
+		return a
+	case tagObject:
+		n := d.length()
+		m := make(map[string]interface{}, n)
+		for i := 0; i < n; i++ {
+			name := d.string()
+			m[name] = d.value()
+		}
+		return m
+	default:
+		d.fail()
+		return nil
+	}
+}
@@ -0,0 +1,57 @@
+//go:build !jwt_hmac_only
+// +build !jwt_hmac_only
+
+package jwt
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestClaimsBinary(t *testing.T) {
+	c := new(Claims)
+	c.Issuer = "a"
+	c.Audiences = []string{"b", "c"}
+	c.Expires = NewNumericTime(time.Unix(1600000000, 500000000))
+	c.Nonce = "n1"
+	c.Events = map[string]map[string]interface{}{"urn:example:event": {"reason": "test"}}
+	c.Set = map[string]interface{}{
+		"null":   nil,
+		"bool":   true,
+		"number": 1.5,
+		"array":  []interface{}{"x", false, []interface{}{}},
+		"object": map[string]interface{}{"y": 2.0},
+	}
+	token, err := c.EdDSASign(testKeyEd25519Private, []byte(`{"typ":"JWT"}`))
+	if err != nil {
+		t.Fatal("sign error:", err)
+	}
+	want, err := EdDSACheck(token, testKeyEd25519Public)
+	if err != nil {
+		t.Fatal("check error:", err)
+	}
+
+	want.Compress = 1024
+	data, err := want.MarshalBinary()
+	if err != nil {
+		t.Fatal("marshal error:", err)
+	}
+	got := new(Claims)
+	if err := got.UnmarshalBinary(data); err != nil {
+		t.Fatal("unmarshal error:", err)
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %+v\nwant %+v", got, want)
+	}
+
+	for i := range data {
+		if err := new(Claims).UnmarshalBinary(data[:i]); err != errBinary {
+			t.Errorf("got error %v for %d bytes, want %v", err, i, errBinary)
+		}
+	}
+	data[0]++
+	if err := new(Claims).UnmarshalBinary(data); err != errBinary {
+		t.Errorf("got error %v for other version, want %v", err, errBinary)
+	}
+}
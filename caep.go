@@ -0,0 +1,127 @@
+package jwt
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// CAEP event types from the “OpenID Continuous Access Evaluation Profile 1.0”.
+const (
+	CAEPSessionRevoked       = "https://schemas.openid.net/secevent/caep/event-type/session-revoked"
+	CAEPCredentialChange     = "https://schemas.openid.net/secevent/caep/event-type/credential-change"
+	CAEPAssuranceLevelChange = "https://schemas.openid.net/secevent/caep/event-type/assurance-level-change"
+)
+
+// SubjectID is a subject identifier conform RFC 9493. The Format determines
+// which of the other fields apply:
+//
+//	"account"       URI
+//	"email"         Email
+//	"iss_sub"       Issuer and Subject
+//	"opaque"        ID
+//	"phone_number"  PhoneNumber
+//	"did" & "uri"   URI
+type SubjectID struct {
+	Format      string `json:"format"`
+	Email       string `json:"email,omitempty"`
+	Issuer      string `json:"iss,omitempty"`
+	Subject     string `json:"sub,omitempty"`
+	ID          string `json:"id,omitempty"`
+	PhoneNumber string `json:"phone_number,omitempty"`
+	URI         string `json:"uri,omitempty"`
+}
+
+// CAEPEvent is the payload of CAEP events. Fields which don't apply to the
+// respective event type must be left zero.
+type CAEPEvent struct {
+	// Subject identifies the principal of the event.
+	Subject *SubjectID `json:"subject,omitempty"`
+
+	// EventTimestamp is the moment in time of the event.
+	EventTimestamp *NumericTime `json:"event_timestamp,omitempty"`
+
+	// InitiatingEntity is one of "admin", "user", "policy" or "system".
+	InitiatingEntity string `json:"initiating_entity,omitempty"`
+
+	// Reasons map language tags to text.
+	ReasonAdmin map[string]string `json:"reason_admin,omitempty"`
+	ReasonUser  map[string]string `json:"reason_user,omitempty"`
+
+	// CredentialType and ChangeType are required for credential-change.
+	CredentialType string `json:"credential_type,omitempty"`
+	ChangeType     string `json:"change_type,omitempty"`
+	FriendlyName   string `json:"friendly_name,omitempty"`
+	X509Issuer     string `json:"x509_issuer,omitempty"`
+	X509Serial     string `json:"x509_serial,omitempty"`
+	FIDO2AAGUID    string `json:"fido2_aaguid,omitempty"`
+
+	// Namespace and CurrentLevel are required for assurance-level-change.
+	Namespace       string `json:"namespace,omitempty"`
+	CurrentLevel    string `json:"current_level,omitempty"`
+	PreviousLevel   string `json:"previous_level,omitempty"`
+	ChangeDirection string `json:"change_direction,omitempty"`
+}
+
+// AddCAEP sets an event of eventType, e.g., CAEPSessionRevoked.
+func (s *SET) AddCAEP(eventType string, e *CAEPEvent) error {
+	if err := e.validate(eventType); err != nil {
+		return err
+	}
+	bytes, err := json.Marshal(e)
+	if err != nil {
+		return err
+	}
+	var payload map[string]interface{}
+	if err := json.Unmarshal(bytes, &payload); err != nil {
+		return err
+	}
+	if s.Events == nil {
+		s.Events = make(map[string]map[string]interface{})
+	}
+	s.Events[eventType] = payload
+	return nil
+}
+
+// CAEP returns the event of eventType, if present.
+func (s *SET) CAEP(eventType string) (e *CAEPEvent, ok bool, err error) {
+	payload, ok := s.Events[eventType]
+	if !ok {
+		return nil, false, nil
+	}
+	bytes, err := json.Marshal(payload)
+	if err != nil {
+		return nil, true, err
+	}
+	e = new(CAEPEvent)
+	if err := json.Unmarshal(bytes, e); err != nil {
+		return nil, true, fmt.Errorf("jwt: CAEP event %q malformed: %w", eventType, err)
+	}
+	if err := e.validate(eventType); err != nil {
+		return nil, true, err
+	}
+	return e, true, nil
+}
+
+func (e *CAEPEvent) validate(eventType string) error {
+	var missing string
+	switch eventType {
+	case CAEPCredentialChange:
+		switch {
+		case e.CredentialType == "":
+			missing = "credential_type"
+		case e.ChangeType == "":
+			missing = "change_type"
+		}
+	case CAEPAssuranceLevelChange:
+		switch {
+		case e.Namespace == "":
+			missing = "namespace"
+		case e.CurrentLevel == "":
+			missing = "current_level"
+		}
+	}
+	if missing != "" {
+		return fmt.Errorf("%w: %q in CAEP event %q", ErrClaimMiss, missing, eventType)
+	}
+	return nil
+}
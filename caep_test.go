@@ -0,0 +1,86 @@
+package jwt
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestCAEP(t *testing.T) {
+	var s SET
+	err := s.AddCAEP(CAEPSessionRevoked, &CAEPEvent{
+		Subject:          &SubjectID{Format: "iss_sub", Issuer: "https://idp.example.com/", Subject: "u1"},
+		EventTimestamp:   NewNumericTime(time.Unix(1600000000, 0)),
+		InitiatingEntity: "policy",
+		ReasonAdmin:      map[string]string{"en": "Landspeed Policy Violation"},
+	})
+	if err != nil {
+		t.Fatal("add error:", err)
+	}
+	err = s.AddCAEP(CAEPCredentialChange, &CAEPEvent{
+		Subject:        &SubjectID{Format: "email", Email: "alice@example.com"},
+		CredentialType: "fido2-roaming",
+		ChangeType:     "create",
+		FIDO2AAGUID:    "accced6a-63f5-490a-9eea-e59bc1896cfc",
+	})
+	if err != nil {
+		t.Fatal("add error:", err)
+	}
+
+	var c Claims
+	c.Issuer = "https://idp.example.com/"
+	c.Issued = NewNumericTime(time.Unix(1600000001, 0))
+	c.ID = "1"
+	s.Apply(&c)
+	token, err := c.HMACSign(HS256, []byte("guest"), json.RawMessage(SETHeader))
+	if err != nil {
+		t.Fatal("sign error:", err)
+	}
+	got, err := HMACCheck(token, []byte("guest"))
+	if err != nil {
+		t.Fatal("check error:", err)
+	}
+	parsed, err := ParseSET(got)
+	if err != nil {
+		t.Fatal("parse error:", err)
+	}
+
+	e, ok, err := parsed.CAEP(CAEPSessionRevoked)
+	if err != nil || !ok {
+		t.Fatalf("got session revoked %t, error %v", ok, err)
+	}
+	if e.Subject == nil || e.Subject.Format != "iss_sub" || e.Subject.Subject != "u1" {
+		t.Errorf("got subject %+v", e.Subject)
+	}
+	if e.EventTimestamp.String() != "2020-09-13T12:26:40Z" || e.ReasonAdmin["en"] != "Landspeed Policy Violation" {
+		t.Errorf("got event %+v", e)
+	}
+
+	e, ok, err = parsed.CAEP(CAEPCredentialChange)
+	if err != nil || !ok {
+		t.Fatalf("got credential change %t, error %v", ok, err)
+	}
+	if e.ChangeType != "create" || e.Subject.Email != "alice@example.com" {
+		t.Errorf("got event %+v", e)
+	}
+
+	if _, ok, err := parsed.CAEP(CAEPAssuranceLevelChange); ok || err != nil {
+		t.Errorf("got assurance level change %t, error %v", ok, err)
+	}
+}
+
+func TestCAEPRequired(t *testing.T) {
+	var s SET
+	err := s.AddCAEP(CAEPAssuranceLevelChange, &CAEPEvent{Namespace: "NIST-AAL"})
+	if !errors.Is(err, ErrClaimMiss) {
+		t.Errorf("got error %v, want %v", err, ErrClaimMiss)
+	}
+
+	s.Events = map[string]map[string]interface{}{
+		CAEPCredentialChange: {"credential_type": "password"},
+	}
+	if _, _, err := s.CAEP(CAEPCredentialChange); !errors.Is(err, ErrClaimMiss) {
+		t.Errorf("got error %v, want %v", err, ErrClaimMiss)
+	}
+}
@@ -0,0 +1,91 @@
+package jwt
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+)
+
+// ErrNonCanonical signals a token which is valid, yet not in canonical form.
+var ErrNonCanonical = errors.New("jwt: non-canonical token encoding")
+
+var strictEncoding = base64.RawURLEncoding.Strict()
+
+// Canonical returns an ErrNonCanonical when the token has an encoding which is
+// not the one and only for its content. Verifiers which use the token bytes as
+// a cache key should reject such tokens, as does Canonical on:
+//
+//   - base64 with padding or with non-zero trailing bits
+//   - JOSE header parameters which are neither registered in RFC 7515 nor
+//     listed as critical
+//   - "exp", "nbf" and "iat" claims with an exponent, a redundant fraction
+//     or trailing zeros
+//
+// Compressed payloads, as produced with Claims.Compress, are inflated up to
+// InflateLimit for the claim inspection. DEFLATE has no canonical form, so the
+// compressed bytes themselves are accepted as is.
+//
+// The Check functions are not affected. Apply Canonical before the check.
+func Canonical(token []byte) error {
+	parts := bytes.Split(token, []byte{'.'})
+	if len(parts) != 3 {
+		return fmt.Errorf("%w: %d parts", ErrNonCanonical, len(parts))
+	}
+	var decoded [3][]byte
+	for i, part := range parts {
+		buf := make([]byte, strictEncoding.DecodedLen(len(part)))
+		n, err := strictEncoding.Decode(buf, part)
+		if err != nil {
+			return fmt.Errorf("%w: %s", ErrNonCanonical, err)
+		}
+		decoded[i] = buf[:n]
+	}
+
+	header := new(Header)
+	if err := json.Unmarshal(decoded[0], header); err != nil {
+		return fmt.Errorf("jwt: malformed JOSE header: %w", err)
+	}
+	for name := range header.Unknown {
+		var listed bool
+		for _, crit := range header.Crit {
+			listed = listed || crit == name
+		}
+		if !listed {
+			return fmt.Errorf("%w: unknown JOSE header parameter %q", ErrNonCanonical, name)
+		}
+	}
+
+	body := decoded[1]
+	if zip, ok := header.Unknown["zip"]; ok {
+		if string(zip) != `"DEF"` {
+			return fmt.Errorf("%w: %s", errZipAlg, zip)
+		}
+		var err error
+		body, err = inflate(body)
+		if err != nil {
+			return err
+		}
+	}
+
+	var payload map[string]json.RawMessage
+	if err := json.Unmarshal(body, &payload); err != nil {
+		return fmt.Errorf("jwt: malformed payload: %w", err)
+	}
+	for _, name := range []string{expires, notBefore, issued} {
+		raw, ok := payload[name]
+		if !ok {
+			continue
+		}
+		f, err := strconv.ParseFloat(string(raw), 64)
+		if err != nil {
+			continue // not a NumericDate
+		}
+		if string(raw) != strconv.FormatFloat(f, 'f', -1, 64) {
+			return fmt.Errorf("%w: number %s for claim %q", ErrNonCanonical, raw, name)
+		}
+	}
+	return nil
+}
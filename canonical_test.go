@@ -0,0 +1,67 @@
+package jwt
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestCanonical(t *testing.T) {
+	sign := func(header, payload string) string {
+		token := encoding.EncodeToString([]byte(header)) + "." + encoding.EncodeToString([]byte(payload))
+		return token + "." + encoding.EncodeToString([]byte("sig"))
+	}
+
+	golden := []struct {
+		token string
+		ok    bool
+	}{
+		{sign(`{"alg":"HS256","typ":"JWT"}`, `{"exp":1600000000,"iat":1599999999.5}`), true},
+		{sign(`{"alg":"HS256","ext":1,"crit":["ext"]}`, `{}`), true},
+		{sign(`{"alg":"HS256","ext":1}`, `{}`), false},
+		{sign(`{"alg":"HS256"}`, `{"exp":1.6e9}`), false},
+		{sign(`{"alg":"HS256"}`, `{"nbf":1600000000.0}`), false},
+		{sign(`{"alg":"HS256"}`, `{"iat":1599999999.50}`), false},
+		{sign(`{"alg":"HS256"}`, `{"exp":"1600000000"}`), true},
+		{"eyJhbGciOiJIUzI1NiJ9.e31.c2lnZw", false}, // trailing bits
+		{"eyJhbGciOiJIUzI1NiJ9.e30.c2lnZw==", false},
+		{"eyJhbGciOiJIUzI1NiJ9.e30", false},
+	}
+	for _, gold := range golden {
+		err := Canonical([]byte(gold.token))
+		if gold.ok && err != nil {
+			t.Errorf("%s: got error %v", gold.token, err)
+		}
+		if !gold.ok && !errors.Is(err, ErrNonCanonical) {
+			t.Errorf("%s: got error %v, want %v", gold.token, err, ErrNonCanonical)
+		}
+	}
+}
+
+func TestCanonicalCompressed(t *testing.T) {
+	sign := func(header, payload string) string {
+		compressed, err := deflate([]byte(payload))
+		if err != nil {
+			t.Fatal(err)
+		}
+		token := encoding.EncodeToString([]byte(header)) + "." + encoding.EncodeToString(compressed)
+		return token + "." + encoding.EncodeToString([]byte("sig"))
+	}
+
+	if err := Canonical([]byte(sign(`{"alg":"HS256","zip":"DEF","crit":["zip"]}`, `{"exp":1600000000}`))); err != nil {
+		t.Error("got error for compressed payload:", err)
+	}
+	if err := Canonical([]byte(sign(`{"alg":"HS256","zip":"DEF","crit":["zip"]}`, `{"exp":1.6e9}`))); !errors.Is(err, ErrNonCanonical) {
+		t.Errorf("got error %v for compressed exponent, want %v", err, ErrNonCanonical)
+	}
+	if err := Canonical([]byte(sign(`{"alg":"HS256","zip":"DEF"}`, `{}`))); !errors.Is(err, ErrNonCanonical) {
+		t.Errorf("got error %v for zip not critical, want %v", err, ErrNonCanonical)
+	}
+	if err := Canonical([]byte(sign(`{"alg":"HS256","zip":"GZ","crit":["zip"]}`, `{}`))); !errors.Is(err, errZipAlg) {
+		t.Errorf("got error %v for unknown zip, want %v", err, errZipAlg)
+	}
+
+	bomb := sign(`{"alg":"HS256","zip":"DEF","crit":["zip"]}`, string(make([]byte, InflateLimit+1)))
+	if err := Canonical([]byte(bomb)); err != errZipLimit {
+		t.Errorf("got error %v for compression bomb, want %v", err, errZipLimit)
+	}
+}
@@ -0,0 +1,362 @@
+package jwt
+
+import (
+	"bytes"
+	"context"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+var errNoX5C = errors.New("jwt: no x5c in JOSE header")
+
+// ErrRevoked signals a certificate revocation.
+var ErrRevoked = errors.New("jwt: certificate revoked")
+
+var errRevocationUnknown = errors.New("jwt: certificate revocation status unavailable")
+
+// CertVerifier checks tokens with the public key of the certificate in their
+// "x5c" header, conform RFC 7515, subsection 4.1.6. The certificate must pass
+// chain validation first.
+//
+// The "x5u" header (with a URL to the chain) is not supported. Tokens without
+// "x5c" are rejected, regardless of any "x5u". Revocation checks are off by
+// default. Set Revocation to check each certificate in the chain.
+//
+// Multiple goroutines may invoke methods on a CertVerifier simultaneously.
+// The exported fields must not be modified after first use.
+type CertVerifier struct {
+	// Options apply to the chain validation with x509.Certificate.Verify.
+	// Roots is required, as the system pool is seldom appropriate. Use
+	// KeyUsages for extended key usage (EKU) constraints, and DNSName for
+	// the name of the leaf. Name constraints of the CAs apply always. Any
+	// Intermediates are ignored in favour of the field with that name.
+	Options x509.VerifyOptions
+
+	// Intermediates are added to the certificates from the token, for
+	// chain validation.
+	Intermediates []*x509.Certificate
+
+	// When not nil, then Revocation is called for each certificate in
+	// the verified chain, except for the root, with the certificate that
+	// signed it as the issuer. The return should be ErrRevoked for any
+	// revoked certificate. The CRLs and the OCSP type provide an
+	// implementation.
+	Revocation func(cert, issuer *x509.Certificate) error
+
+	// RevocationSoftFail accepts certificates when Revocation fails to
+	// determine their status, i.e., with any error other than ErrRevoked.
+	RevocationSoftFail bool
+}
+
+// Check parses a JWT if, and only if, the signature checks out with the key
+// of a valid certificate.
+// Use Claims.Valid to complete the verification.
+func (v *CertVerifier) Check(token []byte) (*Claims, error) {
+	leaf, chains, err := v.verifyChain(token)
+	if err != nil {
+		return nil, err
+	}
+	if v.Revocation != nil {
+		if err := v.checkRevocation(chains[0]); err != nil {
+			return nil, err
+		}
+	}
+	return Check(token, leaf.PublicKey)
+}
+
+func (v *CertVerifier) checkRevocation(chain []*x509.Certificate) error {
+	for i := 0; i+1 < len(chain); i++ {
+		err := v.Revocation(chain[i], chain[i+1])
+		switch {
+		case err == nil:
+			continue
+		case errors.Is(err, ErrRevoked):
+			return err
+		case v.RevocationSoftFail:
+			continue
+		default:
+			return fmt.Errorf("%w: %s", errRevocationUnknown, err)
+		}
+	}
+	return nil
+}
+
+// VerifyChain returns the leaf with its verified chains.
+func (v *CertVerifier) verifyChain(token []byte) (leaf *x509.Certificate, chains [][]*x509.Certificate, err error) {
+	if v.Options.Roots == nil {
+		return nil, nil, errors.New("jwt: certificate verification without root pool")
+	}
+
+	certs, err := x5cFromToken(token)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	opts := v.Options
+	opts.Intermediates = x509.NewCertPool()
+	for _, c := range v.Intermediates {
+		opts.Intermediates.AddCert(c)
+	}
+	for _, c := range certs[1:] {
+		opts.Intermediates.AddCert(c)
+	}
+	if len(opts.KeyUsages) == 0 {
+		// x509 defaults to server authentication
+		opts.KeyUsages = []x509.ExtKeyUsage{x509.ExtKeyUsageAny}
+	}
+
+	chains, err = certs[0].Verify(opts)
+	if err != nil {
+		return nil, nil, fmt.Errorf("jwt: x5c certificate rejected: %w", err)
+	}
+	return certs[0], chains, nil
+}
+
+// X5CFromToken returns the certificates from the JOSE header, without any
+// verification.
+func x5cFromToken(token []byte) ([]*x509.Certificate, error) {
+	c, err := ParseWithoutCheck(token)
+	if err != nil {
+		return nil, err
+	}
+	var header struct {
+		X5C []string `json:"x5c"`
+	}
+	if err := json.Unmarshal([]byte(c.RawHeader), &header); err != nil {
+		return nil, fmt.Errorf("jwt: malformed JOSE header: %w", err)
+	}
+	if len(header.X5C) == 0 {
+		return nil, errNoX5C
+	}
+
+	// “Each string in the array is a base64-encoded (Section 4 of
+	// [RFC4648] -- not base64url-encoded) DER [ITU.X690.2008] PKIX
+	// certificate value.”
+	// — “JSON Web Signature (JWS)” RFC 7515, subsection 4.1.6
+	certs := make([]*x509.Certificate, len(header.X5C))
+	for i, s := range header.X5C {
+		der, err := base64.StdEncoding.DecodeString(s)
+		if err != nil {
+			return nil, fmt.Errorf("jwt: malformed x5c: %w", err)
+		}
+		certs[i], err = x509.ParseCertificate(der)
+		if err != nil {
+			return nil, fmt.Errorf("jwt: malformed x5c: %w", err)
+		}
+	}
+	return certs, nil
+}
+
+// CRLLimit is the maximum number of bytes read from a CRL response.
+const crlLimit = 16 << 20
+
+// DefaultRevocationTimeout is the time limit for requests on the CRL and OCSP
+// types, when not set otherwise.
+const DefaultRevocationTimeout = 10 * time.Second
+
+// CRLs is a CertVerifier Revocation implementation which uses the certificate
+// revocation lists from the CRL distribution points of each certificate. Lists
+// are cached until their next update, yet no longer than MaxRefresh. Their
+// signature is verified once, on arrival. Fetch failures are cached for one
+// minute, such that an outage doesn't cause a request per verification.
+//
+// Multiple goroutines may invoke methods on a CRLs simultaneously.
+type CRLs struct {
+	// Client is used for the HTTP requests. Nil defaults to
+	// http.DefaultClient.
+	Client *http.Client
+
+	// MaxRefresh is the maximum amount of time a list is cached.
+	// Zero defaults to DefaultMaxRefresh.
+	MaxRefresh time.Duration
+
+	// Timeout limits the duration of each request. Zero defaults to
+	// DefaultRevocationTimeout.
+	Timeout time.Duration
+
+	cache revocationCache // *crlEntry by URL and issuer
+}
+
+type crlEntry struct {
+	revoked map[string]struct{} // serial number bytes
+}
+
+// Check returns ErrRevoked when cert is on the list of the first distribution
+// point available. Certificates without an HTTP distribution point cause an
+// error, as their status can not be determined.
+func (r *CRLs) Check(cert, issuer *x509.Certificate) error {
+	var lastErr error = errRevocationUnknown
+	for _, url := range cert.CRLDistributionPoints {
+		if !strings.HasPrefix(url, "http://") && !strings.HasPrefix(url, "https://") {
+			continue
+		}
+		v, err := r.cache.get(url+"\x00"+string(issuer.Raw), func(now time.Time) (interface{}, time.Time, error) {
+			return r.fetch(url, issuer, now)
+		})
+		if err != nil {
+			lastErr = err
+			continue
+		}
+		if _, ok := v.(*crlEntry).revoked[string(cert.SerialNumber.Bytes())]; ok {
+			return ErrRevoked
+		}
+		return nil
+	}
+	return lastErr
+}
+
+// Fetch returns the CRL from url, if, and only if, issuer signed it.
+func (r *CRLs) fetch(url string, issuer *x509.Certificate, now time.Time) (*crlEntry, time.Time, error) {
+	body, err := fetchRevocation(r.Client, r.Timeout, http.MethodGet, url, "", nil, crlLimit)
+	if err != nil {
+		return nil, time.Time{}, fmt.Errorf("jwt: CRL %q unavailable: %w", url, err)
+	}
+
+	list, err := parseCRL(body)
+	if err != nil {
+		return nil, time.Time{}, fmt.Errorf("jwt: CRL %q unusable: %w", url, err)
+	}
+	revoked, nextUpdate, err := crlRevoked(list, issuer)
+	if err != nil {
+		return nil, time.Time{}, fmt.Errorf("jwt: CRL %q signature rejected: %w", url, err)
+	}
+	if !nextUpdate.IsZero() && !now.Before(nextUpdate) {
+		return nil, time.Time{}, fmt.Errorf("jwt: CRL %q expired", url)
+	}
+
+	maxRefresh := r.MaxRefresh
+	if maxRefresh <= 0 {
+		maxRefresh = DefaultMaxRefresh
+	}
+	expires := now.Add(maxRefresh)
+	if !nextUpdate.IsZero() && nextUpdate.Before(expires) {
+		expires = nextUpdate
+	}
+	return &crlEntry{revoked}, expires, nil
+}
+
+// FetchRevocation returns the response body of an HTTP request, with a time
+// limit.
+func fetchRevocation(client *http.Client, timeout time.Duration, method, url, contentType string, body []byte, limit int64) ([]byte, error) {
+	if client == nil {
+		client = http.DefaultClient
+	}
+	if timeout <= 0 {
+		timeout = DefaultRevocationTimeout
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, method, url, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	if contentType != "" {
+		req.Header.Set("Content-Type", contentType)
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		io.Copy(ioutil.Discard, io.LimitReader(resp.Body, limit))
+		return nil, fmt.Errorf("got HTTP %q", resp.Status)
+	}
+	return ioutil.ReadAll(io.LimitReader(resp.Body, limit))
+}
+
+// RevocationCache holds revocation data until expiry. Concurrent requests for
+// the same key share one fetch, which runs without holding the lock.
+type revocationCache struct {
+	mutex   sync.Mutex
+	entries map[string]*revocationEntry
+	swept   time.Time
+}
+
+type revocationEntry struct {
+	done    chan struct{} // closed once fetched
+	value   interface{}
+	err     error
+	expires time.Time
+}
+
+// RevocationRetry is the amount of time a fetch failure is cached, such that
+// an outage of the responder doesn't cause a request for each verification.
+const revocationRetry = time.Minute
+
+// Get returns the value for key. Absent and expired values are fetched first.
+// Errors from fetch are returned to each of the requests sharing it, and to
+// any request within revocationRetry.
+func (c *revocationCache) get(key string, fetch func(now time.Time) (interface{}, time.Time, error)) (interface{}, error) {
+	now := time.Now()
+
+	c.mutex.Lock()
+	c.sweep(now)
+	e, ok := c.entries[key]
+	if ok {
+		select {
+		case <-e.done:
+			if now.Before(e.expires) {
+				c.mutex.Unlock()
+				return e.value, e.err
+			}
+		default:
+			c.mutex.Unlock()
+			<-e.done
+			return e.value, e.err
+		}
+	}
+	e = &revocationEntry{done: make(chan struct{})}
+	c.entries[key] = e
+	c.mutex.Unlock()
+
+	e.value, e.expires, e.err = fetch(now)
+	if e.err != nil {
+		e.value, e.expires = nil, now.Add(revocationRetry)
+	}
+	close(e.done)
+	return e.value, e.err
+}
+
+// Put installs a value for key.
+func (c *revocationCache) put(key string, value interface{}, expires time.Time) {
+	e := &revocationEntry{done: make(chan struct{}), value: value, expires: expires}
+	close(e.done)
+
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	c.sweep(time.Now())
+	c.entries[key] = e
+}
+
+// Sweep removes expired entries once per minute. The mutex must be held.
+func (c *revocationCache) sweep(now time.Time) {
+	if c.entries == nil {
+		c.entries = make(map[string]*revocationEntry)
+		c.swept = now
+	}
+	if now.Sub(c.swept) < time.Minute {
+		return
+	}
+	for k, e := range c.entries {
+		select {
+		case <-e.done:
+			if !now.Before(e.expires) {
+				delete(c.entries, k)
+			}
+		default:
+			break // fetch pending
+		}
+	}
+	c.swept = now
+}
@@ -0,0 +1,238 @@
+//go:build !jwt_hmac_only && !jwt_no_ecdsa
+// +build !jwt_hmac_only,!jwt_no_ecdsa
+
+package jwt
+
+import (
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// TestCertChain returns a CA with testKeyEC256, and a leaf with
+// testKeyEd25519Public, for client authentication of example.com.
+// The revocation URL, for both CRL and OCSP, is optional.
+func testCertChain(t *testing.T, revocationURL string) (ca, leaf *x509.Certificate) {
+	t.Helper()
+	caTemplate := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "Test CA"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+		KeyUsage:              x509.KeyUsageCertSign | x509.KeyUsageCRLSign,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, caTemplate, caTemplate, &testKeyEC256.PublicKey, testKeyEC256)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ca, err = x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	leafTemplate := &x509.Certificate{
+		SerialNumber: big.NewInt(2),
+		Subject:      pkix.Name{CommonName: "example.com"},
+		DNSNames:     []string{"example.com"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+	}
+	if revocationURL != "" {
+		leafTemplate.CRLDistributionPoints = []string{revocationURL}
+		leafTemplate.OCSPServer = []string{revocationURL}
+	}
+	der, err = x509.CreateCertificate(rand.Reader, leafTemplate, ca, testKeyEd25519Public, testKeyEC256)
+	if err != nil {
+		t.Fatal(err)
+	}
+	leaf, err = x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return ca, leaf
+}
+
+func x5cHeader(certs ...*x509.Certificate) json.RawMessage {
+	x5c := make([]string, len(certs))
+	for i, c := range certs {
+		x5c[i] = base64.StdEncoding.EncodeToString(c.Raw)
+	}
+	header, _ := json.Marshal(map[string]interface{}{"x5c": x5c})
+	return header
+}
+
+func TestCertVerifier(t *testing.T) {
+	ca, leaf := testCertChain(t, "")
+	roots := x509.NewCertPool()
+	roots.AddCert(ca)
+
+	var c Claims
+	c.Subject = "device"
+	token, err := c.EdDSASign(testKeyEd25519Private, x5cHeader(leaf))
+	if err != nil {
+		t.Fatal("sign error:", err)
+	}
+
+	v := CertVerifier{Options: x509.VerifyOptions{Roots: roots, DNSName: "example.com"}}
+	if got, err := v.Check(token); err != nil {
+		t.Error("check error:", err)
+	} else if got.Subject != "device" {
+		t.Errorf("got subject %q, want device", got.Subject)
+	}
+
+	v.Options.KeyUsages = []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth}
+	if _, err := v.Check(token); err == nil {
+		t.Error("no error for EKU mismatch")
+	}
+	v.Options.KeyUsages = []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth}
+	v.Options.DNSName = "example.org"
+	if _, err := v.Check(token); err == nil {
+		t.Error("no error for name mismatch")
+	}
+	v.Options.DNSName = ""
+	if _, err := (&CertVerifier{Options: x509.VerifyOptions{Roots: x509.NewCertPool()}}).Check(token); err == nil {
+		t.Error("no error for unknown root")
+	}
+
+	// chain valid, yet signed by other key
+	token, err = c.ECDSASign(ES256, testKeyEC256, x5cHeader(leaf))
+	if err != nil {
+		t.Fatal("sign error:", err)
+	}
+	if _, err := v.Check(token); err != AlgError(ES256) {
+		t.Errorf("got error %v, want %v", err, AlgError(ES256))
+	}
+
+	token, err = c.EdDSASign(testKeyEd25519Private)
+	if err != nil {
+		t.Fatal("sign error:", err)
+	}
+	if _, err := v.Check(token); err != errNoX5C {
+		t.Errorf("got error %v, want %v", err, errNoX5C)
+	}
+}
+
+func TestCertVerifierCRL(t *testing.T) {
+	var crl []byte
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if crl == nil {
+			http.NotFound(w, r)
+			return
+		}
+		w.Write(crl)
+	}))
+	defer srv.Close()
+
+	ca, leaf := testCertChain(t, srv.URL+"/ca.crl")
+	roots := x509.NewCertPool()
+	roots.AddCert(ca)
+	token, err := new(Claims).EdDSASign(testKeyEd25519Private, x5cHeader(leaf))
+	if err != nil {
+		t.Fatal("sign error:", err)
+	}
+
+	v := CertVerifier{
+		Options:    x509.VerifyOptions{Roots: roots},
+		Revocation: new(CRLs).Check,
+	}
+	if _, err := v.Check(token); !errors.Is(err, errRevocationUnknown) {
+		t.Errorf("got error %v for CRL unavailable, want %v", err, errRevocationUnknown)
+	}
+	v.RevocationSoftFail = true
+	if _, err := v.Check(token); err != nil {
+		t.Error("soft fail check error:", err)
+	}
+
+	crl, err = ca.CreateCRL(rand.Reader, testKeyEC256, []pkix.RevokedCertificate{
+		{SerialNumber: big.NewInt(99), RevocationTime: time.Now()},
+	}, time.Now(), time.Now().Add(time.Hour))
+	if err != nil {
+		t.Fatal("CRL creation error:", err)
+	}
+	v.RevocationSoftFail = false
+	v.Revocation = new(CRLs).Check
+	if _, err := v.Check(token); err != nil {
+		t.Error("check error with CRL:", err)
+	}
+
+	crl, err = ca.CreateCRL(rand.Reader, testKeyEC256, []pkix.RevokedCertificate{
+		{SerialNumber: leaf.SerialNumber, RevocationTime: time.Now()},
+	}, time.Now(), time.Now().Add(time.Hour))
+	if err != nil {
+		t.Fatal("CRL creation error:", err)
+	}
+	v.RevocationSoftFail = true
+	v.Revocation = new(CRLs).Check
+	if _, err := v.Check(token); err != ErrRevoked {
+		t.Errorf("got error %v, want %v", err, ErrRevoked)
+	}
+}
+
+func TestRevocationCache(t *testing.T) {
+	var c revocationCache
+	release := make(chan struct{})
+	var fetches int32
+	fetch := func(now time.Time) (interface{}, time.Time, error) {
+		atomic.AddInt32(&fetches, 1)
+		<-release
+		return "list", now.Add(time.Hour), nil
+	}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 3; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			v, err := c.get("k", fetch)
+			if err != nil || v != "list" {
+				t.Errorf("got (%v, %v), want (list, <nil>)", v, err)
+			}
+		}()
+	}
+	for atomic.LoadInt32(&fetches) == 0 {
+		time.Sleep(time.Millisecond)
+	}
+	time.Sleep(10 * time.Millisecond) // await joins
+	close(release)
+	wg.Wait()
+	if n := atomic.LoadInt32(&fetches); n != 1 {
+		t.Errorf("got %d fetches for concurrent requests, want 1", n)
+	}
+
+	failure := errors.New("fetch failure")
+	var failures int32
+	fail := func(time.Time) (interface{}, time.Time, error) {
+		atomic.AddInt32(&failures, 1)
+		return nil, time.Time{}, failure
+	}
+	for i := 0; i < 3; i++ {
+		if _, err := c.get("e", fail); err != failure {
+			t.Errorf("got error %v, want %v", err, failure)
+		}
+	}
+	if n := atomic.LoadInt32(&failures); n != 1 {
+		t.Errorf("got %d fetches on failure, want 1 until retry", n)
+	}
+
+	// retry due
+	c.mutex.Lock()
+	c.entries["e"].expires = time.Now()
+	c.mutex.Unlock()
+	if v, err := c.get("e", fetch); err != nil || v != "list" {
+		t.Errorf("got (%v, %v) after failure, want (list, <nil>)", v, err)
+	}
+}
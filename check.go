@@ -2,6 +2,7 @@ package jwt
 
 import (
 	"bytes"
+	"crypto"
 	"crypto/ecdsa"
 	"crypto/ed25519"
 	"crypto/hmac"
@@ -10,7 +11,6 @@ import (
 	"errors"
 	"fmt"
 	"hash"
-	"math/big"
 )
 
 // ErrSigMiss means the signature check failed.
@@ -22,6 +22,8 @@ var errNoPayload = errors.New("jwt: one part only—payload absent")
 // — “JSON Web Signature (JWS)” RFC 7515, subsection 4.1.11
 var errCritEmpty = errors.New("jwt: empty array in crit header")
 
+var errCritUnknown = errors.New("jwt: unsupported critical extension in JOSE header")
+
 // EvalCrit is invoked by the Check functions for each token with one or more
 // JOSE extensions. The crit slice has the JSON field names (for header) which
 // “MUST be understood and processed” according to RFC 7515, subsection 4.1.11.
@@ -29,10 +31,11 @@ var errCritEmpty = errors.New("jwt: empty array in crit header")
 // supported by the recipient, then the JWS is invalid.”
 // The respective Check function returns any error from EvalCrit as is.
 var EvalCrit = func(token []byte, crit []string, header json.RawMessage) error {
-	return fmt.Errorf("jwt: unsupported critical extension in JOSE header: %q", crit)
+	return fmt.Errorf("%w: %q", errCritUnknown, crit)
 }
 
-// ParseWithoutCheck skips the signature validation.
+// ParseWithoutCheck skips the signature validation. Compressed payloads are
+// inflated regardless, i.e., before any authentication, up to InflateLimit.
 func ParseWithoutCheck(token []byte) (*Claims, error) {
 	var c Claims
 	_, _, _, err := c.scan(token)
@@ -43,51 +46,23 @@ func ParseWithoutCheck(token []byte) (*Claims, error) {
 	return &c, c.applyPayload()
 }
 
-// ECDSACheck parses a JWT if, and only if, the signature checks out.
-// The return is an AlgError when the algorithm is not in ECDSAAlgs.
-// Use Valid to complete the verification.
-func ECDSACheck(token []byte, key *ecdsa.PublicKey) (*Claims, error) {
-	var c Claims
-	bodyLen, sig, alg, err := c.scan(token)
-	if err != nil {
-		return nil, err
-	}
-
-	hash, err := hashLookup(alg, ECDSAAlgs)
-	if err != nil {
-		return nil, err
-	}
-	digest := hash.New()
-	digest.Write(token[:bodyLen])
-
-	r := new(big.Int).SetBytes(sig[:len(sig)/2])
-	s := new(big.Int).SetBytes(sig[len(sig)/2:])
-	buf := sig[len(sig):]
-	if !ecdsa.Verify(key, digest.Sum(buf), r, s) {
-		return nil, ErrSigMiss
-	}
-
-	return &c, c.applyPayload()
-}
-
-// EdDSACheck parses a JWT if, and only if, the signature checks out.
+// Check parses a JWT if, and only if, the signature checks out. The key is
+// either an *ecdsa.PublicKey, an ed25519.PublicKey or an *rsa.PublicKey, as
+// returned by x509.ParsePKIXPublicKey and the likes. The return is an AlgError
+// when the algorithm does not belong to the key type. HMAC secrets are not
+// accepted, as public key material must never end up as a shared secret.
 // Use Valid to complete the verification.
-func EdDSACheck(token []byte, key ed25519.PublicKey) (*Claims, error) {
-	var c Claims
-	bodyLen, sig, alg, err := c.scan(token)
-	if err != nil {
-		return nil, err
-	}
-
-	if alg != EdDSA {
-		return nil, AlgError(alg)
+func Check(token []byte, key crypto.PublicKey) (*Claims, error) {
+	switch k := key.(type) {
+	case *ecdsa.PublicKey:
+		return checkECDSAKey(token, k)
+	case ed25519.PublicKey:
+		return checkEdDSAKey(token, k)
+	case *rsa.PublicKey:
+		return checkRSAKey(token, k)
+	default:
+		return nil, fmt.Errorf("jwt: unsupported key type %T", key)
 	}
-
-	if !ed25519.Verify(key, token[:bodyLen], sig) {
-		return nil, ErrSigMiss
-	}
-
-	return &c, c.applyPayload()
 }
 
 // HMACCheck parses a JWT if, and only if, the signature checks out.
@@ -145,37 +120,7 @@ func (h *HMAC) Check(token []byte) (*Claims, error) {
 	return &c, c.applyPayload()
 }
 
-// RSACheck parses a JWT if, and only if, the signature checks out.
-// The return is an AlgError when the algorithm is not in RSAAlgs.
-// Use Valid to complete the verification.
-func RSACheck(token []byte, key *rsa.PublicKey) (*Claims, error) {
-	var c Claims
-	bodyLen, sig, alg, err := c.scan(token)
-	if err != nil {
-		return nil, err
-	}
-
-	hash, err := hashLookup(alg, RSAAlgs)
-	if err != nil {
-		return nil, err
-	}
-	digest := hash.New()
-	digest.Write(token[:bodyLen])
-
-	buf := sig[len(sig):]
-	if alg != "" && alg[0] == 'P' {
-		err = rsa.VerifyPSS(key, hash, digest.Sum(buf), sig, &pSSOptions)
-	} else {
-		err = rsa.VerifyPKCS1v15(key, hash, digest.Sum(buf), sig)
-	}
-	if err != nil {
-		return nil, ErrSigMiss
-	}
-
-	return &c, c.applyPayload()
-}
-
-// DecodeParts reads up to three base64 parts. The result goes in c.RawHeader, c.Raw and sig.
+// DecodeParts reads up to three base64 parts. The result goes in c.RawHeader, c.Raw and c.RawSignature.
 func (c *Claims) decodeParts(token []byte) (bodyLen int, sig []byte, err error) {
 	// fits all 3 parts decoded + buffer space for Hash.Sum.
 	buf := make([]byte, len(token))
@@ -223,7 +168,8 @@ func (c *Claims) decodeParts(token []byte) (bodyLen int, sig []byte, err error)
 	if err != nil {
 		return 0, nil, fmt.Errorf("jwt: malformed signature: %w", err)
 	}
-	return bodyLen, buf[:n], nil
+	c.RawSignature = buf[:n]
+	return bodyLen, c.RawSignature, nil
 }
 
 func (c *Claims) scan(token []byte) (bodyLen int, sig []byte, alg string, err error) {
@@ -236,6 +182,7 @@ func (c *Claims) scan(token []byte) (bodyLen int, sig []byte, alg string, err er
 		Kid  string   `json:"kid"`
 		Alg  string   `json:"alg"`
 		Crit []string `json:"crit"`
+		Zip  string   `json:"zip"`
 	}
 	if err := json.Unmarshal([]byte(c.RawHeader), &header); err != nil {
 		return 0, nil, "", fmt.Errorf("jwt: malformed JOSE header: %w", err)
@@ -252,8 +199,23 @@ func (c *Claims) scan(token []byte) (bodyLen int, sig []byte, alg string, err er
 		if len(header.Crit) == 0 {
 			return 0, nil, "", errCritEmpty
 		}
-		if err := EvalCrit(token, header.Crit, c.RawHeader); err != nil {
-			return 0, nil, "", err
+		crit := header.Crit
+		for i, name := range crit {
+			if name == "zip" {
+				crit = append(crit[:i:i], crit[i+1:]...)
+				break
+			}
+		}
+		if len(crit) != len(header.Crit) {
+			if header.Zip != "DEF" {
+				return 0, nil, "", fmt.Errorf("%w: %q", errZipAlg, header.Zip)
+			}
+			c.zip = true
+		}
+		if len(crit) != 0 {
+			if err := EvalCrit(token, crit, c.RawHeader); err != nil {
+				return 0, nil, "", err
+			}
 		}
 	}
 
@@ -261,12 +223,26 @@ func (c *Claims) scan(token []byte) (bodyLen int, sig []byte, alg string, err er
 }
 
 func (c *Claims) applyPayload() error {
+	// decompress after signature verification only
+	if c.zip {
+		c.zip = false
+		raw, err := inflate(c.Raw)
+		if err != nil {
+			return err
+		}
+		c.Raw = json.RawMessage(raw)
+	}
+
 	err := json.Unmarshal([]byte(c.Raw), &c.Set)
 	if err != nil {
 		return fmt.Errorf("jwt: malformed payload: %w", err)
 	}
+	c.applySet()
+	return nil
+}
 
-	// move from Set to Registered on type match
+// ApplySet moves entries from Set to Registered on type match.
+func (c *Claims) applySet() {
 	m := c.Set
 	if s, ok := m[issuer].(string); ok {
 		delete(m, issuer)
@@ -317,5 +293,28 @@ func (c *Claims) applyPayload() error {
 		c.ID = s
 	}
 
-	return nil
+	// The claims below remain in Set, as they did before the Registered
+	// fields, for compatibility.
+	if s, ok := m[sessionID].(string); ok {
+		c.SessionID = s
+	}
+	if s, ok := m[nonce].(string); ok {
+		c.Nonce = s
+	}
+
+	// RFC 8417 requires a non-empty object with objects as event payload
+	if o, ok := m[events].(map[string]interface{}); ok && len(o) != 0 {
+		e := make(map[string]map[string]interface{}, len(o))
+		for name, payload := range o {
+			object, ok := payload.(map[string]interface{})
+			if !ok {
+				e = nil
+				break
+			}
+			e[name] = object
+		}
+		if e != nil {
+			c.Events = e
+		}
+	}
 }
@@ -1,6 +1,10 @@
+//go:build !jwt_hmac_only && !jwt_no_rsa && !jwt_no_ecdsa
+// +build !jwt_hmac_only,!jwt_no_rsa,!jwt_no_ecdsa
+
 package jwt
 
 import (
+	"context"
 	"crypto"
 	"crypto/ecdsa"
 	"crypto/ed25519"
@@ -100,6 +104,9 @@ func TestHMACCheck(t *testing.T) {
 		if string(claims.Raw) != gold.claims {
 			t.Errorf("%d: got claims JSON %q, want %q", i, claims.Raw, gold.claims)
 		}
+		if header, payload, sig := claims.Segments(); header+"."+payload+"."+sig != gold.token {
+			t.Errorf("%d: got segments %q, %q and %q, want token %q", i, header, payload, sig, gold.token)
+		}
 
 		// extract alg
 		var header struct {
@@ -209,6 +216,45 @@ func TestCheckAlgError(t *testing.T) {
 	}
 }
 
+func TestCheckKeyType(t *testing.T) {
+	golden := []struct {
+		alg string
+		key interface{}
+	}{
+		{ES384, testKeyEC384},
+		{EdDSA, testKeyEd25519Private},
+		{RS256, testKeyRSA2048},
+	}
+	for _, gold := range golden {
+		s, err := NewSigner(gold.alg, gold.key)
+		if err != nil {
+			t.Fatal("signer error:", err)
+		}
+		token, err := (&Claims{Registered: Registered{Subject: "k"}}).SignContext(context.Background(), s)
+		if err != nil {
+			t.Fatal("sign error:", err)
+		}
+		pub := s.(*cryptoSigner).Public()
+		if c, err := Check(token, pub); err != nil {
+			t.Errorf("%s: check error: %s", gold.alg, err)
+		} else if c.Subject != "k" {
+			t.Errorf("%s: got subject %q, want k", gold.alg, c.Subject)
+		}
+	}
+
+	// algorithm of other family
+	token, err := new(Claims).HMACSign(HS256, []byte("guest"))
+	if err != nil {
+		t.Fatal("sign error:", err)
+	}
+	if _, err := Check(token, &testKeyRSA2048.PublicKey); err != AlgError(HS256) {
+		t.Errorf("got error %v, want %v", err, AlgError(HS256))
+	}
+	if _, err := Check(token, []byte("guest")); err == nil {
+		t.Error("HMAC secret accepted")
+	}
+}
+
 func TestCheckMiss(t *testing.T) {
 	_, err := ECDSACheck([]byte(goldenECDSAs[0].token), &testKeyEC521.PublicKey)
 	if err != ErrSigMiss {
@@ -0,0 +1,121 @@
+package jwt
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+)
+
+// ErrorCodes maps errors to their code, in order of precedence.
+var errorCodes = []struct {
+	err  error
+	code string
+}{
+	{ErrSigMiss, "sig_invalid"},
+	{ErrUnsecured, "alg_none"},
+	{errHashLink, "alg_unavailable"},
+	{ErrExpired, "token_expired"},
+	{ErrNotBefore, "token_not_yet_valid"},
+	{ErrIssuedAt, "token_future_iat"},
+	{ErrIssuer, "iss_mismatch"},
+	{ErrAudience, "aud_mismatch"},
+	{ErrClaimMiss, "claim_missing"},
+	{ErrNonce, "nonce_mismatch"},
+	{ErrNoHeader, "token_missing"},
+	{ErrNoClaims, "token_missing"},
+	{errAuthSchema, "auth_scheme_invalid"},
+	{errNoPayload, "token_malformed"},
+	{errCritEmpty, "token_malformed"},
+	{errCritUnknown, "crit_unsupported"},
+	{errNoSecret, "key_missing"},
+	{ErrDenied, "access_denied"},
+	{ErrUsed, "token_used"},
+	{ErrPurpose, "claim_invalid"},
+	{ErrRevoked, "cert_revoked"},
+	{errRevocationUnknown, "cert_status_unknown"},
+	{errAuthTime, "claim_invalid"},
+	{errAuthTimeType, "claim_invalid"},
+	{errEntraVersion, "claim_invalid"},
+	{errEntraTenant, "claim_invalid"},
+	{errCognitoTokenUse, "claim_invalid"},
+	{errCognitoClient, "claim_invalid"},
+	{errKeycloakType, "claim_invalid"},
+	{errKeycloakAZP, "claim_invalid"},
+	{errSETEvents, "claim_invalid"},
+	{errSETExpires, "claim_invalid"},
+	{errJARClient, "claim_invalid"},
+	{errAssertionTTL, "claim_invalid"},
+}
+
+// ErrorCode returns a stable identifier for the cause of a verification
+// failure. The codes are:
+//
+//	sig_invalid          signature mismatch (ErrSigMiss)
+//	alg_none             unsecured token (ErrUnsecured)
+//	alg_unsupported      algorithm not in use (AlgError)
+//	alg_unavailable      hash function not linked into binary
+//	token_expired        expiry exceeded (ErrExpired)
+//	token_not_yet_valid  not-before pending (ErrNotBefore)
+//	token_future_iat     issued-at pending (ErrIssuedAt)
+//	iss_mismatch         issuer not accepted (ErrIssuer)
+//	aud_mismatch         audience not accepted (ErrAudience)
+//	claim_missing        required claim absent (ErrClaimMiss)
+//	claim_invalid        claim value not accepted
+//	nonce_mismatch       nonce not confirmed (ErrNonce)
+//	token_missing        no HTTP authorization (ErrNoHeader)
+//	auth_scheme_invalid  HTTP authorization without Bearer
+//	token_malformed      encoding violation
+//	crit_unsupported     critical JOSE header extension not understood
+//	key_missing          no key material
+//	access_denied        authorization policy decision (ErrDenied)
+//	token_used           one-time token redeemed before (ErrUsed)
+//	cert_revoked         certificate revoked (ErrRevoked)
+//	cert_status_unknown  certificate revocation status unavailable
+//	token_invalid        any other error
+//
+// The empty string is returned for nil.
+func ErrorCode(err error) string {
+	if err == nil {
+		return ""
+	}
+	for _, e := range errorCodes {
+		if errors.Is(err, e.err) {
+			return e.code
+		}
+	}
+	if errors.As(err, new(AlgError)) {
+		return "alg_unsupported"
+	}
+	if errors.As(err, new(*SchemaError)) {
+		return "claim_invalid"
+	}
+	if errors.As(err, new(base64.CorruptInputError)) ||
+		errors.As(err, new(*json.SyntaxError)) ||
+		errors.As(err, new(*json.UnmarshalTypeError)) {
+		return "token_malformed"
+	}
+	return "token_invalid"
+}
+
+// CodeError decorates an error with its ErrorCode.
+type CodeError struct {
+	Err error // cause
+}
+
+// Error honors the error interface.
+func (e CodeError) Error() string { return e.Err.Error() }
+
+// Unwrap honors the errors package conventions.
+func (e CodeError) Unwrap() error { return e.Err }
+
+// Code returns the ErrorCode.
+func (e CodeError) Code() string { return ErrorCode(e.Err) }
+
+// MarshalJSON honors the json.Marshaler interface. The object has a "code"
+// with the ErrorCode and a "message" with the error description.
+func (e CodeError) MarshalJSON() ([]byte, error) {
+	return json.Marshal(struct {
+		Code    string `json:"code"`
+		Message string `json:"message"`
+	}{e.Code(), e.Err.Error()})
+}
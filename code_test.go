@@ -0,0 +1,62 @@
+package jwt
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"testing"
+)
+
+func TestErrorCode(t *testing.T) {
+	golden := []struct {
+		err  error
+		want string
+	}{
+		{nil, ""},
+		{ErrSigMiss, "sig_invalid"},
+		{ErrUnsecured, "alg_none"},
+		{AlgError("HS1"), "alg_unsupported"},
+		{ErrExpired, "token_expired"},
+		{fmt.Errorf("%w: %q", ErrClaimMiss, "sub"), "claim_missing"},
+		{ErrAudience, "aud_mismatch"},
+		{errors.New("other"), "token_invalid"},
+	}
+	for _, gold := range golden {
+		if got := ErrorCode(gold.err); got != gold.want {
+			t.Errorf("got code %q for %v, want %q", got, gold.err, gold.want)
+		}
+	}
+
+	tokens := []struct {
+		token string
+		want  string
+	}{
+		{"eyJhbGciOiJIUzI1NiJ9", "token_malformed"},
+		{"eyJhbGciOiJIUzI1NiJ9.#.e30", "token_malformed"},
+		{"eyJhbGciOiJIUzI1NiJ9.e30.e30", "sig_invalid"},
+		{"eyJhbGciOiJub25lIn0.e30.", "alg_none"},
+		{"eyJhbGciOiJIUzI1NiIsImNyaXQiOlsiYSJdfQ.e30.e30", "crit_unsupported"},
+	}
+	for _, gold := range tokens {
+		_, err := HMACCheck([]byte(gold.token), []byte("guest"))
+		if got := ErrorCode(err); got != gold.want {
+			t.Errorf("got code %q for token %q with error %v, want %q", got, gold.token, err, gold.want)
+		}
+	}
+}
+
+func TestCodeErrorJSON(t *testing.T) {
+	err := error(CodeError{ErrExpired})
+	if !errors.Is(err, ErrExpired) {
+		t.Error("cause not unwrapped")
+	}
+
+	got, e := json.Marshal(err)
+	if e != nil {
+		t.Fatal("marshal error:", e)
+	}
+	const want = `{"code":"token_expired","message":"jwt: token expired"}`
+	if string(got) != want {
+		t.Errorf("got JSON %s, want %s", got, want)
+	}
+}
@@ -0,0 +1,56 @@
+package jwt
+
+import (
+	"bytes"
+	"compress/flate"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+)
+
+// The "zip" header parameter borrows from JWE, as in “JSON Web Encryption
+// (JWE)” RFC 7516, subsection 4.1.3. JWS has no such parameter, hence it is
+// listed as critical, which makes recipients without support reject the
+// token, instead of a misinterpretation of the compressed payload.
+const zipHeader = `{"zip":"DEF","crit":["zip"]}`
+
+// InflateLimit is the maximum number of bytes in a decompressed payload.
+const InflateLimit = 1 << 20
+
+var (
+	errZipAlg   = errors.New("jwt: unsupported zip algorithm in JOSE header")
+	errZipLimit = fmt.Errorf("jwt: decompressed payload exceeds %d bytes", InflateLimit)
+)
+
+// Deflate returns the raw DEFLATE, as in “DEFLATE Compressed Data Format
+// Specification version 1.3” RFC 1951, of data.
+func deflate(data []byte) ([]byte, error) {
+	var buf bytes.Buffer
+	w, err := flate.NewWriter(&buf, flate.BestCompression)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := w.Write(data); err != nil {
+		return nil, err
+	}
+	if err := w.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// Inflate returns the decompression of a raw DEFLATE. The output is limited
+// to InflateLimit to protect against compression bombs.
+func inflate(data []byte) ([]byte, error) {
+	r := flate.NewReader(bytes.NewReader(data))
+	defer r.Close()
+	out, err := ioutil.ReadAll(io.LimitReader(r, InflateLimit+1))
+	if err != nil {
+		return nil, fmt.Errorf("jwt: malformed compressed payload: %w", err)
+	}
+	if len(out) > InflateLimit {
+		return nil, errZipLimit
+	}
+	return out, nil
+}
@@ -0,0 +1,89 @@
+//go:build !jwt_hmac_only
+// +build !jwt_hmac_only
+
+package jwt
+
+import (
+	"bytes"
+	"crypto/ed25519"
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestCompress(t *testing.T) {
+	var c Claims
+	c.Subject = "alice"
+	c.Set = map[string]interface{}{"entitlements": strings.Repeat("read:document ", 200)}
+	c.Compress = 512
+	token, err := c.EdDSASign(testKeyEd25519Private)
+	if err != nil {
+		t.Fatal("sign error:", err)
+	}
+	if len(token) > 512 {
+		t.Errorf("got %d bytes of compressed token, want less than 512", len(token))
+	}
+	if !bytes.Contains(c.RawHeader, []byte(`"zip":"DEF"`)) {
+		t.Errorf("got JOSE header %s, want zip parameter", c.RawHeader)
+	}
+
+	got, err := EdDSACheck(token, testKeyEd25519Public)
+	if err != nil {
+		t.Fatal("check error:", err)
+	}
+	if got.Subject != "alice" || got.Set["entitlements"] != c.Set["entitlements"] {
+		t.Errorf("got claims %+v", got)
+	}
+	if !bytes.Equal(got.Raw, c.Raw) {
+		t.Errorf("got payload %s, want %s", got.Raw, c.Raw)
+	}
+
+	if got, err := ParseWithoutCheck(token); err != nil {
+		t.Error("parse error:", err)
+	} else if !bytes.Equal(got.Raw, c.Raw) {
+		t.Errorf("got parsed payload %s, want %s", got.Raw, c.Raw)
+	}
+	if err := Canonical(token); err != nil {
+		t.Error("canonical error:", err)
+	}
+
+	keys := &KeyRegister{EdDSAs: []ed25519.PublicKey{testKeyEd25519Public}}
+	if _, err := keys.Check(token); err != nil {
+		t.Error("key register check error:", err)
+	}
+}
+
+func TestCompressThreshold(t *testing.T) {
+	var c Claims
+	c.Subject = "alice"
+	c.Compress = 512
+	if _, err := c.EdDSASign(testKeyEd25519Private); err != nil {
+		t.Fatal("sign error:", err)
+	}
+	if string(c.RawHeader) != `{"alg":"EdDSA"}` {
+		t.Errorf("got JOSE header %s for small payload, want no compression", c.RawHeader)
+	}
+}
+
+func TestCompressZipUnknown(t *testing.T) {
+	var c Claims
+	c.Subject = "alice"
+	token, err := c.EdDSASign(testKeyEd25519Private, []byte(`{"zip":"GZ","crit":["zip"]}`))
+	if err != nil {
+		t.Fatal("sign error:", err)
+	}
+	_, err = EdDSACheck(token, testKeyEd25519Public)
+	if !errors.Is(err, errZipAlg) {
+		t.Errorf("got error %v, want %v", err, errZipAlg)
+	}
+}
+
+func TestInflateLimit(t *testing.T) {
+	bomb, err := deflate(make([]byte, InflateLimit+1))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := inflate(bomb); err != errZipLimit {
+		t.Errorf("got error %v, want %v", err, errZipLimit)
+	}
+}
@@ -0,0 +1,62 @@
+//go:build !jwt_hmac_only
+// +build !jwt_hmac_only
+
+package jwt
+
+import (
+	"context"
+	"crypto/ed25519"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestRequireScope(t *testing.T) {
+	golden := []struct {
+		claims *Claims
+		scopes []string
+		want   error
+	}{
+		{nil, nil, ErrNoClaims},
+		{&Claims{}, nil, nil},
+		{&Claims{Set: map[string]interface{}{"scope": "read write"}}, []string{"write", "read"}, nil},
+		{&Claims{Set: map[string]interface{}{"scope": "read"}}, []string{"write"}, ErrDenied},
+		{&Claims{Set: map[string]interface{}{"scp": []interface{}{"read"}}}, []string{"read"}, nil},
+		{&Claims{Set: map[string]interface{}{"scp": "read write"}}, []string{"write"}, nil},
+	}
+	for i, gold := range golden {
+		ctx := context.Background()
+		if gold.claims != nil {
+			ctx = ContextWithClaims(ctx, gold.claims)
+		}
+		if err := RequireScope(ctx, gold.scopes...); !errors.Is(err, gold.want) && err != gold.want {
+			t.Errorf("%d: got error %v, want %v", i, err, gold.want)
+		}
+	}
+
+	if err := RequireClaims(context.Background()); err != ErrNoClaims {
+		t.Errorf("got error %v without claims, want %v", err, ErrNoClaims)
+	}
+}
+
+func TestClaimsContextKey(t *testing.T) {
+	var got *Claims
+	handler := Handler{
+		Target: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			got, _ = ClaimsFromContext(r.Context())
+		}),
+		Keys:       &KeyRegister{EdDSAs: []ed25519.PublicKey{testKeyEd25519Public}},
+		ContextKey: ClaimsContextKey,
+	}
+
+	c := &Claims{Registered: Registered{Subject: "u1"}}
+	req := httptest.NewRequest("POST", "/graphql", nil)
+	if err := c.EdDSASignHeader(req, testKeyEd25519Private); err != nil {
+		t.Fatal("sign error:", err)
+	}
+	handler.ServeHTTP(httptest.NewRecorder(), req)
+	if got == nil || got.Subject != "u1" {
+		t.Errorf("got claims %+v from context, want subject u1", got)
+	}
+}
@@ -0,0 +1,27 @@
+//go:build go1.21
+// +build go1.21
+
+package jwt
+
+import (
+	"crypto/x509"
+	"time"
+)
+
+// ParseCRL reads a DER-encoded certificate revocation list.
+func parseCRL(der []byte) (*x509.RevocationList, error) {
+	return x509.ParseRevocationList(der)
+}
+
+// CRLRevoked returns the serial numbers on list, if, and only if, issuer
+// signed it.
+func crlRevoked(list *x509.RevocationList, issuer *x509.Certificate) (serials map[string]struct{}, nextUpdate time.Time, err error) {
+	if err := list.CheckSignatureFrom(issuer); err != nil {
+		return nil, time.Time{}, err
+	}
+	serials = make(map[string]struct{}, len(list.RevokedCertificateEntries))
+	for _, entry := range list.RevokedCertificateEntries {
+		serials[string(entry.SerialNumber.Bytes())] = struct{}{}
+	}
+	return serials, list.NextUpdate, nil
+}
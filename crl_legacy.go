@@ -0,0 +1,29 @@
+//go:build !go1.21
+// +build !go1.21
+
+package jwt
+
+import (
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"time"
+)
+
+// ParseCRL reads a DER-encoded certificate revocation list. Go 1.21 replaces
+// the implementation with x509.ParseRevocationList.
+func parseCRL(der []byte) (*pkix.CertificateList, error) {
+	return x509.ParseDERCRL(der)
+}
+
+// CRLRevoked returns the serial numbers on list, if, and only if, issuer
+// signed it.
+func crlRevoked(list *pkix.CertificateList, issuer *x509.Certificate) (serials map[string]struct{}, nextUpdate time.Time, err error) {
+	if err := issuer.CheckCRLSignature(list); err != nil {
+		return nil, time.Time{}, err
+	}
+	serials = make(map[string]struct{}, len(list.TBSCertList.RevokedCertificates))
+	for _, entry := range list.TBSCertList.RevokedCertificates {
+		serials[string(entry.SerialNumber.Bytes())] = struct{}{}
+	}
+	return serials, list.TBSCertList.NextUpdate, nil
+}
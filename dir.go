@@ -0,0 +1,229 @@
+package jwt
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// LoadDir adds the keys from each file in a directory, non-recursive. Files
+// with a .pem, .crt, .key or .pub extension are read with LoadPEM, without
+// password. Files with a .der or .cer extension are read with LoadDER. Files
+// with a .json, .jwk or .jwks extension are read with LoadJWK. Other files,
+// and files with a name starting with a dot, are ignored.
+//
+// Keys without a key ID get one derived. Files with one key only use the file
+// name, without extension, as the key ID. Keys from files with more than one
+// key use their thumbprint, as described by RFC 7638.
+func (keys *KeyRegister) LoadDir(path string) (keysAdded int, err error) {
+	files, err := dirKeyFiles(path)
+	if err != nil {
+		return 0, err
+	}
+	for _, f := range files {
+		n, err := keys.loadFile(filepath.Join(path, f.Name()))
+		keysAdded += n
+		if err != nil {
+			return keysAdded, err
+		}
+	}
+	return keysAdded, nil
+}
+
+// DirKeyFiles returns the applicable files in name order, with symbolic links
+// resolved.
+func dirKeyFiles(path string) ([]os.FileInfo, error) {
+	d, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	names, err := d.Readdirnames(-1)
+	d.Close()
+	if err != nil {
+		return nil, err
+	}
+	sort.Strings(names)
+
+	var files []os.FileInfo
+	for _, name := range names {
+		if strings.HasPrefix(name, ".") {
+			continue
+		}
+		switch strings.ToLower(filepath.Ext(name)) {
+		case ".pem", ".crt", ".key", ".pub", ".der", ".cer", ".json", ".jwk", ".jwks":
+			break
+		default:
+			continue
+		}
+		info, err := os.Stat(filepath.Join(path, name))
+		if err != nil {
+			return nil, err
+		}
+		if info.Mode().IsRegular() {
+			files = append(files, info)
+		}
+	}
+	return files, nil
+}
+
+func (keys *KeyRegister) loadFile(path string) (keysAdded int, err error) {
+	text, err := ioutil.ReadFile(path)
+	if err != nil {
+		return 0, err
+	}
+
+	var file KeyRegister
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".json", ".jwk", ".jwks":
+		_, err = file.LoadJWK(text)
+	case ".der", ".cer":
+		_, err = file.LoadDER(text)
+	default:
+		_, err = file.LoadPEM(text, nil)
+	}
+	if err != nil {
+		return 0, fmt.Errorf("jwt: key file %q unusable: %w", path, err)
+	}
+
+	var fileKeys []interface{}
+	var fileIDs []string
+	file.each(func(key interface{}, kid string) {
+		fileKeys = append(fileKeys, key)
+		fileIDs = append(fileIDs, kid)
+	})
+	for i, key := range fileKeys {
+		kid := fileIDs[i]
+		if kid == "" {
+			if len(fileKeys) == 1 {
+				base := filepath.Base(path)
+				kid = strings.TrimSuffix(base, filepath.Ext(base))
+			} else {
+				kid, _ = Thumbprint(key) // secrets get none
+			}
+		}
+		if err := keys.add(key, kid); err != nil {
+			return keysAdded, err
+		}
+		keysAdded++
+	}
+	return keysAdded, nil
+}
+
+// Each calls f for every key in the register, including its key ID, if any.
+func (keys *KeyRegister) each(f func(key interface{}, kid string)) {
+	id := func(ids []string, i int) string {
+		if i < len(ids) {
+			return ids[i]
+		}
+		return ""
+	}
+	for i, k := range keys.ECDSAs {
+		f(k, id(keys.ECDSAIDs, i))
+	}
+	for i, k := range keys.EdDSAs {
+		f(k, id(keys.EdDSAIDs, i))
+	}
+	for i, k := range keys.RSAs {
+		f(k, id(keys.RSAIDs, i))
+	}
+	for i, k := range keys.Secrets {
+		f(k, id(keys.SecretIDs, i))
+	}
+}
+
+// DirKeys is a KeyRegister loaded from a directory with LoadDir. Changes to
+// the directory content are picked up on use, yet no more than once per
+// MinRefresh. Such polling suits rotation schemes which replace files, like
+// Kubernetes secret volumes as maintained by cert-manager. File system
+// notifications, like with fsnotify, are not used, as these would require a
+// dependency, and as they are unreliable on network and container volumes.
+//
+// Multiple goroutines may invoke methods on a DirKeys simultaneously.
+type DirKeys struct {
+	// Path locates the directory.
+	Path string
+
+	// MinRefresh is the minimum amount of time between two directory
+	// scans. Zero defaults to DefaultMinRefresh.
+	MinRefresh time.Duration
+
+	mutex   sync.Mutex
+	keys    *KeyRegister // nil before first load
+	stamp   string       // file listing with size and modification time
+	scanned time.Time    // last scan attempt
+}
+
+// Check parses a JWT if, and only if, the signature checks out.
+// Use Claims.Valid to complete the verification.
+func (d *DirKeys) Check(token []byte) (*Claims, error) {
+	return d.CheckTrace(token, nil)
+}
+
+// CheckTrace is like Check, with each verification step recorded in trace.
+func (d *DirKeys) CheckTrace(token []byte, trace *Trace) (*Claims, error) {
+	keys, err := d.Keys(context.Background())
+	if err != nil {
+		return nil, err
+	}
+	return keys.check(token, trace)
+}
+
+// Keys returns the current register. Any directory changes are loaded first,
+// unless the previous scan is less than MinRefresh ago. Errors on reload keep
+// the previous register in place. A ctx which is done skips the scan, in which
+// case the previous register, if any, remains in place too. File reads are not
+// interrupted. The register must not be modified.
+func (d *DirKeys) Keys(ctx context.Context) (*KeyRegister, error) {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+
+	minRefresh := d.MinRefresh
+	if minRefresh <= 0 {
+		minRefresh = DefaultMinRefresh
+	}
+	if d.keys != nil && time.Since(d.scanned) < minRefresh {
+		return d.keys, nil
+	}
+	if err := ctx.Err(); err != nil {
+		if d.keys == nil {
+			return nil, err
+		}
+		return d.keys, nil
+	}
+	if err := d.reload(); err != nil && d.keys == nil {
+		return nil, err
+	}
+	return d.keys, nil
+}
+
+// Reload updates the register on directory changes. The caller must hold the
+// mutex.
+func (d *DirKeys) reload() error {
+	d.scanned = time.Now()
+
+	files, err := dirKeyFiles(d.Path)
+	if err != nil {
+		return err
+	}
+	var stamp strings.Builder
+	for _, f := range files {
+		fmt.Fprintf(&stamp, "%s %d %d\n", f.Name(), f.Size(), f.ModTime().UnixNano())
+	}
+	if d.keys != nil && stamp.String() == d.stamp {
+		return nil
+	}
+
+	keys := new(KeyRegister)
+	if _, err := keys.LoadDir(d.Path); err != nil {
+		return err
+	}
+	d.keys = keys
+	d.stamp = stamp.String()
+	return nil
+}
@@ -0,0 +1,127 @@
+//go:build !jwt_hmac_only && !jwt_no_rsa && !jwt_no_ecdsa
+// +build !jwt_hmac_only,!jwt_no_rsa,!jwt_no_ecdsa
+
+package jwt
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func writeTestPEM(t *testing.T, path string, keys *KeyRegister) {
+	t.Helper()
+	text, err := keys.PEM()
+	if err != nil {
+		t.Fatal("PEM export error:", err)
+	}
+	if err := ioutil.WriteFile(path, text, 0o600); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestLoadDir(t *testing.T) {
+	dir, err := ioutil.TempDir("", "jwt-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	writeTestPEM(t, filepath.Join(dir, "ed.pem"), &KeyRegister{EdDSAs: []ed25519.PublicKey{testKeyEd25519Public}})
+	writeTestPEM(t, filepath.Join(dir, "multi.crt"), &KeyRegister{
+		ECDSAs: []*ecdsa.PublicKey{&testKeyEC256.PublicKey},
+		RSAs:   []*rsa.PublicKey{&testKeyRSA2048.PublicKey},
+	})
+	if err := ioutil.WriteFile(filepath.Join(dir, "rfc.jwks"), []byte(testJWKSEd25519), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if err := ioutil.WriteFile(filepath.Join(dir, "README"), []byte("ignored"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if err := ioutil.WriteFile(filepath.Join(dir, ".hidden.pem"), []byte("ignored"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	var keys KeyRegister
+	n, err := keys.LoadDir(dir)
+	if err != nil {
+		t.Fatal("load error:", err)
+	}
+	if n != 4 {
+		t.Errorf("got %d keys added, want 4", n)
+	}
+
+	if got := keys.EdDSAIDs; len(got) != 2 || got[0] != "ed" || got[1] != "rfc" {
+		t.Errorf("got EdDSA key IDs %q, want file name and JWK", got)
+	}
+	want, err := Thumbprint(&testKeyEC256.PublicKey)
+	if err != nil {
+		t.Fatal("thumbprint error:", err)
+	}
+	if got := keys.ECDSAIDs; len(got) != 1 || got[0] != want {
+		t.Errorf("got ECDSA key IDs %q, want thumbprint %q", got, want)
+	}
+
+	if err := ioutil.WriteFile(filepath.Join(dir, "broken.key"), []byte("-----BEGIN FOO-----\n-----END FOO-----\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := new(KeyRegister).LoadDir(dir); err == nil {
+		t.Error("no error for broken key file")
+	}
+}
+
+func TestDirKeys(t *testing.T) {
+	dir, err := ioutil.TempDir("", "jwt-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	var c Claims
+	c.KeyID = "next"
+	token, err := c.EdDSASign(testKeyEd25519Private)
+	if err != nil {
+		t.Fatal("sign error:", err)
+	}
+
+	keys := DirKeys{Path: dir, MinRefresh: time.Hour}
+	if _, err := keys.Check(token); err != ErrSigMiss {
+		t.Errorf("got error %v for empty directory, want %v", err, ErrSigMiss)
+	}
+
+	writeTestPEM(t, filepath.Join(dir, "next.pem"), &KeyRegister{EdDSAs: []ed25519.PublicKey{testKeyEd25519Public}})
+	if _, err := keys.Check(token); err != ErrSigMiss {
+		t.Errorf("got error %v within MinRefresh, want %v", err, ErrSigMiss)
+	}
+	keys.MinRefresh = time.Nanosecond
+	if _, err := keys.Check(token); err != nil {
+		t.Error("check error after rotation:", err)
+	}
+
+	// broken update keeps previous keys
+	if err := ioutil.WriteFile(filepath.Join(dir, "broken.pem"), []byte("-----BEGIN FOO-----\n-----END FOO-----\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := keys.Check(token); err != nil {
+		t.Error("check error after broken update:", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := (&DirKeys{Path: dir}).Keys(ctx); err != context.Canceled {
+		t.Errorf("got error %v for cancelled first load, want %v", err, context.Canceled)
+	}
+	if got, err := keys.Keys(ctx); err != nil || got == nil {
+		t.Errorf("got (%v, %v) for cancelled reload, want previous keys", got, err)
+	}
+
+	if _, err := (&DirKeys{Path: filepath.Join(dir, "absent")}).Check(token); !os.IsNotExist(err) {
+		t.Errorf("got error %v for absent directory, want not exist", err)
+	}
+}
@@ -0,0 +1,96 @@
+package jwt
+
+import (
+	"encoding/json"
+	"net/http"
+)
+
+// TokenHandler is an OAuth 2.0 token endpoint which issues a new access token,
+// and optionally a refresh token, per successful authentication. See RFC 6749,
+// section 5 for the protocol. Requests other than POST are denied.
+type TokenHandler struct {
+	// Authenticate validates the credentials of a request, with its
+	// form already parsed. The subject is the principal authenticated,
+	// with the empty string for denial (invalid_grant). The extra claims
+	// are optional. Errors are not exposed; they cause an HTTP status
+	// code 500 (Internal Server Error).
+	Authenticate func(r *http.Request) (subject string, extraClaims map[string]interface{}, err error)
+
+	// Access issues the access tokens.
+	Access *Issuer
+
+	// Refresh, when not nil, issues a refresh token next to each access
+	// token. Authenticate should accept the refresh_token grant type in
+	// such case. Refresh tokens have a "typ" claim with RefreshTokenType.
+	// Use a key and audience other than those of Access, as verifiers of
+	// access tokens would accept refresh tokens otherwise.
+	Refresh *Issuer
+}
+
+// RefreshTokenType is the "typ" claim value of refresh tokens from a
+// TokenHandler.
+const RefreshTokenType = "refresh"
+
+// ServeHTTP honors the http.Handler interface.
+func (h *TokenHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	// “The authorization server MUST include the HTTP "Cache-Control"
+	// response header field [RFC2616] with a value of "no-store" in any
+	// response containing tokens, credentials, or other sensitive
+	// information, as well as the "Pragma" response header field
+	// [RFC2616] with a value of "no-cache".”
+	// — “The OAuth 2.0 Authorization Framework” RFC 6749, subsection 5.1
+	w.Header().Set("Cache-Control", "no-store")
+	w.Header().Set("Pragma", "no-cache")
+
+	if r.Method != http.MethodPost {
+		w.Header().Set("Allow", http.MethodPost)
+		http.Error(w, "jwt: token request requires POST", http.StatusMethodNotAllowed)
+		return
+	}
+	if err := r.ParseForm(); err != nil {
+		writeTokenJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid_request"})
+		return
+	}
+
+	subject, extraClaims, err := h.Authenticate(r)
+	if err != nil {
+		http.Error(w, "jwt: authentication unavailable", http.StatusInternalServerError)
+		return
+	}
+	if subject == "" {
+		writeTokenJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid_grant"})
+		return
+	}
+
+	var resp struct {
+		AccessToken  string `json:"access_token"`
+		TokenType    string `json:"token_type"`
+		ExpiresIn    int64  `json:"expires_in,omitempty"`
+		RefreshToken string `json:"refresh_token,omitempty"`
+	}
+	token, err := h.Access.IssueContext(r.Context(), subject, extraClaims)
+	if err != nil {
+		http.Error(w, "jwt: token issue unavailable", http.StatusInternalServerError)
+		return
+	}
+	resp.AccessToken = string(token)
+	resp.TokenType = "Bearer"
+	resp.ExpiresIn = int64(h.Access.TTL.Seconds())
+
+	if h.Refresh != nil {
+		token, err := h.Refresh.IssueContext(r.Context(), subject, map[string]interface{}{"typ": RefreshTokenType})
+		if err != nil {
+			http.Error(w, "jwt: token issue unavailable", http.StatusInternalServerError)
+			return
+		}
+		resp.RefreshToken = string(token)
+	}
+
+	writeTokenJSON(w, http.StatusOK, &resp)
+}
+
+func writeTokenJSON(w http.ResponseWriter, statusCode int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json;charset=UTF-8")
+	w.WriteHeader(statusCode)
+	json.NewEncoder(w).Encode(v)
+}
@@ -0,0 +1,116 @@
+//go:build !jwt_hmac_only
+// +build !jwt_hmac_only
+
+package jwt
+
+import (
+	"crypto/ed25519"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestTokenHandler(t *testing.T) {
+	s, err := NewSigner(EdDSA, testKeyEd25519Private)
+	if err != nil {
+		t.Fatal("signer error:", err)
+	}
+	access := NewIssuer(s, "")
+	access.TTL = 5 * time.Minute
+	access.Audiences = []string{"https://api.example.com"}
+	refresh := NewIssuer(s, "")
+	refresh.TTL = 24 * time.Hour
+	refresh.Audiences = []string{"https://example.com/token"}
+	h := &TokenHandler{
+		Authenticate: func(r *http.Request) (string, map[string]interface{}, error) {
+			switch r.PostForm.Get("password") {
+			case "secret":
+				return r.PostForm.Get("username"), map[string]interface{}{"scope": "all"}, nil
+			case "broken":
+				return "", nil, errors.New("database down")
+			}
+			return "", nil, nil
+		},
+		Access:  access,
+		Refresh: refresh,
+	}
+
+	resp := httptest.NewRecorder()
+	form := url.Values{"grant_type": {"password"}, "username": {"alice"}, "password": {"secret"}}
+	req := httptest.NewRequest(http.MethodPost, "/token", strings.NewReader(form.Encode()))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	h.ServeHTTP(resp, req)
+	if resp.Code != http.StatusOK {
+		t.Fatalf("got HTTP %d: %s", resp.Code, resp.Body)
+	}
+	if got := resp.Header().Get("Cache-Control"); got != "no-store" {
+		t.Errorf("got Cache-Control %q, want no-store", got)
+	}
+	if got := resp.Header().Get("Content-Type"); got != "application/json;charset=UTF-8" {
+		t.Errorf("got Content-Type %q", got)
+	}
+	var body struct {
+		AccessToken  string `json:"access_token"`
+		TokenType    string `json:"token_type"`
+		ExpiresIn    int64  `json:"expires_in"`
+		RefreshToken string `json:"refresh_token"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		t.Fatal("response body error:", err)
+	}
+	if body.TokenType != "Bearer" || body.ExpiresIn != 300 || body.RefreshToken == "" {
+		t.Errorf("got response %+v", body)
+	}
+	c, err := EdDSACheck([]byte(body.AccessToken), testKeyEd25519Public)
+	if err != nil {
+		t.Fatal("access token check error:", err)
+	}
+	if scope, _ := c.String("scope"); c.Subject != "alice" || scope != "all" {
+		t.Errorf("got access token claims %s", c.Raw)
+	}
+
+	c, err = EdDSACheck([]byte(body.RefreshToken), testKeyEd25519Public)
+	if err != nil {
+		t.Fatal("refresh token check error:", err)
+	}
+	if typ, _ := c.String("typ"); typ != RefreshTokenType {
+		t.Errorf("got refresh token claims %s, want typ %q", c.Raw, RefreshTokenType)
+	}
+	v := Verifier{
+		Keys:   &KeyRegister{EdDSAs: []ed25519.PublicKey{testKeyEd25519Public}},
+		Policy: Policy{Audiences: access.Audiences},
+	}
+	if _, err := v.Check([]byte(body.AccessToken)); err != nil {
+		t.Error("access token rejected by access verifier:", err)
+	}
+	if _, err := v.Check([]byte(body.RefreshToken)); !errors.Is(err, ErrAudience) {
+		t.Errorf("got error %v for refresh token at access verifier, want %v", err, ErrAudience)
+	}
+
+	golden := []struct {
+		method, password string
+		wantCode         int
+	}{
+		{http.MethodPost, "wrong", http.StatusBadRequest},
+		{http.MethodPost, "broken", http.StatusInternalServerError},
+		{http.MethodGet, "secret", http.StatusMethodNotAllowed},
+	}
+	for _, gold := range golden {
+		resp := httptest.NewRecorder()
+		form := url.Values{"username": {"alice"}, "password": {gold.password}}
+		req := httptest.NewRequest(gold.method, "/token", strings.NewReader(form.Encode()))
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		h.ServeHTTP(resp, req)
+		if resp.Code != gold.wantCode {
+			t.Errorf("%s with password %q: got HTTP %d, want %d", gold.method, gold.password, resp.Code, gold.wantCode)
+		}
+		if strings.Contains(resp.Body.String(), "database") {
+			t.Errorf("error exposed: %s", resp.Body)
+		}
+	}
+}
@@ -0,0 +1,22 @@
+package jwt
+
+import (
+	"crypto/sha256"
+	"crypto/subtle"
+)
+
+// Equal compares tokens, signatures or any other secret value in constant time,
+// as opposed to bytes.Equal. The length of the values is not protected. Compare
+// the TokenDigest of each value when the length is secret too.
+func Equal(a, b []byte) bool {
+	return subtle.ConstantTimeCompare(a, b) == 1
+}
+
+// TokenDigest returns the SHA-256 of a token, in base64url encoding. Stores for
+// refresh tokens and replay detection should hold the digest, rather than the
+// token, such that a leak of the store does not leak credentials. The digests
+// have a fixed length, which makes them suitable for Equal.
+func TokenDigest(token []byte) string {
+	sum := sha256.Sum256(token)
+	return encoding.EncodeToString(sum[:])
+}
@@ -0,0 +1,28 @@
+package jwt
+
+import "testing"
+
+func TestEqual(t *testing.T) {
+	golden := []struct {
+		a, b string
+		want bool
+	}{
+		{"", "", true},
+		{"eyJhbGciOiJIUzI1NiJ9", "eyJhbGciOiJIUzI1NiJ9", true},
+		{"eyJhbGciOiJIUzI1NiJ9", "eyJhbGciOiJIUzI1NiJ8", false},
+		{"eyJhbGciOiJIUzI1NiJ9", "eyJhbGciOiJIUzI1NiJ", false},
+	}
+	for _, gold := range golden {
+		if got := Equal([]byte(gold.a), []byte(gold.b)); got != gold.want {
+			t.Errorf("got %t for %q and %q", got, gold.a, gold.b)
+		}
+	}
+}
+
+func TestTokenDigest(t *testing.T) {
+	// SHA-256 of "abc" from FIPS 180-2, appendix B.1
+	const want = "ungWv48Bz-pBQUDeXa4iI7ADYaOWF3qctBD_YfIAFa0"
+	if got := TokenDigest([]byte("abc")); got != want {
+		t.Errorf("got digest %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,55 @@
+//go:build ignore
+// +build ignore
+
+// Package graph has the gqlgen directives for a schema with:
+//
+//	directive @auth on FIELD_DEFINITION
+//	directive @hasScope(scope: String!) on FIELD_DEFINITION
+//
+// Copy the file into the package with the resolvers, and install with:
+//
+//	cfg := generated.Config{Resolvers: &Resolver{}}
+//	cfg.Directives.Auth = Auth
+//	cfg.Directives.HasScope = HasScope
+//
+// The build tag keeps the dependencies out of the jwt module. Claims come from
+// a jwt.Handler, with AllowAnonymous and jwt.ClaimsContextKey, in front of the
+// GraphQL server.
+package graph
+
+import (
+	"context"
+	"errors"
+
+	"github.com/99designs/gqlgen/graphql"
+	"github.com/pascaldekloe/jwt"
+	"github.com/vektah/gqlparser/v2/gqlerror"
+)
+
+// Auth implements the @auth directive.
+func Auth(ctx context.Context, obj interface{}, next graphql.Resolver) (interface{}, error) {
+	if err := jwt.RequireClaims(ctx); err != nil {
+		return nil, directiveError(err)
+	}
+	return next(ctx)
+}
+
+// HasScope implements the @hasScope directive.
+func HasScope(ctx context.Context, obj interface{}, next graphql.Resolver, scope string) (interface{}, error) {
+	if err := jwt.RequireScope(ctx, scope); err != nil {
+		return nil, directiveError(err)
+	}
+	return next(ctx)
+}
+
+// DirectiveError adds the conventional error code as an extension.
+func directiveError(err error) error {
+	code := "FORBIDDEN"
+	if errors.Is(err, jwt.ErrNoClaims) {
+		code = "UNAUTHENTICATED"
+	}
+	return &gqlerror.Error{
+		Message:    err.Error(),
+		Extensions: map[string]interface{}{"code": code},
+	}
+}
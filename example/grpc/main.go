@@ -0,0 +1,115 @@
+//go:build ignore
+// +build ignore
+
+// Package main demonstrates gRPC interceptors with jwt.Casbin enforcement. The
+// build tag keeps the dependencies out of the jwt module.
+package main
+
+import (
+	"context"
+	"errors"
+	"io/ioutil"
+	"log"
+	"net"
+	"strings"
+	"time"
+
+	"github.com/casbin/casbin/v2"
+	"github.com/pascaldekloe/jwt"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// Authz verifies the bearer token from the request metadata, and it enforces
+// the Casbin policy on the method invoked.
+type authz struct {
+	keys   jwt.Checker
+	casbin *jwt.Casbin
+}
+
+// Claims returns a context with the verified claims for the method.
+func (a *authz) claims(ctx context.Context, fullMethod string) (context.Context, error) {
+	md, _ := metadata.FromIncomingContext(ctx)
+	values := md.Get("authorization")
+	if len(values) != 1 || len(values[0]) < 7 || !strings.EqualFold(values[0][:7], "Bearer ") {
+		return nil, status.Error(codes.Unauthenticated, "bearer token required")
+	}
+	c, err := a.keys.Check([]byte(values[0][7:]))
+	if err != nil {
+		return nil, status.Error(codes.Unauthenticated, err.Error())
+	}
+	if !c.Valid(time.Now()) {
+		return nil, status.Error(codes.Unauthenticated, "jwt: time constraints exceeded")
+	}
+
+	if err := a.casbin.AuthorizeMethod(fullMethod, c); err != nil {
+		// the Casbin request stays out of the response
+		log.Print(err)
+		if errors.Is(err, jwt.ErrDenied) {
+			return nil, status.Error(codes.PermissionDenied, jwt.ErrDenied.Error())
+		}
+		return nil, status.Error(codes.Internal, "jwt: authorization unavailable")
+	}
+	return jwt.ContextWithClaims(ctx, c), nil
+}
+
+// Unary implements grpc.UnaryServerInterceptor.
+func (a *authz) unary(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+	ctx, err := a.claims(ctx, info.FullMethod)
+	if err != nil {
+		return nil, err
+	}
+	return handler(ctx, req)
+}
+
+// Stream implements grpc.StreamServerInterceptor.
+func (a *authz) stream(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+	ctx, err := a.claims(ss.Context(), info.FullMethod)
+	if err != nil {
+		return err
+	}
+	return handler(srv, claimsStream{ss, ctx})
+}
+
+// ClaimsStream is a grpc.ServerStream with the verified claims in its context.
+type claimsStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+// Context overrides the grpc.ServerStream method.
+func (s claimsStream) Context() context.Context { return s.ctx }
+
+func main() {
+	// policy with rows like "p, alice, orders.v1.Orders, GetOrder"
+	enforcer, err := casbin.NewEnforcer("model.conf", "policy.csv")
+	if err != nil {
+		log.Fatal(err)
+	}
+	text, err := ioutil.ReadFile("keys.pem")
+	if err != nil {
+		log.Fatal(err)
+	}
+	var keys jwt.KeyRegister
+	if _, err := keys.LoadPEM(text, nil); err != nil {
+		log.Fatal(err)
+	}
+
+	a := &authz{
+		keys:   &keys,
+		casbin: &jwt.Casbin{Enforcer: enforcer, RolesClaim: "roles"},
+	}
+	srv := grpc.NewServer(
+		grpc.UnaryInterceptor(a.unary),
+		grpc.StreamInterceptor(a.stream),
+	)
+	// register services on srv here
+
+	l, err := net.Listen("tcp", ":50051")
+	if err != nil {
+		log.Fatal(err)
+	}
+	log.Fatal(srv.Serve(l))
+}
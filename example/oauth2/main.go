@@ -0,0 +1,67 @@
+//go:build ignore
+// +build ignore
+
+// Package main demonstrates the use of a jwt.TokenSource with the
+// golang.org/x/oauth2 package. The build tag keeps the dependency out of
+// the jwt module.
+package main
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/pascaldekloe/jwt"
+	"golang.org/x/oauth2"
+)
+
+// TokenSource adapts a jwt.TokenSource to the oauth2.TokenSource interface.
+type tokenSource struct{ jwt.TokenSource }
+
+// Token implements the oauth2.TokenSource interface.
+func (s tokenSource) Token() (*oauth2.Token, error) {
+	t, err := s.TokenSource.Token()
+	if err != nil {
+		return nil, err
+	}
+	return &oauth2.Token{
+		AccessToken: t.AccessToken,
+		TokenType:   t.TokenType,
+		Expiry:      t.Expiry,
+	}, nil
+}
+
+func main() {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		log.Fatal(err)
+	}
+	signer, err := jwt.NewSigner(jwt.ES256, key)
+	if err != nil {
+		log.Fatal(err)
+	}
+	grant := &jwt.BearerGrant{
+		Issuer:        "client-1",
+		Subject:       "client-1",
+		TokenEndpoint: "https://auth.example.com/token",
+		Signer:        signer,
+		TTL:           time.Minute,
+	}
+
+	// Refreshes run without the context of any request, so the
+	// client remains usable for the lifetime of the process.
+	src := grant.TokenSource(&http.Client{Timeout: 10 * time.Second})
+	client := &http.Client{Transport: &oauth2.Transport{
+		Source: oauth2.ReuseTokenSource(nil, tokenSource{src}),
+	}}
+
+	resp, err := client.Get("https://api.example.com/v1/status")
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer resp.Body.Close()
+	log.Print(resp.Status)
+}
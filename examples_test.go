@@ -1,3 +1,6 @@
+//go:build !jwt_hmac_only && !jwt_no_rsa && !jwt_no_ecdsa
+// +build !jwt_hmac_only,!jwt_no_rsa,!jwt_no_ecdsa
+
 package jwt_test
 
 import (
@@ -0,0 +1,101 @@
+package jwt
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// Header is the JOSE header of a JWS, with the parameters from “JSON Web
+// Signature (JWS)” RFC 7515, section 4.1.
+type Header struct {
+	Alg         string          `json:"alg,omitempty"`      // algorithm
+	KeyID       string          `json:"kid,omitempty"`      // key ID
+	Type        string          `json:"typ,omitempty"`      // media type of the JWS
+	ContentType string          `json:"cty,omitempty"`      // media type of the payload
+	Crit        []string        `json:"crit,omitempty"`     // extensions which must be understood
+	X5C         []string        `json:"x5c,omitempty"`      // X.509 certificate chain, in base64 DER
+	X5T         string          `json:"x5t,omitempty"`      // X.509 SHA-1 thumbprint, in base64url
+	X5TS256     string          `json:"x5t#S256,omitempty"` // X.509 SHA-256 thumbprint, in base64url
+	X5U         string          `json:"x5u,omitempty"`      // X.509 URL
+	JKU         string          `json:"jku,omitempty"`      // JWK Set URL
+	JWK         json.RawMessage `json:"jwk,omitempty"`      // JSON Web Key
+
+	// Unknown has any other parameters by name.
+	Unknown map[string]json.RawMessage `json:"-"`
+}
+
+// Header parses the JOSE header. The Check functions and the Sign methods both
+// set RawHeader, which is the source.
+func (c *Claims) Header() (*Header, error) {
+	h := new(Header)
+	if err := json.Unmarshal([]byte(c.RawHeader), h); err != nil {
+		return nil, fmt.Errorf("jwt: malformed JOSE header: %w", err)
+	}
+	return h, nil
+}
+
+// UnmarshalJSON honors the json.Unmarshaler interface.
+func (h *Header) UnmarshalJSON(data []byte) error {
+	type known Header // drops the methods
+	if err := json.Unmarshal(data, (*known)(h)); err != nil {
+		return err
+	}
+
+	var all map[string]json.RawMessage
+	if err := json.Unmarshal(data, &all); err != nil {
+		return err
+	}
+	for _, name := range headerNames {
+		delete(all, name)
+	}
+	if len(all) != 0 {
+		h.Unknown = all
+	} else {
+		h.Unknown = nil
+	}
+	return nil
+}
+
+var headerNames = []string{"alg", "kid", "typ", "cty", "crit", "x5c", "x5t", "x5t#S256", "x5u", "jku", "jwk"}
+
+// ExtraHeader returns the JSON object for the extraHeaders argument of the
+// Sign methods. Alg and KeyID are omitted, as signing sets them from the
+// algorithm and Claims.KeyID respectively.
+func (h *Header) ExtraHeader() (json.RawMessage, error) {
+	m := make(map[string]interface{}, len(h.Unknown)+8)
+	for name, value := range h.Unknown {
+		m[name] = value
+	}
+	for _, name := range headerNames {
+		delete(m, name)
+	}
+
+	if h.Type != "" {
+		m["typ"] = h.Type
+	}
+	if h.ContentType != "" {
+		m["cty"] = h.ContentType
+	}
+	if len(h.Crit) != 0 {
+		m["crit"] = h.Crit
+	}
+	if len(h.X5C) != 0 {
+		m["x5c"] = h.X5C
+	}
+	if h.X5T != "" {
+		m["x5t"] = h.X5T
+	}
+	if h.X5TS256 != "" {
+		m["x5t#S256"] = h.X5TS256
+	}
+	if h.X5U != "" {
+		m["x5u"] = h.X5U
+	}
+	if h.JKU != "" {
+		m["jku"] = h.JKU
+	}
+	if len(h.JWK) != 0 {
+		m["jwk"] = h.JWK
+	}
+	return json.Marshal(m)
+}
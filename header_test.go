@@ -0,0 +1,43 @@
+package jwt
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+func TestHeader(t *testing.T) {
+	h := &Header{
+		Type:    "at+jwt",
+		X5TS256: "bnVsbA",
+		Crit:    []string{"exp"},
+		Unknown: map[string]json.RawMessage{"exp": json.RawMessage(`1600000000`), "alg": json.RawMessage(`"none"`)},
+	}
+	extra, err := h.ExtraHeader()
+	if err != nil {
+		t.Fatal("extra header error:", err)
+	}
+	const want = `{"crit":["exp"],"exp":1600000000,"typ":"at+jwt","x5t#S256":"bnVsbA"}`
+	if string(extra) != want {
+		t.Errorf("got extra header %s, want %s", extra, want)
+	}
+
+	c := &Claims{KeyID: "k1"}
+	if _, err := c.HMACSign(HS256, []byte("guest"), extra); err != nil {
+		t.Fatal("sign error:", err)
+	}
+	got, err := c.Header()
+	if err != nil {
+		t.Fatal("header error:", err)
+	}
+	h.Alg = HS256
+	h.KeyID = "k1"
+	delete(h.Unknown, "alg")
+	if !reflect.DeepEqual(got, h) {
+		t.Errorf("got header %+v, want %+v", got, h)
+	}
+
+	if _, err := (&Claims{RawHeader: json.RawMessage(`[]`)}).Header(); err == nil {
+		t.Error("no error for array header")
+	}
+}
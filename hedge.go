@@ -0,0 +1,112 @@
+package jwt
+
+import (
+	"context"
+	"time"
+)
+
+// SetFallback installs a secondary signing key, e.g., a local key next to a KMS,
+// such that issuance survives outages of the primary signer. Tokens signed by
+// the fallback are identified by kid in the JOSE header. Recipients need the
+// public keys of both signers. A nil s removes the fallback.
+//
+// The fallback starts when the signer from NewIssuer or SetSigner fails, or
+// when it doesn't complete within HedgeDelay, whichever comes first. The first
+// signature to complete is used, and the other signer gets its context
+// cancelled.
+func (iss *Issuer) SetFallback(s Signer, kid string) {
+	iss.mutex.Lock()
+	defer iss.mutex.Unlock()
+	iss.fallback = s
+	iss.fallbackKeyID = kid
+}
+
+// Sign returns the token of claims from the claims method, signed by s, or by
+// the fallback signer, if any.
+func (iss *Issuer) sign(ctx context.Context, c *Claims, s Signer) ([]byte, error) {
+	iss.mutex.RLock()
+	fallback, fallbackKeyID := iss.fallback, iss.fallbackKeyID
+	iss.mutex.RUnlock()
+	if fallback == nil {
+		return c.SignContext(ctx, s)
+	}
+
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	// compose both tokens before any concurrency, as
+	// newToken writes the Registered values in Set
+	primaryToken, err := c.newToken(s.Alg(), 0, nil)
+	if err != nil {
+		return nil, err
+	}
+	alt := *c
+	alt.KeyID = fallbackKeyID
+	if c.Set != nil {
+		alt.Set = make(map[string]interface{}, len(c.Set))
+		for name, value := range c.Set {
+			alt.Set[name] = value
+		}
+	}
+	fallbackToken, err := alt.newToken(fallback.Alg(), 0, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel() // aborts the signer which lost
+
+	type result struct {
+		claims *Claims
+		alg    string
+		token  []byte
+		err    error
+	}
+	results := make(chan result, 2) // never blocks
+	start := func(c *Claims, s Signer, token []byte) {
+		go func() {
+			token, err := appendSignature(ctx, s, token)
+			results <- result{c, s.Alg(), token, err}
+		}()
+	}
+
+	start(c, s, primaryToken)
+	var hedge <-chan time.Time
+	if iss.HedgeDelay > 0 {
+		timer := time.NewTimer(iss.HedgeDelay)
+		defer timer.Stop()
+		hedge = timer.C
+	}
+
+	pending, fallbackStarted := 1, false
+	var primaryErr error
+	for {
+		select {
+		case <-hedge:
+			hedge = nil
+			if !fallbackStarted {
+				fallbackStarted = true
+				pending++
+				start(&alt, fallback, fallbackToken)
+			}
+
+		case r := <-results:
+			pending--
+			if r.err == nil {
+				r.claims.audit(ctx, r.alg)
+				return r.token, nil
+			}
+			if r.claims == c {
+				primaryErr = r.err
+			}
+			if !fallbackStarted {
+				fallbackStarted = true
+				pending++
+				start(&alt, fallback, fallbackToken)
+			} else if pending == 0 {
+				// error from primary
+				return nil, primaryErr
+			}
+		}
+	}
+}
@@ -0,0 +1,92 @@
+//go:build !jwt_hmac_only
+// +build !jwt_hmac_only
+
+package jwt
+
+import (
+	"context"
+	"crypto/ed25519"
+	"errors"
+	"testing"
+	"time"
+)
+
+// FailSigner returns err, after ctx is done when err is nil.
+type failSigner struct {
+	Signer
+	err error
+}
+
+func (s failSigner) Sign(ctx context.Context, data []byte) ([]byte, error) {
+	if s.err != nil {
+		return nil, s.err
+	}
+	<-ctx.Done()
+	return nil, ctx.Err()
+}
+
+func TestIssuerFallback(t *testing.T) {
+	hmac, err := NewSigner(HS256, []byte("guest"))
+	if err != nil {
+		t.Fatal("signer error:", err)
+	}
+	local, err := NewSigner(EdDSA, testKeyEd25519Private)
+	if err != nil {
+		t.Fatal("signer error:", err)
+	}
+	keys := &KeyRegister{
+		Secrets:  [][]byte{[]byte("guest")},
+		EdDSAs:   []ed25519.PublicKey{testKeyEd25519Public},
+		EdDSAIDs: []string{"local"},
+	}
+	errKMS := errors.New("KMS unavailable")
+
+	golden := []struct {
+		primary    Signer
+		hedgeDelay time.Duration
+		wantKeyID  string
+	}{
+		{hmac, 0, "kms"},
+		{hmac, time.Hour, "kms"},
+		{failSigner{hmac, errKMS}, 0, "local"},
+		{failSigner{hmac, errKMS}, time.Hour, "local"},
+		{failSigner{Signer: hmac}, time.Millisecond, "local"},
+	}
+	for i, gold := range golden {
+		iss := NewIssuer(gold.primary, "kms")
+		iss.SetFallback(local, "local")
+		iss.HedgeDelay = gold.hedgeDelay
+		token, err := iss.Issue("alice", map[string]interface{}{"tier": "gold"})
+		if err != nil {
+			t.Errorf("%d: issue error: %v", i, err)
+			continue
+		}
+		c, err := keys.Check(token)
+		if err != nil {
+			t.Errorf("%d: check error: %v", i, err)
+			continue
+		}
+		if c.KeyID != gold.wantKeyID || c.Subject != "alice" || c.Set["tier"] != "gold" {
+			t.Errorf("%d: got kid %q and claims %+v, want kid %q", i, c.KeyID, c, gold.wantKeyID)
+		}
+	}
+}
+
+func TestIssuerFallbackError(t *testing.T) {
+	hmac, err := NewSigner(HS256, []byte("guest"))
+	if err != nil {
+		t.Fatal("signer error:", err)
+	}
+	errKMS := errors.New("KMS unavailable")
+	errLocal := errors.New("local key unavailable")
+	iss := NewIssuer(failSigner{hmac, errKMS}, "kms")
+	iss.SetFallback(failSigner{hmac, errLocal}, "local")
+	if _, err := iss.Issue("alice", nil); err != errKMS {
+		t.Errorf("got error %v, want primary error %v", err, errKMS)
+	}
+
+	iss.SetFallback(nil, "")
+	if _, err := iss.Issue("alice", nil); err != errKMS {
+		t.Errorf("got error %v without fallback, want %v", err, errKMS)
+	}
+}
@@ -0,0 +1,107 @@
+package jwt
+
+import (
+	"net/http"
+	"time"
+)
+
+// IntrospectionHandler is an OAuth 2.0 token introspection endpoint for tokens
+// from an Issuer. See RFC 7662 for the protocol. Resource servers which treat
+// access tokens as opaque can interoperate without JWT support this way.
+// Requests other than POST are denied.
+type IntrospectionHandler struct {
+	// Authenticate validates the credentials of the protected resource,
+	// with the request form already parsed. Denials get HTTP status code
+	// 401 (Unauthorized). Errors are not exposed; they cause an HTTP
+	// status code 500 (Internal Server Error).
+	Authenticate func(r *http.Request) (ok bool, err error)
+
+	// Keys verifies the signature of each token.
+	Keys Checker
+
+	// Revoked, when not nil, excludes tokens from being active.
+	Revoked func(*Claims) bool
+
+	// Claims lists the names of the claims disclosed in the response
+	// of active tokens. All claims are disclosed when nil.
+	Claims []string
+
+	// Now overrides time.Now for the active calculation when not nil.
+	Now func() time.Time
+}
+
+// ServeHTTP honors the http.Handler interface.
+func (h *IntrospectionHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Cache-Control", "no-store")
+	w.Header().Set("Pragma", "no-cache")
+
+	if r.Method != http.MethodPost {
+		w.Header().Set("Allow", http.MethodPost)
+		http.Error(w, "jwt: introspection request requires POST", http.StatusMethodNotAllowed)
+		return
+	}
+	if err := r.ParseForm(); err != nil {
+		writeTokenJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid_request"})
+		return
+	}
+
+	// “To prevent token scanning attacks, the endpoint MUST also require
+	// some form of authorization to access this endpoint […]”
+	// — “OAuth 2.0 Token Introspection” RFC 7662, section 2.1
+	ok, err := h.Authenticate(r)
+	if err != nil {
+		http.Error(w, "jwt: authentication unavailable", http.StatusInternalServerError)
+		return
+	}
+	if !ok {
+		http.Error(w, "jwt: introspection requires authentication", http.StatusUnauthorized)
+		return
+	}
+
+	token := r.PostForm.Get("token")
+	if token == "" {
+		writeTokenJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid_request"})
+		return
+	}
+
+	// “If the introspection call is properly authorized but the token is
+	// not active, does not exist on this server, or the protected
+	// resource is not allowed to introspect this particular token, then
+	// the authorization server MUST return an introspection response
+	// with the "active" field set to "false".”
+	// — “OAuth 2.0 Token Introspection” RFC 7662, subsection 2.2
+	inactive := map[string]bool{"active": false}
+	claims, err := h.Keys.Check([]byte(token))
+	if err != nil {
+		writeTokenJSON(w, http.StatusOK, inactive)
+		return
+	}
+	now := time.Now
+	if h.Now != nil {
+		now = h.Now
+	}
+	if !claims.Valid(now()) || h.Revoked != nil && h.Revoked(claims) {
+		writeTokenJSON(w, http.StatusOK, inactive)
+		return
+	}
+
+	var all map[string]interface{}
+	if err := claims.DecodeSet(&all); err != nil {
+		writeTokenJSON(w, http.StatusOK, inactive)
+		return
+	}
+	resp := make(map[string]interface{}, len(all)+1)
+	if h.Claims == nil {
+		for name, value := range all {
+			resp[name] = value
+		}
+	} else {
+		for _, name := range h.Claims {
+			if value, ok := all[name]; ok {
+				resp[name] = value
+			}
+		}
+	}
+	resp["active"] = true
+	writeTokenJSON(w, http.StatusOK, resp)
+}
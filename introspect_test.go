@@ -0,0 +1,98 @@
+//go:build !jwt_hmac_only
+// +build !jwt_hmac_only
+
+package jwt
+
+import (
+	"crypto/ed25519"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestIntrospectionHandler(t *testing.T) {
+	s, err := NewSigner(EdDSA, testKeyEd25519Private)
+	if err != nil {
+		t.Fatal("signer error:", err)
+	}
+	iss := NewIssuer(s, "")
+	iss.TTL = time.Minute
+	active, err := iss.Issue("alice", map[string]interface{}{"scope": "read", "secret": "x"})
+	if err != nil {
+		t.Fatal("issue error:", err)
+	}
+	revoked, err := iss.Issue("bob", nil)
+	if err != nil {
+		t.Fatal("issue error:", err)
+	}
+
+	h := &IntrospectionHandler{
+		Authenticate: func(r *http.Request) (bool, error) {
+			user, pass, _ := r.BasicAuth()
+			if pass == "broken" {
+				return false, errors.New("database down")
+			}
+			return user == "rs" && pass == "secret", nil
+		},
+		Keys:    &KeyRegister{EdDSAs: []ed25519.PublicKey{testKeyEd25519Public}},
+		Revoked: func(c *Claims) bool { return c.Subject == "bob" },
+		Claims:  []string{"sub", "scope", "exp"},
+	}
+
+	introspect := func(token, pass string) *httptest.ResponseRecorder {
+		form := url.Values{"token": {token}}
+		req := httptest.NewRequest(http.MethodPost, "/introspect", strings.NewReader(form.Encode()))
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		req.SetBasicAuth("rs", pass)
+		resp := httptest.NewRecorder()
+		h.ServeHTTP(resp, req)
+		return resp
+	}
+
+	resp := introspect(string(active), "secret")
+	if resp.Code != http.StatusOK {
+		t.Fatalf("got HTTP %d: %s", resp.Code, resp.Body)
+	}
+	if got := resp.Header().Get("Cache-Control"); got != "no-store" {
+		t.Errorf("got Cache-Control %q, want no-store", got)
+	}
+	var body map[string]interface{}
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		t.Fatal("response body error:", err)
+	}
+	if body["active"] != true || body["sub"] != "alice" || body["scope"] != "read" || body["exp"] == nil {
+		t.Errorf("got response %v, want active alice with scope read and exp", body)
+	}
+	if _, ok := body["secret"]; ok {
+		t.Error("response discloses claim absent from filter")
+	}
+
+	for _, token := range []string{string(revoked), "not a token"} {
+		resp := introspect(token, "secret")
+		if got := strings.TrimSpace(resp.Body.String()); resp.Code != http.StatusOK || got != `{"active":false}` {
+			t.Errorf("token %q: got HTTP %d: %s, want inactive", token, resp.Code, got)
+		}
+	}
+
+	h.Now = func() time.Time { return time.Now().Add(time.Hour) }
+	if got := strings.TrimSpace(introspect(string(active), "secret").Body.String()); got != `{"active":false}` {
+		t.Errorf("expired token got %s, want inactive", got)
+	}
+
+	if resp := introspect(string(active), "wrong"); resp.Code != http.StatusUnauthorized {
+		t.Errorf("wrong credentials got HTTP %d, want 401", resp.Code)
+	}
+	if resp := introspect(string(active), "broken"); resp.Code != http.StatusInternalServerError {
+		t.Errorf("authentication error got HTTP %d, want 500", resp.Code)
+	}
+	resp = httptest.NewRecorder()
+	h.ServeHTTP(resp, httptest.NewRequest(http.MethodGet, "/introspect", nil))
+	if resp.Code != http.StatusMethodNotAllowed {
+		t.Errorf("GET got HTTP %d, want 405", resp.Code)
+	}
+}
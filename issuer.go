@@ -0,0 +1,182 @@
+package jwt
+
+import (
+	"context"
+	"crypto"
+	"crypto/rand"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"sync"
+	"time"
+)
+
+var errNoSigner = errors.New("jwt: issuer without signer")
+
+// Issuer produces tokens with a common configuration.
+//
+// Multiple goroutines may invoke methods on an Issuer simultaneously. The
+// exported fields must not be modified after first use.
+type Issuer struct {
+	// Name is the "iss" claim value. The empty string omits the claim.
+	Name string
+
+	// Audiences is the "aud" claim value. Nil omits the claim.
+	Audiences []string
+
+	// TTL is the time to live from the moment of issue, as expressed by
+	// the "exp" claim. Zero omits the claim.
+	TTL time.Duration
+
+	// Now is the time source. Nil defaults to time.Now.
+	Now func() time.Time
+
+	// Compress is the payload size threshold, as in Claims.Compress.
+	Compress int
+
+	// HedgeDelay is the amount of time the signer gets before the
+	// fallback signer, if any, starts in parallel. Zero disables hedging,
+	// i.e., the fallback signer starts only when the signer failed. See
+	// SetFallback for details.
+	HedgeDelay time.Duration
+
+	mutex         sync.RWMutex
+	signer        Signer
+	keyID         string
+	fallback      Signer // optional
+	fallbackKeyID string
+}
+
+// NewIssuer returns a new Issuer which signs with s, identified by kid in the
+// JOSE header. The empty string omits the key ID.
+func NewIssuer(s Signer, kid string) *Issuer {
+	return &Issuer{signer: s, keyID: kid}
+}
+
+// SetSigner replaces the signing key, e.g., on key rotation. Tokens issued
+// after the call are signed by s, identified by kid in the JOSE header.
+func (iss *Issuer) SetSigner(s Signer, kid string) {
+	iss.mutex.Lock()
+	defer iss.mutex.Unlock()
+	iss.signer = s
+	iss.keyID = kid
+}
+
+// Issue returns a new token for the subject, identified with a UUIDv7 "jti"
+// claim, and with the "iat" claim set to the current time. The extra claims
+// are optional. Any of the Registered claim names in extra are overruled when
+// the Issuer has a value for them.
+func (iss *Issuer) Issue(subject string, extraClaims map[string]interface{}) ([]byte, error) {
+	return iss.IssueContext(context.Background(), subject, extraClaims)
+}
+
+// IssueContext is like Issue, with ctx applied to the signer.
+func (iss *Issuer) IssueContext(ctx context.Context, subject string, extraClaims map[string]interface{}) ([]byte, error) {
+	c, s, err := iss.claims(subject, extraClaims)
+	if err != nil {
+		return nil, err
+	}
+	return iss.sign(ctx, c, s)
+}
+
+// Claims returns a new claim set with its signer.
+func (iss *Issuer) claims(subject string, extraClaims map[string]interface{}) (*Claims, Signer, error) {
+	iss.mutex.RLock()
+	s, kid := iss.signer, iss.keyID
+	iss.mutex.RUnlock()
+	if s == nil {
+		return nil, nil, errNoSigner
+	}
+
+	c := &Claims{KeyID: kid, Compress: iss.Compress}
+	if extraClaims != nil {
+		c.Set = make(map[string]interface{}, len(extraClaims)+6)
+		for name, value := range extraClaims {
+			c.Set[name] = value
+		}
+	}
+
+	now := time.Now
+	if iss.Now != nil {
+		now = iss.Now
+	}
+	c.StampTTL(now(), iss.TTL)
+
+	c.Issuer = iss.Name
+	c.Subject = subject
+	c.Audiences = iss.Audiences
+
+	if err := c.GenerateID(); err != nil {
+		return nil, nil, err
+	}
+
+	return c, s, nil
+}
+
+// StampTTL sets the "iat" claim to now, and the "exp" claim to now plus ttl,
+// both truncated to seconds, such that neither is in the future. A zero ttl clears "exp". Use StampTTL right before
+// signing to prevent stale time claims on reused Claims.
+func (c *Claims) StampTTL(now time.Time, ttl time.Duration) {
+	now = now.Truncate(time.Second)
+	c.Issued = NewNumericTime(now)
+	if ttl == 0 {
+		c.Expires = nil
+	} else {
+		c.Expires = NewNumericTime(now.Add(ttl))
+	}
+}
+
+// GenerateID sets the "jti" claim to a new UUID version 7, unless the claim is
+// set already. The identifiers are time-ordered, with 74 bits of randomness
+// per millisecond. See RFC 9562, subsection 5.7 for details.
+func (c *Claims) GenerateID() error {
+	if c.ID != "" {
+		return nil
+	}
+	return c.generateID(time.Now())
+}
+
+func (c *Claims) generateID(now time.Time) error {
+	var uuid [16]byte
+	if _, err := rand.Read(uuid[6:]); err != nil {
+		return err
+	}
+	var ms [8]byte
+	binary.BigEndian.PutUint64(ms[:], uint64(now.UnixNano()/int64(time.Millisecond)))
+	copy(uuid[:6], ms[2:])
+	uuid[6] = uuid[6]&0x0f | 0x70 // version 7
+	uuid[8] = uuid[8]&0x3f | 0x80 // RFC variant
+
+	var buf [36]byte
+	hex.Encode(buf[0:8], uuid[0:4])
+	buf[8] = '-'
+	hex.Encode(buf[9:13], uuid[4:6])
+	buf[13] = '-'
+	hex.Encode(buf[14:18], uuid[6:8])
+	buf[18] = '-'
+	hex.Encode(buf[19:23], uuid[8:10])
+	buf[23] = '-'
+	hex.Encode(buf[24:], uuid[10:])
+	c.ID = string(buf[:])
+	return nil
+}
+
+// DeriveKeyID sets the key ID to the JWK thumbprint of key, as described in
+// RFC 7638, unless the key ID is set already. The key is either a public key,
+// a private key, or a crypto.Signer, which includes the Signer instances from
+// NewSigner for non-HMAC algorithms. The result matches the "kid" of any JWKS
+// which uses thumbprints for identification.
+func (c *Claims) DeriveKeyID(key interface{}) error {
+	if c.KeyID != "" {
+		return nil
+	}
+	if s, ok := key.(interface{ Public() crypto.PublicKey }); ok {
+		key = s.Public()
+	}
+	kid, err := Thumbprint(key)
+	if err != nil {
+		return err
+	}
+	c.KeyID = kid
+	return nil
+}
@@ -0,0 +1,170 @@
+//go:build !jwt_hmac_only && !jwt_no_ecdsa
+// +build !jwt_hmac_only,!jwt_no_ecdsa
+
+package jwt
+
+import (
+	"crypto/ed25519"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestIssuer(t *testing.T) {
+	s, err := NewSigner(EdDSA, testKeyEd25519Private)
+	if err != nil {
+		t.Fatal("signer error:", err)
+	}
+	iss := NewIssuer(s, "k1")
+	iss.Name = "https://auth.example.com"
+	iss.Audiences = []string{"api"}
+	iss.TTL = time.Hour
+	iss.Now = func() time.Time { return time.Unix(1600000000, 4e8) }
+
+	extra := map[string]interface{}{"scope": "read", "iss": "ignored"}
+	token, err := iss.Issue("alice", extra)
+	if err != nil {
+		t.Fatal("issue error:", err)
+	}
+	if extra["iss"] != "ignored" || len(extra) != 2 {
+		t.Errorf("extra claims modified: %v", extra)
+	}
+
+	keys := KeyRegister{EdDSAs: []ed25519.PublicKey{testKeyEd25519Public}, EdDSAIDs: []string{"k1"}}
+	c, err := keys.Check(token)
+	if err != nil {
+		t.Fatal("check error:", err)
+	}
+	if c.KeyID != "k1" {
+		t.Errorf("got key ID %q, want k1", c.KeyID)
+	}
+	if c.Issuer != iss.Name || c.Subject != "alice" || len(c.Audiences) != 1 || c.Audiences[0] != "api" {
+		t.Errorf("got claims %s", c.Raw)
+	}
+	if got, want := c.Issued.String(), "2020-09-13T12:26:40Z"; got != want {
+		t.Errorf("got issued %s, want %s", got, want)
+	}
+	if got, want := c.Expires.String(), "2020-09-13T13:26:40Z"; got != want {
+		t.Errorf("got expires %s, want %s", got, want)
+	}
+	if len(c.ID) != 36 {
+		t.Errorf("got token ID %q, want UUID", c.ID)
+	}
+	if scope, _ := c.String("scope"); scope != "read" {
+		t.Errorf("got scope %q, want read", scope)
+	}
+
+	again, err := iss.Issue("alice", nil)
+	if err != nil {
+		t.Fatal("issue error:", err)
+	}
+	if c2, err := keys.Check(again); err != nil {
+		t.Error("check error:", err)
+	} else if c2.ID == c.ID {
+		t.Error("token ID reused")
+	}
+}
+
+func TestIssuerRotation(t *testing.T) {
+	if _, err := new(Issuer).Issue("alice", nil); err != errNoSigner {
+		t.Errorf("got error %v, want %v", err, errNoSigner)
+	}
+
+	iss := NewIssuer(nil, "")
+	s, err := NewSigner(HS256, []byte("guest"))
+	if err != nil {
+		t.Fatal("signer error:", err)
+	}
+	iss.SetSigner(s, "k2")
+	token, err := iss.Issue("bob", nil)
+	if err != nil {
+		t.Fatal("issue error:", err)
+	}
+	c, err := HMACCheck(token, []byte("guest"))
+	if err != nil {
+		t.Fatal("check error:", err)
+	}
+	if c.KeyID != "k2" {
+		t.Errorf("got key ID %q, want k2", c.KeyID)
+	}
+}
+
+func TestStampTTL(t *testing.T) {
+	c := Claims{Registered: Registered{
+		Issued:  NewNumericTime(time.Unix(1, 0)),
+		Expires: NewNumericTime(time.Unix(2, 0)),
+	}}
+
+	now := time.Unix(1600000000, 6e8)
+	c.StampTTL(now, time.Minute)
+	if got, want := c.Issued.String(), "2020-09-13T12:26:40Z"; got != want {
+		t.Errorf("got issued %s, want %s", got, want)
+	}
+	if got, want := c.Expires.String(), "2020-09-13T12:27:40Z"; got != want {
+		t.Errorf("got expires %s, want %s", got, want)
+	}
+	if err := (&Policy{RejectFutureIssued: true}).Apply(&c, now); err != nil {
+		t.Error("stamped claims rejected without leeway:", err)
+	}
+
+	c.StampTTL(time.Unix(1600000000, 0), 0)
+	if c.Expires != nil {
+		t.Errorf("got expires %s for zero TTL, want none", c.Expires)
+	}
+}
+
+func TestGenerateID(t *testing.T) {
+	c := Claims{Registered: Registered{ID: "preset"}}
+	if err := c.GenerateID(); err != nil || c.ID != "preset" {
+		t.Errorf("got ID %q, error %v, want preset untouched", c.ID, err)
+	}
+
+	c.ID = ""
+	if err := c.generateID(time.Unix(1600000000, 123456789)); err != nil {
+		t.Fatal("generate error:", err)
+	}
+	// 1600000000123 milliseconds is 0x174876e807b
+	if want := "0174876e-807b-7"; !strings.HasPrefix(c.ID, want) {
+		t.Errorf("got ID %q, want prefix %q", c.ID, want)
+	}
+	if len(c.ID) != 36 || !strings.ContainsAny(c.ID[19:20], "89ab") {
+		t.Errorf("got ID %q, want UUID with RFC variant", c.ID)
+	}
+
+	first := c.ID
+	c.ID = ""
+	if err := c.GenerateID(); err != nil {
+		t.Fatal("generate error:", err)
+	}
+	if c.ID == first {
+		t.Error("ID reused")
+	}
+}
+
+func TestDeriveKeyID(t *testing.T) {
+	want, err := Thumbprint(testKeyEC256.Public())
+	if err != nil {
+		t.Fatal("thumbprint error:", err)
+	}
+	s, err := NewSigner(ES256, testKeyEC256)
+	if err != nil {
+		t.Fatal("signer error:", err)
+	}
+
+	for _, key := range []interface{}{testKeyEC256, &testKeyEC256.PublicKey, s} {
+		var c Claims
+		if err := c.DeriveKeyID(key); err != nil {
+			t.Errorf("%T: derive error: %s", key, err)
+		} else if c.KeyID != want {
+			t.Errorf("%T: got key ID %q, want %q", key, c.KeyID, want)
+		}
+	}
+
+	c := Claims{KeyID: "manual"}
+	if err := c.DeriveKeyID(testKeyEC256); err != nil || c.KeyID != "manual" {
+		t.Errorf("got key ID %q, error %v, want manual untouched", c.KeyID, err)
+	}
+	if err := new(Claims).DeriveKeyID([]byte("secret")); err == nil {
+		t.Error("no error for HMAC secret")
+	}
+}
@@ -0,0 +1,107 @@
+package jwt
+
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
+// JARHeader is the JOSE header extension for request objects, conform RFC
+// 9101, subsection 10.8. Use json.RawMessage(JARHeader) as an extra header
+// with any of the Sign methods.
+const JARHeader = `{"typ":"oauth-authz-req+jwt"}`
+
+var errJARClient = errors.New("jwt: request object client_id mismatch")
+
+// RequestObject is an authorization request in the form of a JWT, as defined by
+// RFC 9101 (JAR). Encryption to the authorization server is not supported, as
+// this package implements signatures (JWS) only.
+type RequestObject struct {
+	ClientID     string // required
+	ResponseType string // required
+	RedirectURI  string
+	Scope        string
+	State        string
+	Nonce        string
+
+	// Extra has any other request parameters, e.g., code_challenge.
+	Extra map[string]interface{}
+}
+
+// Claims returns new claims for a request to the authorization server
+// identified by audience. The issuer is ClientID. The claims expire after
+// ttl, and they are not valid before now, as required by FAPI.
+func (r *RequestObject) Claims(audience string, now time.Time, ttl time.Duration) (*Claims, error) {
+	c := &Claims{Set: make(map[string]interface{}, len(r.Extra)+6)}
+	for name, value := range r.Extra {
+		c.Set[name] = value
+	}
+	c.Set["client_id"] = r.ClientID
+	c.Set["response_type"] = r.ResponseType
+	for name, value := range map[string]string{
+		"redirect_uri": r.RedirectURI,
+		"scope":        r.Scope,
+		"state":        r.State,
+	} {
+		if value != "" {
+			c.Set[name] = value
+		}
+	}
+
+	c.Issuer = r.ClientID
+	c.Nonce = r.Nonce
+	c.Audiences = []string{audience}
+	c.StampTTL(now, ttl)
+	c.NotBefore = c.Issued
+	if err := c.GenerateID(); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+// ParseRequestObject returns the request from verified claims. The client ID
+// is the one from the authorization request, which must match the one in the
+// request object, as well as the issuer. See RFC 9101, section 5. Use a Policy
+// with the issuer of the authorization server in Audiences to complete the
+// validation.
+func ParseRequestObject(c *Claims, clientID string) (*RequestObject, error) {
+	r := &RequestObject{Nonce: c.Nonce, Extra: make(map[string]interface{})}
+	for name, value := range c.Set {
+		switch name {
+		case issuer, subject, audience, expires, notBefore, issued, id, sessionID, nonce, events:
+			continue
+		}
+		s, isString := value.(string)
+		switch name {
+		case "client_id", "response_type", "redirect_uri", "scope", "state":
+			if !isString {
+				return nil, fmt.Errorf("jwt: request object %s not a string", name)
+			}
+		}
+		switch name {
+		case "client_id":
+			r.ClientID = s
+		case "response_type":
+			r.ResponseType = s
+		case "redirect_uri":
+			r.RedirectURI = s
+		case "scope":
+			r.Scope = s
+		case "state":
+			r.State = s
+		default:
+			r.Extra[name] = value
+		}
+	}
+
+	if r.ClientID == "" {
+		return nil, fmt.Errorf("%w: %q", ErrClaimMiss, "client_id")
+	}
+	if r.ResponseType == "" {
+		return nil, fmt.Errorf("%w: %q", ErrClaimMiss, "response_type")
+	}
+	if r.ClientID != clientID || c.Issuer != clientID {
+		return nil, errJARClient
+	}
+	return r, nil
+}
@@ -0,0 +1,58 @@
+//go:build !jwt_hmac_only && !jwt_no_rsa
+// +build !jwt_hmac_only,!jwt_no_rsa
+
+package jwt
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestRequestObject(t *testing.T) {
+	now := time.Unix(1600000000, 0)
+	r := RequestObject{
+		ClientID:     "s6BhdRkqt3",
+		ResponseType: "code",
+		RedirectURI:  "https://client.example.org/cb",
+		Scope:        "openid",
+		State:        "af0ifjsldkj",
+		Extra:        map[string]interface{}{"code_challenge_method": "S256"},
+	}
+	c, err := r.Claims("https://server.example.com", now, time.Minute)
+	if err != nil {
+		t.Fatal("claims error:", err)
+	}
+	token, err := c.RSASign(PS256, testKeyRSA2048, json.RawMessage(JARHeader))
+	if err != nil {
+		t.Fatal("sign error:", err)
+	}
+
+	got, err := RSACheck(token, &testKeyRSA2048.PublicKey)
+	if err != nil {
+		t.Fatal("check error:", err)
+	}
+	p := Policy{Audiences: []string{"https://server.example.com"}, Require: []string{"exp", "nbf", "jti"}}
+	if err := p.Apply(got, now); err != nil {
+		t.Error("policy error:", err)
+	}
+	parsed, err := ParseRequestObject(got, "s6BhdRkqt3")
+	if err != nil {
+		t.Fatal("parse error:", err)
+	}
+	if parsed.ResponseType != "code" || parsed.RedirectURI != r.RedirectURI || parsed.State != r.State || parsed.Nonce != "" {
+		t.Errorf("got request %+v", parsed)
+	}
+	if len(parsed.Extra) != 1 || parsed.Extra["code_challenge_method"] != "S256" {
+		t.Errorf("got extra parameters %v", parsed.Extra)
+	}
+
+	if _, err := ParseRequestObject(got, "other"); err != errJARClient {
+		t.Errorf("got error %v for other client, want %v", err, errJARClient)
+	}
+	delete(got.Set, "response_type")
+	if _, err := ParseRequestObject(got, "s6BhdRkqt3"); !errors.Is(err, ErrClaimMiss) {
+		t.Errorf("got error %v, want %v", err, ErrClaimMiss)
+	}
+}
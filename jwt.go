@@ -5,7 +5,6 @@ package jwt
 import (
 	"crypto"
 	"crypto/hmac"
-	"crypto/rsa"
 	_ "crypto/sha256" // link into binary
 	_ "crypto/sha512" // link into binary
 	"encoding/base64"
@@ -13,6 +12,8 @@ import (
 	"errors"
 	"fmt"
 	"math"
+	"strconv"
+	"strings"
 	"sync"
 	"time"
 )
@@ -112,10 +113,6 @@ func NewHMAC(alg string, secret []byte) (*HMAC, error) {
 
 var encoding = base64.RawURLEncoding
 
-// “The size of the salt value is the same size as the hash function output.”
-// — “JSON Web Algorithms (JWA)” RFC 7518, subsection 3.5
-var pSSOptions = rsa.PSSOptions{SaltLength: rsa.PSSSaltLengthEqualsHash}
-
 // Standard (IANA registered) claim names.
 const (
 	issuer    = "iss"
@@ -125,6 +122,9 @@ const (
 	notBefore = "nbf"
 	issued    = "iat"
 	id        = "jti"
+	sessionID = "sid"
+	nonce     = "nonce"
+	events    = "events"
 )
 
 // Registered “JSON Web Token Claims” has a subset of the IANA registration.
@@ -155,6 +155,22 @@ type Registered struct {
 
 	// ID provides a unique identifier for the JWT.
 	ID string `json:"jti,omitempty"`
+
+	// The Check functions keep a copy of "sid", "nonce" and "events" in
+	// Claims.Set, where these claims resided before the fields below.
+	// The copy is scheduled for removal in a future release.
+
+	// SessionID identifies the session at the issuer, as in “OpenID
+	// Connect Front-Channel Logout 1.0”, section 3.
+	SessionID string `json:"sid,omitempty"`
+
+	// Nonce associates a client session with an ID token, as in
+	// “OpenID Connect Core 1.0”, subsection 2.
+	Nonce string `json:"nonce,omitempty"`
+
+	// Events has the payload of each security event by type, as in
+	// “Security Event Token (SET)” RFC 8417, subsection 2.2.
+	Events map[string]map[string]interface{} `json:"events,omitempty"`
 }
 
 // Valid returns whether the claims set may be accepted for processing at the
@@ -170,6 +186,39 @@ func (r *Registered) Valid(t time.Time) bool {
 		(r.NotBefore == nil || *r.NotBefore <= *n)
 }
 
+// ExpiresIn returns the duration until expiry, which is negative once expired.
+// The return is false on absence of the exp(iration) claim.
+func (r *Registered) ExpiresIn(now time.Time) (d time.Duration, ok bool) {
+	if r.Expires == nil {
+		return 0, false
+	}
+	return r.Expires.Time().Sub(now), true
+}
+
+// ValidWindow returns the NotBefore and Expires time, with zero for absence.
+func (r *Registered) ValidWindow() (start, end time.Time) {
+	return r.NotBefore.Time(), r.Expires.Time()
+}
+
+// NeedsRefresh returns whether the JWT expires within threshold from now, or
+// whether it expired already. Tokens without exp(iration) never need refresh.
+func (r *Registered) NeedsRefresh(now time.Time, threshold time.Duration) bool {
+	d, ok := r.ExpiresIn(now)
+	return ok && d <= threshold
+}
+
+// RoundTimes rounds Expires, NotBefore and Issued to a multiple of precision.
+// Use time.Second to emit integers, for compatibility with validators which
+// reject fractions. See the bugs section for details. Values from the Check
+// functions keep the precision as received, within float64 limits.
+func (r *Registered) RoundTimes(precision time.Duration) {
+	for _, p := range []**NumericTime{&r.Expires, &r.NotBefore, &r.Issued} {
+		if *p != nil {
+			*p = NewNumericTime((*p).Time().Round(precision))
+		}
+	}
+}
+
 // AcceptAudience verifies the applicability of an audience identified as
 // stringOrURI. Any stringOrURI is accepted on absence of the aud(ience) claim.
 func (r *Registered) AcceptAudience(stringOrURI string) bool {
@@ -205,6 +254,9 @@ type Claims struct {
 	Raw json.RawMessage
 	// RawHeader encoding as is within the token. This field is read-only.
 	RawHeader json.RawMessage
+	// RawSignature is the decoded signature, as set by the Check functions.
+	// This field is read-only.
+	RawSignature []byte
 
 	// “The "kid" (key ID) Header Parameter is a hint indicating which key
 	// was used to secure the JWS. This parameter allows originators to
@@ -213,6 +265,16 @@ type Claims struct {
 	// string. Use of this Header Parameter is OPTIONAL.”
 	// — “JSON Web Signature (JWS)” RFC 7515, subsection 4.1.4
 	KeyID string
+
+	// Compress enables DEFLATE compression of the payload when the JSON
+	// exceeds the number of bytes. Zero disables compression. Compressed
+	// tokens carry a "zip" header parameter, listed in "crit", which the
+	// Check functions of this package resolve transparently. Other
+	// implementations are likely to reject such tokens.
+	Compress int
+
+	// payload compressed, as found by scan
+	zip bool
 }
 
 // String returns the claim when present and if the representation is a JSON string.
@@ -233,6 +295,10 @@ func (c *Claims) String(name string) (value string, ok bool) {
 		}
 	case id:
 		value = c.ID
+	case sessionID:
+		value = c.SessionID
+	case nonce:
+		value = c.Nonce
 	}
 	if value != "" {
 		return value, true
@@ -267,6 +333,91 @@ func (c *Claims) Number(name string) (value float64, ok bool) {
 	return
 }
 
+// DecodeSet unmarshals the claims into target, conform json.Unmarshal. Use the
+// ",string" option in struct tags for numbers encoded as JSON strings. Raw is
+// the source when present, as set by the Check functions and the Sign methods.
+// Otherwise, Set is the source, with the Registered values merged.
+func (c *Claims) DecodeSet(target interface{}) error {
+	raw, err := c.payload()
+	if err != nil {
+		return err
+	}
+	if err := json.Unmarshal(raw, target); err != nil {
+		return fmt.Errorf("jwt: claims decode: %w", err)
+	}
+	return nil
+}
+
+// Payload returns Raw when present. Otherwise, the return is the JSON of Set,
+// with the Registered values merged.
+func (c *Claims) payload() ([]byte, error) {
+	if len(c.Raw) != 0 {
+		return []byte(c.Raw), nil
+	}
+
+	m := make(map[string]interface{}, len(c.Set)+7)
+	for name, value := range c.Set {
+		m[name] = value
+	}
+	registered, err := json.Marshal(&c.Registered)
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(registered, &m); err != nil {
+		return nil, err
+	}
+	return json.Marshal(m)
+}
+
+// Segments returns the base64url encoding of RawHeader, Raw and RawSignature,
+// which is the token as checked, in canonical form. Compressed tokens (with a
+// "zip" header) get their payload segment decompressed, which does not match
+// the signature.
+func (c *Claims) Segments() (header, payload, signature string) {
+	return encoding.EncodeToString(c.RawHeader), encoding.EncodeToString(c.Raw), encoding.EncodeToString(c.RawSignature)
+}
+
+// Numeric returns the claim when present and if the representation is either a
+// JSON number or a JSON string with a number, like "1600000000".
+func (c *Claims) Numeric(name string) (value float64, ok bool) {
+	if value, ok = c.Number(name); ok {
+		return value, true
+	}
+	s, ok := c.Set[name].(string)
+	if !ok {
+		return 0, false
+	}
+	value, err := strconv.ParseFloat(strings.TrimSpace(s), 64)
+	if err != nil || math.IsInf(value, 0) || math.IsNaN(value) {
+		return 0, false
+	}
+	return value, true
+}
+
+// CoerceNumericStrings moves "exp", "nbf" and "iat" claims with a number in a
+// JSON string from Set into Registered. The Check functions only map numbers,
+// as required by RFC 7519.
+func (c *Claims) CoerceNumericStrings() {
+	for _, name := range []string{expires, notBefore, issued} {
+		if _, isString := c.Set[name].(string); !isString {
+			continue
+		}
+		f, ok := c.Numeric(name)
+		if !ok {
+			continue
+		}
+		delete(c.Set, name)
+		switch name {
+		case expires:
+			c.Expires = (*NumericTime)(&f)
+		case notBefore:
+			c.NotBefore = (*NumericTime)(&f)
+		case issued:
+			c.Issued = (*NumericTime)(&f)
+		}
+	}
+}
+
 // NumericTime implements NumericDate: “A JSON numeric value representing
 // the number of seconds from 1970-01-01T00:00:00Z UTC until the specified
 // UTC date/time, ignoring leap seconds.”
@@ -312,3 +463,60 @@ func (n *NumericTime) String() string {
 	}
 	return n.Time().Format(time.RFC3339Nano)
 }
+
+// NewClaimsFromMap returns claims with the entries of m. Names which match any
+// of the Registered fields go in there, conform the Check functions. The rest
+// goes in Set. In addition to the types from encoding/json, the Registered
+// mapping accepts []string for "aud", map[string]map[string]interface{} for
+// "events", and any of time.Time, json.Number, int,
+// int64 and float32 for "exp", "nbf" and "iat". Map m is not modified.
+func NewClaimsFromMap(m map[string]interface{}) *Claims {
+	c := &Claims{Set: make(map[string]interface{}, len(m))}
+	for name, value := range m {
+		c.Set[name] = value
+	}
+
+	if a, ok := c.Set[audience].([]string); ok {
+		array := make([]interface{}, len(a))
+		for i, s := range a {
+			array[i] = s
+		}
+		c.Set[audience] = array
+	}
+	if e, ok := c.Set[events].(map[string]map[string]interface{}); ok {
+		object := make(map[string]interface{}, len(e))
+		for name, payload := range e {
+			object[name] = payload
+		}
+		c.Set[events] = object
+	}
+	for _, name := range []string{expires, notBefore, issued} {
+		var f float64
+		switch v := c.Set[name].(type) {
+		case time.Time:
+			n := NewNumericTime(v)
+			if n == nil {
+				continue
+			}
+			f = float64(*n)
+		case json.Number:
+			var err error
+			f, err = v.Float64()
+			if err != nil {
+				continue
+			}
+		case int:
+			f = float64(v)
+		case int64:
+			f = float64(v)
+		case float32:
+			f = float64(v)
+		default:
+			continue
+		}
+		c.Set[name] = f
+	}
+
+	c.applySet()
+	return c
+}
@@ -5,7 +5,9 @@ import (
 	"crypto/ed25519"
 	"crypto/rsa"
 	"crypto/x509"
+	"encoding/json"
 	"encoding/pem"
+	"reflect"
 	"testing"
 	"time"
 )
@@ -257,6 +259,195 @@ func TestClaimsValid(t *testing.T) {
 	}
 }
 
+func TestClaimsExpiry(t *testing.T) {
+	now := time.Unix(1600000000, 0)
+	c := new(Claims)
+	if _, ok := c.ExpiresIn(now); ok {
+		t.Error("expiry for claims without exp")
+	}
+	if c.NeedsRefresh(now, time.Hour) {
+		t.Error("refresh for claims without exp")
+	}
+	if start, end := c.ValidWindow(); !start.IsZero() || !end.IsZero() {
+		t.Errorf("got window %s–%s for claims without time limits, want zero", start, end)
+	}
+
+	c.NotBefore = NewNumericTime(now)
+	c.Expires = NewNumericTime(now.Add(time.Hour))
+	if d, ok := c.ExpiresIn(now.Add(15 * time.Minute)); !ok || d != 45*time.Minute {
+		t.Errorf("got expiry in %s, want 45m", d)
+	}
+	if d, _ := c.ExpiresIn(now.Add(2 * time.Hour)); d != -time.Hour {
+		t.Errorf("got expiry in %s after exp, want -1h", d)
+	}
+	if start, end := c.ValidWindow(); !start.Equal(now) || !end.Equal(now.Add(time.Hour)) {
+		t.Errorf("got window %s–%s", start, end)
+	}
+	if c.NeedsRefresh(now, 5*time.Minute) {
+		t.Error("refresh for fresh token")
+	}
+	if !c.NeedsRefresh(now.Add(55*time.Minute), 5*time.Minute) {
+		t.Error("no refresh on threshold")
+	}
+}
+
+func TestRoundTimes(t *testing.T) {
+	c := new(Claims)
+	c.Expires = NewNumericTime(time.Unix(1600000000, 600000000))
+	c.Issued = NewNumericTime(time.Unix(1600000000, 260000000))
+	c.RoundTimes(100 * time.Millisecond)
+	if *c.Expires != 1600000000.6 || *c.Issued != 1600000000.3 {
+		t.Errorf("got exp %f and iat %f, want 1600000000.6 and 1600000000.3", *c.Expires, *c.Issued)
+	}
+
+	c.RoundTimes(time.Second)
+	token, err := c.HMACSign(HS256, []byte("guest"))
+	if err != nil {
+		t.Fatal("sign error:", err)
+	}
+	if want := `{"exp":1600000001,"iat":1600000000}`; string(c.Raw) != want {
+		t.Errorf("got payload %s, want %s", c.Raw, want)
+	}
+	if c.NotBefore != nil {
+		t.Error("absent nbf set")
+	}
+
+	c, err = HMACCheck(token, []byte("guest"))
+	if err != nil {
+		t.Fatal("check error:", err)
+	}
+	if *c.Expires != 1600000001 {
+		t.Errorf("got exp %f after check, want 1600000001", *c.Expires)
+	}
+
+	// fractions as received
+	c.Expires = NewNumericTime(time.Unix(1600000000, 125000000))
+	token, err = c.HMACSign(HS256, []byte("guest"))
+	if err != nil {
+		t.Fatal("sign error:", err)
+	}
+	c, err = HMACCheck(token, []byte("guest"))
+	if err != nil {
+		t.Fatal("check error:", err)
+	}
+	if *c.Expires != 1600000000.125 {
+		t.Errorf("got exp %f after check, want 1600000000.125", *c.Expires)
+	}
+}
+
+func TestDecodeSet(t *testing.T) {
+	type profile struct {
+		Subject string   `json:"sub"`
+		Name    string   `json:"name"`
+		Roles   []string `json:"roles"`
+		Level   int      `json:"level,string"`
+	}
+	want := profile{"u1", "Alice", []string{"admin"}, 7}
+
+	c := &Claims{Set: map[string]interface{}{
+		"name":  "Alice",
+		"roles": []interface{}{"admin"},
+		"level": "7",
+	}}
+	c.Subject = "u1"
+	var got profile
+	if err := c.DecodeSet(&got); err != nil {
+		t.Fatal("decode error:", err)
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %+v, want %+v", got, want)
+	}
+
+	token, err := c.HMACSign(HS256, []byte("guest"))
+	if err != nil {
+		t.Fatal("sign error:", err)
+	}
+	c, err = HMACCheck(token, []byte("guest"))
+	if err != nil {
+		t.Fatal("check error:", err)
+	}
+	got = profile{}
+	if err := c.DecodeSet(&got); err != nil {
+		t.Fatal("decode error:", err)
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %+v after check, want %+v", got, want)
+	}
+
+	var wrong struct {
+		Name int `json:"name"`
+	}
+	if err := c.DecodeSet(&wrong); err == nil {
+		t.Error("no error for type mismatch")
+	}
+}
+
+func TestNewClaimsFromMap(t *testing.T) {
+	m := map[string]interface{}{
+		"iss":  "a",
+		"aud":  []string{"b", "c"},
+		"exp":  int64(1600000000),
+		"nbf":  json.Number("1599999999.5"),
+		"iat":  time.Unix(1599999999, 0),
+		"jti":  42,
+		"name": "Alice",
+	}
+	c := NewClaimsFromMap(m)
+
+	want := Registered{
+		Issuer:    "a",
+		Audiences: []string{"b", "c"},
+		Expires:   NewNumericTime(time.Unix(1600000000, 0)),
+		NotBefore: NewNumericTime(time.Unix(1599999999, 500000000)),
+		Issued:    NewNumericTime(time.Unix(1599999999, 0)),
+	}
+	if !reflect.DeepEqual(c.Registered, want) {
+		t.Errorf("got registered %+v, want %+v", c.Registered, want)
+	}
+	wantSet := map[string]interface{}{"jti": 42, "name": "Alice"}
+	if !reflect.DeepEqual(c.Set, wantSet) {
+		t.Errorf("got set %#v, want %#v", c.Set, wantSet)
+	}
+	if len(m) != 7 {
+		t.Error("map argument modified")
+	}
+}
+
+func TestOpenIDClaims(t *testing.T) {
+	logout := map[string]map[string]interface{}{
+		"http://schemas.openid.net/event/backchannel-logout": {},
+	}
+	for _, set := range []map[string]interface{}{nil, {"x": true}} {
+		c := &Claims{Set: set}
+		c.SessionID = "08a5019c"
+		c.Nonce = "n-0S6_WzA2Mj"
+		c.Events = logout
+		token, err := c.HMACSign(HS256, []byte("guest"))
+		if err != nil {
+			t.Fatal("sign error:", err)
+		}
+		got, err := HMACCheck(token, []byte("guest"))
+		if err != nil {
+			t.Fatal("check error:", err)
+		}
+		if !reflect.DeepEqual(got.Registered, c.Registered) {
+			t.Errorf("got registered %+v, want %+v", got.Registered, c.Registered)
+		}
+		if s, ok := got.String("sid"); !ok || s != c.SessionID {
+			t.Errorf("got sid %q, want %q", s, c.SessionID)
+		}
+		// compatibility copies
+		if got.Set["sid"] != c.SessionID || got.Set["nonce"] != c.Nonce || got.Set["events"] == nil {
+			t.Errorf("got set %#v, want sid, nonce and events mirrored", got.Set)
+		}
+	}
+
+	c := NewClaimsFromMap(map[string]interface{}{"events": map[string]interface{}{"e": 1}})
+	if c.Events != nil || c.Set["events"] == nil {
+		t.Error("malformed events claim mapped to Registered")
+	}
+}
+
 func TestClaimsNull(t *testing.T) {
 	const name = "x"
 	c := Claims{Set: map[string]interface{}{name: nil}}
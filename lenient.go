@@ -0,0 +1,29 @@
+package jwt
+
+import "bytes"
+
+// Lenient returns the token in compact serialization, cleared from the forms
+// which some clients send. Any whitespace, including line breaks, is removed.
+// A Bearer scheme prefix is removed, case-insensitive. Base64 padding is removed.
+// The Check functions remain strict by default. Apply Lenient before the check
+// to accept such tokens.
+func Lenient(token []byte) []byte {
+	token = bytes.TrimSpace(token)
+	const scheme = "bearer"
+	if len(token) > len(scheme) && bytes.EqualFold(token[:len(scheme)], []byte(scheme)) {
+		switch token[len(scheme)] {
+		case ' ', '\t':
+			token = token[len(scheme):]
+		}
+	}
+
+	buf := make([]byte, 0, len(token))
+	for _, b := range token {
+		switch b {
+		case ' ', '\t', '\n', '\r', '\v', '\f', '=':
+			continue
+		}
+		buf = append(buf, b)
+	}
+	return buf
+}
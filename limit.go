@@ -0,0 +1,94 @@
+package jwt
+
+import (
+	"sync"
+	"time"
+)
+
+// Limiter throttles requests per key. Implementations may use shared storage,
+// like Redis, to limit across instances.
+type Limiter interface {
+	// Allow registers a request for key. Rejections include the amount
+	// of time to wait before a retry may pass.
+	Allow(key string) (ok bool, retryAfter time.Duration)
+}
+
+// LimitSweep is the amount of time between two scans for idle buckets.
+const limitSweep = time.Minute
+
+// TokenBucket is a Limiter in memory. Each key has a bucket of Burst tokens,
+// which refill at a rate of one per Interval.
+//
+// Multiple goroutines may invoke methods on a TokenBucket simultaneously.
+type TokenBucket struct {
+	// Interval is the refill period for one token. Zero (or less) means
+	// a fixed quota of Burst per key, without refill. Such buckets remain
+	// in memory indefinitely, and rejections have a zero retryAfter.
+	Interval time.Duration
+
+	// Burst is the bucket size. Values below one default to one.
+	Burst int
+
+	// Now is the time source. Nil defaults to time.Now.
+	Now func() time.Time
+
+	mutex   sync.Mutex
+	buckets map[string]*bucket
+	swept   time.Time
+}
+
+type bucket struct {
+	tokens float64
+	last   time.Time
+}
+
+// Allow implements the Limiter interface.
+func (l *TokenBucket) Allow(key string) (ok bool, retryAfter time.Duration) {
+	now := time.Now()
+	if l.Now != nil {
+		now = l.Now()
+	}
+	burst := float64(l.Burst)
+	if burst < 1 {
+		burst = 1
+	}
+	full := time.Duration(burst * float64(l.Interval))
+
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	if l.buckets == nil {
+		l.buckets = make(map[string]*bucket)
+		l.swept = now
+	}
+	// full buckets are equivalent to absence
+	if l.Interval > 0 && now.Sub(l.swept) >= limitSweep {
+		for k, b := range l.buckets {
+			if now.Sub(b.last) >= full {
+				delete(l.buckets, k)
+			}
+		}
+		l.swept = now
+	}
+
+	b, found := l.buckets[key]
+	if !found {
+		b = &bucket{tokens: burst, last: now}
+		l.buckets[key] = b
+	} else if l.Interval > 0 {
+		b.tokens += float64(now.Sub(b.last)) / float64(l.Interval)
+		if b.tokens > burst {
+			b.tokens = burst
+		}
+		b.last = now
+	}
+
+	if b.tokens < 1 {
+		if l.Interval <= 0 {
+			return false, 0 // no refill
+		}
+		return false, time.Duration((1 - b.tokens) * float64(l.Interval))
+	}
+	b.tokens--
+	return true, 0
+}
@@ -0,0 +1,88 @@
+//go:build !jwt_hmac_only
+// +build !jwt_hmac_only
+
+package jwt
+
+import (
+	"crypto/ed25519"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestTokenBucket(t *testing.T) {
+	now := time.Unix(1600000000, 0)
+	l := TokenBucket{Interval: time.Second, Burst: 2, Now: func() time.Time { return now }}
+
+	for i := 0; i < 2; i++ {
+		if ok, _ := l.Allow("a"); !ok {
+			t.Fatalf("request %d within burst rejected", i+1)
+		}
+	}
+	if ok, retryAfter := l.Allow("a"); ok || retryAfter != time.Second {
+		t.Errorf("got pass %t with retry after %s beyond burst, want false and 1s", ok, retryAfter)
+	}
+	if ok, _ := l.Allow("b"); !ok {
+		t.Error("other key rejected")
+	}
+
+	now = now.Add(500 * time.Millisecond)
+	if ok, retryAfter := l.Allow("a"); ok || retryAfter != 500*time.Millisecond {
+		t.Errorf("got pass %t with retry after %s on half refill, want false and 500ms", ok, retryAfter)
+	}
+	now = now.Add(500 * time.Millisecond)
+	if ok, _ := l.Allow("a"); !ok {
+		t.Error("rejected after refill")
+	}
+
+	now = now.Add(time.Hour)
+	l.Allow("c")
+	if len(l.buckets) != 1 {
+		t.Errorf("got %d buckets after sweep, want 1", len(l.buckets))
+	}
+}
+
+func TestTokenBucketQuota(t *testing.T) {
+	now := time.Unix(1600000000, 0)
+	l := TokenBucket{Burst: 2, Now: func() time.Time { return now }}
+
+	for i := 0; i < 2; i++ {
+		if ok, _ := l.Allow("a"); !ok {
+			t.Fatalf("request %d within quota rejected", i+1)
+		}
+	}
+	for i := 0; i < 3; i++ {
+		now = now.Add(time.Hour)
+		l.Allow("b") // sweep attempt
+		if ok, retryAfter := l.Allow("a"); ok || retryAfter != 0 {
+			t.Errorf("got pass %t with retry after %s beyond quota, want false and 0", ok, retryAfter)
+		}
+	}
+}
+
+func TestHandlerLimiter(t *testing.T) {
+	handler := Handler{
+		Target:     http.HandlerFunc(func(http.ResponseWriter, *http.Request) {}),
+		Keys:       &KeyRegister{EdDSAs: []ed25519.PublicKey{testKeyEd25519Public}},
+		Limiter:    &TokenBucket{Interval: time.Minute, Burst: 1},
+		LimitClaim: "client_id",
+	}
+	c := &Claims{Set: map[string]interface{}{"client_id": "c1"}}
+	for _, want := range []int{http.StatusOK, http.StatusTooManyRequests} {
+		req := httptest.NewRequest("GET", "/", nil)
+		if err := c.EdDSASignHeader(req, testKeyEd25519Private); err != nil {
+			t.Fatal("sign error:", err)
+		}
+		resp := httptest.NewRecorder()
+		handler.ServeHTTP(resp, req)
+		if resp.Code != want {
+			t.Errorf("got HTTP %d, want %d", resp.Code, want)
+		}
+		if want == http.StatusTooManyRequests {
+			if got := resp.Header().Get("Retry-After"); got != "60" {
+				t.Errorf("got Retry-After %q, want 60", got)
+			}
+		}
+	}
+}
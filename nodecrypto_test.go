@@ -0,0 +1,229 @@
+//go:build !jwt_hmac_only && !jwt_no_rsa && !jwt_no_ecdsa
+// +build !jwt_hmac_only,!jwt_no_rsa,!jwt_no_ecdsa
+
+package jwt
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os/exec"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+)
+
+// NodeCryptoCase is a token from the Node.js counterpart.
+type nodeCryptoCase struct {
+	Name   string                 `json:"name"`
+	Alg    string                 `json:"alg"`
+	JWK    json.RawMessage        `json:"jwk"`
+	Token  string                 `json:"token"`
+	Claims map[string]interface{} `json:"claims"`
+	Reject string                 `json:"reject"` // deviation, if any
+}
+
+// TestNodeCryptoFixtures verifies the tokens recorded with the Node.js crypto
+// counterpart in testdata/nodecrypto. See nodecrypto.js there for the format.
+// Other JOSE libraries, like node-jose, PyJWT and jwx, are not covered.
+func TestNodeCryptoFixtures(t *testing.T) {
+	files, err := filepath.Glob(filepath.Join("testdata", "nodecrypto", "*.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(files) == 0 {
+		t.Fatal("no Node.js crypto fixtures")
+	}
+
+	for _, file := range files {
+		data, err := ioutil.ReadFile(file)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var fixtures struct {
+			Generator string           `json:"generator"`
+			Cases     []nodeCryptoCase `json:"cases"`
+		}
+		if err := json.Unmarshal(data, &fixtures); err != nil {
+			t.Fatalf("%s: %s", file, err)
+		}
+
+		for _, c := range fixtures.Cases {
+			keys := new(KeyRegister)
+			if _, err := keys.LoadJWK(c.JWK); err != nil {
+				t.Errorf("%s %s: JWK error: %s", fixtures.Generator, c.Name, err)
+				continue
+			}
+			claims, err := keys.Check([]byte(c.Token))
+			if c.Reject != "" {
+				if err == nil {
+					t.Errorf("%s %s: accepted %s", fixtures.Generator, c.Name, c.Reject)
+				}
+				continue
+			}
+			if err != nil {
+				t.Errorf("%s %s: check error: %s", fixtures.Generator, c.Name, err)
+				continue
+			}
+			if err := nodeCryptoClaims(claims, c.Claims); err != nil {
+				t.Errorf("%s %s: %s", fixtures.Generator, c.Name, err)
+			}
+		}
+	}
+}
+
+// NodeCryptoClaims returns a deviation of c from the claims as JSON decoded.
+func nodeCryptoClaims(c *Claims, want map[string]interface{}) error {
+	var got map[string]interface{}
+	if err := json.Unmarshal(c.Raw, &got); err != nil {
+		return fmt.Errorf("payload unreadable: %w", err)
+	}
+	if !reflect.DeepEqual(got, want) {
+		return fmt.Errorf("got payload %s, want %v", c.Raw, want)
+	}
+
+	// typing of Registered
+	for name, value := range want {
+		var ok bool
+		switch name {
+		case issuer:
+			ok = value == c.Issuer
+		case subject:
+			ok = value == c.Subject
+		case audience:
+			switch value := value.(type) {
+			case string:
+				ok = len(c.Audiences) == 1 && c.Audiences[0] == value
+			case []interface{}:
+				ok = len(c.Audiences) == len(value)
+				for i := 0; ok && i < len(value); i++ {
+					ok = value[i] == c.Audiences[i]
+				}
+			}
+		case expires:
+			ok = c.Expires != nil && value == float64(*c.Expires)
+		case notBefore:
+			ok = c.NotBefore != nil && value == float64(*c.NotBefore)
+		case issued:
+			ok = c.Issued != nil && value == float64(*c.Issued)
+		default:
+			ok = reflect.DeepEqual(value, c.Set[name])
+		}
+		if !ok {
+			return fmt.Errorf("claim %q not mapped as %#v", name, value)
+		}
+	}
+	return nil
+}
+
+// TestNodeCryptoVerify verifies tokens from this package with the Node.js
+// crypto counterpart in testdata/nodecrypto.
+func TestNodeCryptoVerify(t *testing.T) {
+	if testing.Short() {
+		t.Skip("external process")
+	}
+	node, err := exec.LookPath("node")
+	if err != nil {
+		t.Skip("Node.js not installed")
+	}
+
+	var claims Claims
+	claims.Issuer = "go"
+	claims.Subject = "interop"
+	claims.Audiences = []string{"node", "others"}
+	claims.Expires = NewNumericTime(time.Unix(2000000000, 0))
+	claims.Issued = NewNumericTime(time.Unix(1600000000, 5e8))
+	claims.Set = map[string]interface{}{
+		"html":  "<a href='?x&y'>",
+		"text":  "π ☃  ",
+		"ratio": 1.5,
+		"list":  []interface{}{1.0, "two", nil, true},
+	}
+
+	secret := []byte("conformance secret of 32 bytes!!")
+	signers := []struct {
+		alg string
+		key interface{} // private
+	}{
+		{HS256, secret}, {HS384, secret}, {HS512, secret},
+		{RS256, testKeyRSA2048}, {RS384, testKeyRSA2048}, {RS512, testKeyRSA2048},
+		{PS256, testKeyRSA2048}, {PS384, testKeyRSA2048}, {PS512, testKeyRSA2048},
+		{ES256, testKeyEC256}, {ES384, testKeyEC384}, {ES512, testKeyEC521},
+		{EdDSA, testKeyEd25519Private},
+	}
+	cases := make([]map[string]interface{}, len(signers))
+	for i, s := range signers {
+		var token []byte
+		var jwk json.RawMessage
+		switch key := s.key.(type) {
+		case []byte:
+			token, err = claims.HMACSign(s.alg, key)
+			jwk = json.RawMessage(`{"kty":"oct","k":"` + encoding.EncodeToString(key) + `"}`)
+		case *rsa.PrivateKey:
+			token, err = claims.RSASign(s.alg, key)
+			jwk, err = nodeCryptoJWK(&key.PublicKey, err)
+		case *ecdsa.PrivateKey:
+			token, err = claims.ECDSASign(s.alg, key)
+			jwk, err = nodeCryptoJWK(&key.PublicKey, err)
+		case ed25519.PrivateKey:
+			token, err = claims.EdDSASign(key)
+			jwk, err = nodeCryptoJWK(key.Public(), err)
+		}
+		if err != nil {
+			t.Fatalf("%s: %s", s.alg, err)
+		}
+		cases[i] = map[string]interface{}{
+			"name":  s.alg,
+			"alg":   s.alg,
+			"jwk":   jwk,
+			"token": string(token),
+		}
+	}
+	input, err := json.Marshal(cases)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cmd := exec.Command(node, filepath.Join("testdata", "nodecrypto", "nodecrypto.js"), "verify")
+	cmd.Stdin = bytes.NewReader(input)
+	output, err := cmd.Output()
+	if err != nil {
+		t.Fatal("Node.js verify:", err)
+	}
+	var results []struct {
+		Name   string                 `json:"name"`
+		OK     bool                   `json:"ok"`
+		Error  string                 `json:"error"`
+		Claims map[string]interface{} `json:"claims"`
+	}
+	if err := json.Unmarshal(output, &results); err != nil {
+		t.Fatalf("Node.js output %q: %s", output, err)
+	}
+	if len(results) != len(cases) {
+		t.Fatalf("got %d results from Node.js, want %d", len(results), len(cases))
+	}
+	for _, r := range results {
+		if !r.OK {
+			t.Errorf("%s: Node.js rejected token: %s", r.Name, r.Error)
+			continue
+		}
+		if err := nodeCryptoClaims(&claims, r.Claims); err != nil {
+			t.Errorf("%s: Node.js %s", r.Name, err)
+		}
+	}
+}
+
+// NodeCryptoJWK returns the JWK of a public key, or the first error.
+func nodeCryptoJWK(key crypto.PublicKey, err error) (json.RawMessage, error) {
+	if err != nil {
+		return nil, err
+	}
+	members, err := thumbprintMembers(key)
+	return json.RawMessage(members), err
+}
@@ -0,0 +1,337 @@
+package jwt
+
+import (
+	"bytes"
+	"crypto"
+	_ "crypto/sha1" // link into binary
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"errors"
+	"fmt"
+	"math/big"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// OCSPLimit is the maximum number of bytes read from an OCSP response.
+const ocspLimit = 1 << 20
+
+// OCSP is a CertVerifier Revocation implementation with the “Online Certificate
+// Status Protocol (OCSP)” of RFC 6960. The responders come from the authority
+// information access of each certificate. Responses are cached until their
+// next update, yet no longer than MaxRefresh. Their signature is verified once,
+// on arrival. Fetch failures are cached for one minute, such that an outage
+// doesn't cause a request per verification.
+//
+// Stapled responses, e.g., from a TLS handshake, go in with Staple, which saves
+// the request to the responder.
+//
+// Multiple goroutines may invoke methods on an OCSP simultaneously.
+type OCSP struct {
+	// Client is used for the HTTP requests. Nil defaults to
+	// http.DefaultClient.
+	Client *http.Client
+
+	// MaxRefresh is the maximum amount of time a response is cached.
+	// Zero defaults to DefaultMaxRefresh.
+	MaxRefresh time.Duration
+
+	// Timeout limits the duration of each request. Zero defaults to
+	// DefaultRevocationTimeout.
+	Timeout time.Duration
+
+	cache revocationCache // *ocspStatus by issuer and serial number
+}
+
+// OCSPStatus is the outcome of a response, with nil for good.
+type ocspStatus struct{ err error }
+
+// Check returns ErrRevoked when the status of cert is revoked, conform the
+// first responder available. Certificates without an HTTP responder cause an
+// error, as their status can not be determined.
+func (o *OCSP) Check(cert, issuer *x509.Certificate) error {
+	v, err := o.cache.get(ocspKey(cert, issuer), func(now time.Time) (interface{}, time.Time, error) {
+		return o.fetch(cert, issuer, now)
+	})
+	if err != nil {
+		return err
+	}
+	return v.(*ocspStatus).err
+}
+
+// Staple installs a response for cert, as received from elsewhere. The return
+// is the status, like Check, or an error for unusable responses.
+func (o *OCSP) Staple(response []byte, cert, issuer *x509.Certificate) error {
+	status, expires, err := o.parse(response, cert, issuer, time.Now())
+	if err != nil {
+		return err
+	}
+	o.cache.put(ocspKey(cert, issuer), status, expires)
+	return status.err
+}
+
+func ocspKey(cert, issuer *x509.Certificate) string {
+	return string(issuer.Raw) + "\x00" + string(cert.SerialNumber.Bytes())
+}
+
+// Fetch requests the status from the first responder available.
+func (o *OCSP) fetch(cert, issuer *x509.Certificate, now time.Time) (*ocspStatus, time.Time, error) {
+	req, err := newOCSPRequest(cert, issuer)
+	if err != nil {
+		return nil, time.Time{}, err
+	}
+
+	var lastErr error = errRevocationUnknown
+	for _, url := range cert.OCSPServer {
+		if !strings.HasPrefix(url, "http://") && !strings.HasPrefix(url, "https://") {
+			continue
+		}
+		body, err := fetchRevocation(o.Client, o.Timeout, http.MethodPost, url, "application/ocsp-request", req, ocspLimit)
+		if err != nil {
+			lastErr = fmt.Errorf("jwt: OCSP %q unavailable: %w", url, err)
+			continue
+		}
+		status, expires, err := o.parse(body, cert, issuer, now)
+		if err != nil {
+			lastErr = fmt.Errorf("jwt: OCSP %q unusable: %w", url, err)
+			continue
+		}
+		return status, expires, nil
+	}
+	return nil, time.Time{}, lastErr
+}
+
+// Parse verifies a response, and it returns the status of cert with its expiry.
+func (o *OCSP) parse(der []byte, cert, issuer *x509.Certificate, now time.Time) (*ocspStatus, time.Time, error) {
+	single, err := parseOCSPResponse(der, cert, issuer, now)
+	if err != nil {
+		return nil, time.Time{}, err
+	}
+	if now.Before(single.ThisUpdate.Add(-ocspSkew)) {
+		return nil, time.Time{}, errors.New("jwt: OCSP response from the future")
+	}
+	if !single.NextUpdate.IsZero() && !now.Before(single.NextUpdate) {
+		return nil, time.Time{}, errors.New("jwt: OCSP response expired")
+	}
+
+	maxRefresh := o.MaxRefresh
+	if maxRefresh <= 0 {
+		maxRefresh = DefaultMaxRefresh
+	}
+	expires := now.Add(maxRefresh)
+	if !single.NextUpdate.IsZero() && single.NextUpdate.Before(expires) {
+		expires = single.NextUpdate
+	}
+
+	status := new(ocspStatus)
+	if single.Unknown {
+		status.err = fmt.Errorf("%w: OCSP responder doesn't know the certificate", errRevocationUnknown)
+	} else if !single.Good {
+		status.err = ErrRevoked
+	}
+	return status, expires, nil
+}
+
+// OCSPSkew is the clock tolerance for the time of production.
+const ocspSkew = 5 * time.Minute
+
+// The ASN.1 structures are described in RFC 6960, subsection 4.1.1 and 4.2.1.
+type (
+	ocspRequest struct {
+		TBSRequest ocspTBSRequest
+	}
+	ocspTBSRequest struct {
+		RequestList []ocspSingleRequest
+	}
+	ocspSingleRequest struct {
+		Cert ocspCertID
+	}
+	ocspCertID struct {
+		HashAlgorithm  pkix.AlgorithmIdentifier
+		IssuerNameHash []byte
+		IssuerKeyHash  []byte
+		SerialNumber   *big.Int
+	}
+
+	ocspResponse struct {
+		Status        asn1.Enumerated
+		ResponseBytes ocspResponseBytes `asn1:"explicit,tag:0,optional"`
+	}
+	ocspResponseBytes struct {
+		ResponseType asn1.ObjectIdentifier
+		Response     []byte
+	}
+	ocspBasicResponse struct {
+		TBSResponseData    ocspResponseData
+		SignatureAlgorithm pkix.AlgorithmIdentifier
+		Signature          asn1.BitString
+		Certificates       []asn1.RawValue `asn1:"explicit,tag:0,optional"`
+	}
+	ocspResponseData struct {
+		Raw                asn1.RawContent
+		Version            int `asn1:"optional,default:0,explicit,tag:0"`
+		RawResponderID     asn1.RawValue
+		ProducedAt         time.Time `asn1:"generalized"`
+		Responses          []ocspSingleResponse
+		ResponseExtensions []pkix.Extension `asn1:"explicit,tag:1,optional"`
+	}
+	ocspSingleResponse struct {
+		CertID           ocspCertID
+		Good             asn1.Flag        `asn1:"tag:0,optional"`
+		Revoked          ocspRevokedInfo  `asn1:"tag:1,optional"`
+		Unknown          asn1.Flag        `asn1:"tag:2,optional"`
+		ThisUpdate       time.Time        `asn1:"generalized"`
+		NextUpdate       time.Time        `asn1:"generalized,explicit,tag:0,optional"`
+		SingleExtensions []pkix.Extension `asn1:"explicit,tag:1,optional"`
+	}
+	ocspRevokedInfo struct {
+		RevocationTime time.Time       `asn1:"generalized"`
+		Reason         asn1.Enumerated `asn1:"explicit,tag:0,optional"`
+	}
+)
+
+var (
+	oidOCSPBasic = asn1.ObjectIdentifier{1, 3, 6, 1, 5, 5, 7, 48, 1, 1}
+	oidSHA1      = asn1.ObjectIdentifier{1, 3, 14, 3, 2, 26}
+	oidSHA256    = asn1.ObjectIdentifier{2, 16, 840, 1, 101, 3, 4, 2, 1}
+)
+
+// OCSPSignatureAlgs maps the object identifiers for response signatures.
+var ocspSignatureAlgs = []struct {
+	oid asn1.ObjectIdentifier
+	alg x509.SignatureAlgorithm
+}{
+	{asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 1, 11}, x509.SHA256WithRSA},
+	{asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 1, 12}, x509.SHA384WithRSA},
+	{asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 1, 13}, x509.SHA512WithRSA},
+	{asn1.ObjectIdentifier{1, 2, 840, 10045, 4, 3, 2}, x509.ECDSAWithSHA256},
+	{asn1.ObjectIdentifier{1, 2, 840, 10045, 4, 3, 3}, x509.ECDSAWithSHA384},
+	{asn1.ObjectIdentifier{1, 2, 840, 10045, 4, 3, 4}, x509.ECDSAWithSHA512},
+	{asn1.ObjectIdentifier{1, 3, 101, 112}, x509.PureEd25519},
+}
+
+// NewOCSPRequest returns the DER encoding of a request for cert.
+func newOCSPRequest(cert, issuer *x509.Certificate) ([]byte, error) {
+	nameHash, keyHash, err := ocspIssuerHashes(crypto.SHA1, issuer)
+	if err != nil {
+		return nil, err
+	}
+	return asn1.Marshal(ocspRequest{ocspTBSRequest{[]ocspSingleRequest{{ocspCertID{
+		HashAlgorithm:  pkix.AlgorithmIdentifier{Algorithm: oidSHA1, Parameters: asn1.NullRawValue},
+		IssuerNameHash: nameHash,
+		IssuerKeyHash:  keyHash,
+		SerialNumber:   cert.SerialNumber,
+	}}}}})
+}
+
+// OCSPIssuerHashes returns the hash of the subject and the public key of the
+// issuer, which identify it in requests and responses.
+func ocspIssuerHashes(hash crypto.Hash, issuer *x509.Certificate) (nameHash, keyHash []byte, err error) {
+	var spki struct {
+		Algorithm pkix.AlgorithmIdentifier
+		PublicKey asn1.BitString
+	}
+	if _, err := asn1.Unmarshal(issuer.RawSubjectPublicKeyInfo, &spki); err != nil {
+		return nil, nil, fmt.Errorf("jwt: OCSP issuer key unusable: %w", err)
+	}
+	h := hash.New()
+	h.Write(issuer.RawSubject)
+	nameHash = h.Sum(nil)
+	h.Reset()
+	h.Write(spki.PublicKey.RightAlign())
+	return nameHash, h.Sum(nil), nil
+}
+
+// ParseOCSPResponse returns the status of cert, if, and only if, the response
+// was signed by issuer, or by a responder which issuer delegated to.
+func parseOCSPResponse(der []byte, cert, issuer *x509.Certificate, now time.Time) (*ocspSingleResponse, error) {
+	var resp ocspResponse
+	if rest, err := asn1.Unmarshal(der, &resp); err != nil {
+		return nil, err
+	} else if len(rest) != 0 {
+		return nil, errors.New("jwt: trailing data after OCSP response")
+	}
+	if resp.Status != 0 {
+		return nil, fmt.Errorf("jwt: OCSP response status %d", resp.Status)
+	}
+	if !resp.ResponseBytes.ResponseType.Equal(oidOCSPBasic) {
+		return nil, fmt.Errorf("jwt: OCSP response type %s not supported", resp.ResponseBytes.ResponseType)
+	}
+	var basic ocspBasicResponse
+	if rest, err := asn1.Unmarshal(resp.ResponseBytes.Response, &basic); err != nil {
+		return nil, err
+	} else if len(rest) != 0 {
+		return nil, errors.New("jwt: trailing data after OCSP basic response")
+	}
+
+	if err := verifyOCSPSignature(&basic, issuer, now); err != nil {
+		return nil, err
+	}
+
+	for i := range basic.TBSResponseData.Responses {
+		single := &basic.TBSResponseData.Responses[i]
+		var hash crypto.Hash
+		switch alg := single.CertID.HashAlgorithm.Algorithm; {
+		case alg.Equal(oidSHA1):
+			hash = crypto.SHA1
+		case alg.Equal(oidSHA256):
+			hash = crypto.SHA256
+		default:
+			continue
+		}
+		nameHash, keyHash, err := ocspIssuerHashes(hash, issuer)
+		if err != nil {
+			return nil, err
+		}
+		if single.CertID.SerialNumber.Cmp(cert.SerialNumber) == 0 &&
+			bytes.Equal(single.CertID.IssuerNameHash, nameHash) &&
+			bytes.Equal(single.CertID.IssuerKeyHash, keyHash) {
+			return single, nil
+		}
+	}
+	return nil, errors.New("jwt: OCSP response does not cover the certificate")
+}
+
+// VerifyOCSPSignature checks the response signature from either issuer, or
+// from a responder certificate which issuer signed for the purpose, as
+// described in RFC 6960, subsection 4.2.2.2.
+func verifyOCSPSignature(basic *ocspBasicResponse, issuer *x509.Certificate, now time.Time) error {
+	var alg x509.SignatureAlgorithm
+	for _, a := range ocspSignatureAlgs {
+		if a.oid.Equal(basic.SignatureAlgorithm.Algorithm) {
+			alg = a.alg
+			break
+		}
+	}
+	if alg == x509.UnknownSignatureAlgorithm {
+		return fmt.Errorf("jwt: OCSP signature algorithm %s not supported", basic.SignatureAlgorithm.Algorithm)
+	}
+	signed, sig := basic.TBSResponseData.Raw, basic.Signature.RightAlign()
+
+	err := issuer.CheckSignature(alg, signed, sig)
+	if err == nil {
+		return nil
+	}
+	for _, raw := range basic.Certificates {
+		responder, parseErr := x509.ParseCertificate(raw.FullBytes)
+		if parseErr != nil {
+			continue
+		}
+		if responder.CheckSignatureFrom(issuer) != nil ||
+			now.Before(responder.NotBefore) || now.After(responder.NotAfter) {
+			continue
+		}
+		delegated := false
+		for _, usage := range responder.ExtKeyUsage {
+			if usage == x509.ExtKeyUsageOCSPSigning {
+				delegated = true
+			}
+		}
+		if delegated && responder.CheckSignature(alg, signed, sig) == nil {
+			return nil
+		}
+	}
+	return fmt.Errorf("jwt: OCSP signature rejected: %w", err)
+}
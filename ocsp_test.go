@@ -0,0 +1,195 @@
+//go:build !jwt_hmac_only && !jwt_no_ecdsa
+// +build !jwt_hmac_only,!jwt_no_ecdsa
+
+package jwt
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"errors"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// TestOCSPResponse returns a response for leaf, signed with key. Any responder
+// certificate is included.
+func testOCSPResponse(t *testing.T, ca, leaf *x509.Certificate, revoked bool, key *ecdsa.PrivateKey, responder *x509.Certificate) []byte {
+	t.Helper()
+	nameHash, keyHash, err := ocspIssuerHashes(crypto.SHA1, ca)
+	if err != nil {
+		t.Fatal(err)
+	}
+	single := ocspSingleResponse{
+		CertID: ocspCertID{
+			HashAlgorithm:  pkix.AlgorithmIdentifier{Algorithm: oidSHA1, Parameters: asn1.NullRawValue},
+			IssuerNameHash: nameHash,
+			IssuerKeyHash:  keyHash,
+			SerialNumber:   leaf.SerialNumber,
+		},
+		ThisUpdate: time.Now().Add(-time.Minute).UTC(),
+		NextUpdate: time.Now().Add(time.Hour).UTC(),
+	}
+	if revoked {
+		single.Revoked.RevocationTime = time.Now().UTC()
+	} else {
+		single.Good = true
+	}
+
+	responderID, err := asn1.Marshal(keyHash)
+	if err != nil {
+		t.Fatal(err)
+	}
+	data := ocspResponseData{
+		RawResponderID: asn1.RawValue{Class: asn1.ClassContextSpecific, Tag: 2, IsCompound: true, Bytes: responderID},
+		ProducedAt:     time.Now().UTC(),
+		Responses:      []ocspSingleResponse{single},
+	}
+	data.Raw, err = asn1.Marshal(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	digest := sha256.Sum256(data.Raw)
+	sig, err := key.Sign(rand.Reader, digest[:], crypto.SHA256)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	basic := ocspBasicResponse{
+		TBSResponseData:    data,
+		SignatureAlgorithm: pkix.AlgorithmIdentifier{Algorithm: asn1.ObjectIdentifier{1, 2, 840, 10045, 4, 3, 2}},
+		Signature:          asn1.BitString{Bytes: sig, BitLength: 8 * len(sig)},
+	}
+	if responder != nil {
+		basic.Certificates = []asn1.RawValue{{FullBytes: responder.Raw}}
+	}
+	basicDER, err := asn1.Marshal(basic)
+	if err != nil {
+		t.Fatal(err)
+	}
+	der, err := asn1.Marshal(ocspResponse{ResponseBytes: ocspResponseBytes{oidOCSPBasic, basicDER}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return der
+}
+
+func TestOCSP(t *testing.T) {
+	var mutex sync.Mutex
+	var response []byte
+	var requests int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&requests, 1)
+		if r.Method != http.MethodPost || r.Header.Get("Content-Type") != "application/ocsp-request" {
+			t.Errorf("got %s request with content type %q", r.Method, r.Header.Get("Content-Type"))
+		}
+		mutex.Lock()
+		defer mutex.Unlock()
+		if response == nil {
+			http.NotFound(w, r)
+			return
+		}
+		w.Write(response)
+	}))
+	defer srv.Close()
+	setResponse := func(der []byte) {
+		mutex.Lock()
+		defer mutex.Unlock()
+		response = der
+	}
+
+	ca, leaf := testCertChain(t, srv.URL)
+	roots := x509.NewCertPool()
+	roots.AddCert(ca)
+	token, err := new(Claims).EdDSASign(testKeyEd25519Private, x5cHeader(leaf))
+	if err != nil {
+		t.Fatal("sign error:", err)
+	}
+
+	v := CertVerifier{
+		Options:    x509.VerifyOptions{Roots: roots},
+		Revocation: new(OCSP).Check,
+	}
+	if _, err := v.Check(token); !errors.Is(err, errRevocationUnknown) {
+		t.Errorf("got error %v for responder unavailable, want %v", err, errRevocationUnknown)
+	}
+
+	setResponse(testOCSPResponse(t, ca, leaf, false, testKeyEC256, nil))
+	o := new(OCSP)
+	v.Revocation = o.Check
+	if _, err := v.Check(token); err != nil {
+		t.Error("check error with good status:", err)
+	}
+	atomic.StoreInt32(&requests, 0)
+	if _, err := v.Check(token); err != nil {
+		t.Error("check error with cached status:", err)
+	}
+	if n := atomic.LoadInt32(&requests); n != 0 {
+		t.Errorf("got %d requests with cached status, want 0", n)
+	}
+
+	setResponse(testOCSPResponse(t, ca, leaf, true, testKeyEC256, nil))
+	v.Revocation = new(OCSP).Check
+	if _, err := v.Check(token); err != ErrRevoked {
+		t.Errorf("got error %v, want %v", err, ErrRevoked)
+	}
+
+	// signed by a key other than the issuer's
+	setResponse(testOCSPResponse(t, ca, leaf, true, testKeyEC384, nil))
+	v.Revocation = new(OCSP).Check
+	if _, err := v.Check(token); err == nil || err == ErrRevoked {
+		t.Errorf("got error %v for response from unknown signer", err)
+	}
+}
+
+func TestOCSPDelegated(t *testing.T) {
+	ca, leaf := testCertChain(t, "")
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(3),
+		Subject:      pkix.Name{CommonName: "Test OCSP"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageOCSPSigning},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, ca, &testKeyEC384.PublicKey, testKeyEC256)
+	if err != nil {
+		t.Fatal(err)
+	}
+	responder, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	o := new(OCSP)
+	if err := o.Staple(testOCSPResponse(t, ca, leaf, true, testKeyEC384, responder), leaf, ca); err != ErrRevoked {
+		t.Errorf("got error %v for delegated response, want %v", err, ErrRevoked)
+	}
+	// stapled status in use without responder
+	if err := o.Check(leaf, ca); err != ErrRevoked {
+		t.Errorf("got error %v after staple, want %v", err, ErrRevoked)
+	}
+
+	// responder without the OCSP signing purpose
+	template.ExtKeyUsage = []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth}
+	der, err = x509.CreateCertificate(rand.Reader, template, ca, &testKeyEC384.PublicKey, testKeyEC256)
+	if err != nil {
+		t.Fatal(err)
+	}
+	responder, err = x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := new(OCSP).Staple(testOCSPResponse(t, ca, leaf, false, testKeyEC384, responder), leaf, ca); err == nil {
+		t.Error("no error for responder without OCSP signing purpose")
+	}
+}
@@ -0,0 +1,377 @@
+package jwt
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// Policy errors signal a claim constraint violation.
+var (
+	ErrExpired   = errors.New("jwt: token expired")
+	ErrNotBefore = errors.New("jwt: token not valid yet")
+	ErrIssuedAt  = errors.New("jwt: token issued in the future")
+	ErrIssuer    = errors.New("jwt: issuer not accepted")
+	ErrAudience  = errors.New("jwt: audience not accepted")
+	ErrClaimMiss = errors.New("jwt: required claim absent")
+	ErrNonce     = errors.New("jwt: nonce not accepted")
+)
+
+// Checker verifies the signature of a token. KeyRegister, HMAC, RemoteKeys,
+// Verifier and Router are all implementations.
+type Checker interface {
+	Check(token []byte) (*Claims, error)
+}
+
+// Policy defines the constraints for claims acceptance. The zero value accepts
+// any claims within the time constraints ("nbf" & "exp").
+type Policy struct {
+	// Issuers lists the accepted "iss" values. Any issuer (including
+	// none) passes when empty.
+	Issuers []string
+
+	// Audiences lists the accepted "aud" values. Tokens must name at
+	// least one of them. Any audience (including none) passes when
+	// empty.
+	Audiences []string
+
+	// AudienceMatch compares each of the Audiences with the "aud" values
+	// in tokens. Nil defaults to AudienceExact.
+	AudienceMatch func(accept, aud string) bool
+
+	// Algs lists the accepted algorithms. Any algorithm passes when
+	// empty.
+	Algs []string
+
+	// Require lists the claim names which must be present.
+	Require []string
+
+	// Leeway is the tolerance for clock skew on time constraints.
+	Leeway time.Duration
+
+	// RejectFutureIssued denies tokens with an "iat" claim beyond the
+	// current time plus Leeway, which usually means a misconfigured
+	// clock at the issuer, or a forgery.
+	RejectFutureIssued bool
+
+	// NumericStrings accepts "exp", "nbf" and "iat" claims with a number
+	// in a JSON string, as emitted by some identity providers. Apply moves
+	// such claims into Registered with Claims.CoerceNumericStrings.
+	NumericStrings bool
+
+	// When not nil, then Nonce must confirm the NonceClaim value, e.g.,
+	// with a lookup of the challenge stored for the client session. A
+	// false return causes ErrNonce. Tokens without the claim fail with
+	// ErrClaimMiss.
+	Nonce func(value string) bool
+
+	// NonceClaim names the claim for Nonce. The empty string defaults to
+	// "nonce".
+	NonceClaim string
+
+	// When not nil, then Schema must accept the payload, i.e., the JSON
+	// claims set, before any of the other constraints apply. Rejections
+	// are wrapped in a SchemaError.
+	Schema SchemaValidator
+
+	// When not nil, then Func is called after all other constraints
+	// passed. The return, if any, is passed as is.
+	Func func(c *Claims, now time.Time) error
+
+	// SoftFail lists ErrorCode values, like "aud_mismatch", for which
+	// violations pass to Report, instead of a rejection. Operators can
+	// measure the impact of new constraints this way, before enforcement.
+	SoftFail []string
+
+	// SoftFailUntil ends the soft-fail mode, such that migrations don't
+	// linger. SoftFail is ignored from then on, including the zero value.
+	SoftFailUntil time.Time
+
+	// Report receives each violation in soft-fail mode, e.g., for a
+	// metric count per ErrorCode. Nil discards the violations.
+	Report func(c *Claims, err error)
+}
+
+// Apply returns the first constraint violation, if any, for claims at the
+// given moment in time.
+func (p *Policy) Apply(c *Claims, now time.Time) error {
+	if p.NumericStrings {
+		c.CoerceNumericStrings()
+	}
+
+	if p.Schema != nil {
+		payload, err := c.payload()
+		if err != nil {
+			return err
+		}
+		if err := p.Schema.Validate(payload); err != nil {
+			if err := p.violation(c, now, &SchemaError{Err: err}); err != nil {
+				return err
+			}
+		}
+	}
+
+	if len(p.Algs) != 0 {
+		var header struct {
+			Alg string `json:"alg"`
+		}
+		if err := json.Unmarshal([]byte(c.RawHeader), &header); err != nil {
+			return fmt.Errorf("jwt: malformed JOSE header: %w", err)
+		}
+		if !containsString(p.Algs, header.Alg) {
+			if err := p.violation(c, now, AlgError(header.Alg)); err != nil {
+				return err
+			}
+		}
+	}
+
+	for _, name := range p.Require {
+		if !c.has(name) {
+			if err := p.violation(c, now, fmt.Errorf("%w: %q", ErrClaimMiss, name)); err != nil {
+				return err
+			}
+		}
+	}
+
+	if c.Expires != nil && !now.Add(-p.Leeway).Before(c.Expires.Time()) {
+		if err := p.violation(c, now, ErrExpired); err != nil {
+			return err
+		}
+	}
+	if c.NotBefore != nil && now.Add(p.Leeway).Before(c.NotBefore.Time()) {
+		if err := p.violation(c, now, ErrNotBefore); err != nil {
+			return err
+		}
+	}
+	if p.RejectFutureIssued && c.Issued != nil && now.Add(p.Leeway).Before(c.Issued.Time()) {
+		if err := p.violation(c, now, ErrIssuedAt); err != nil {
+			return err
+		}
+	}
+
+	if len(p.Issuers) != 0 && !containsString(p.Issuers, c.Issuer) {
+		if err := p.violation(c, now, ErrIssuer); err != nil {
+			return err
+		}
+	}
+
+	if len(p.Audiences) != 0 && !p.acceptAudience(c.Audiences) {
+		if err := p.violation(c, now, ErrAudience); err != nil {
+			return err
+		}
+	}
+
+	if p.Nonce != nil {
+		name := p.NonceClaim
+		if name == "" {
+			name = nonce
+		}
+		value, ok := c.String(name)
+		if !ok {
+			if err := p.violation(c, now, fmt.Errorf("%w: %q", ErrClaimMiss, name)); err != nil {
+				return err
+			}
+		} else if !p.Nonce(value) {
+			if err := p.violation(c, now, ErrNonce); err != nil {
+				return err
+			}
+		}
+	}
+
+	if p.Func != nil {
+		if err := p.Func(c, now); err != nil {
+			return p.violation(c, now, err)
+		}
+	}
+	return nil
+}
+
+// Violation returns err, or nil when the ErrorCode of err is in soft-fail mode.
+func (p *Policy) violation(c *Claims, now time.Time, err error) error {
+	if len(p.SoftFail) == 0 || !now.Before(p.SoftFailUntil) || !containsString(p.SoftFail, ErrorCode(err)) {
+		return err
+	}
+	if p.Report != nil {
+		p.Report(c, err)
+	}
+	return nil
+}
+
+func (p *Policy) acceptAudience(auds []string) bool {
+	match := p.AudienceMatch
+	if match == nil {
+		match = AudienceExact
+	}
+	for _, aud := range auds {
+		for _, accept := range p.Audiences {
+			if match(accept, aud) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// AudienceExact is a Policy AudienceMatch conform RFC 7519, subsection 4.1.3.
+// “In the general case, the "aud" value is an array of case-sensitive
+// strings, each containing a StringOrURI value.”
+func AudienceExact(accept, aud string) bool { return accept == aud }
+
+// AudienceFold is a Policy AudienceMatch which ignores case differences.
+func AudienceFold(accept, aud string) bool { return strings.EqualFold(accept, aud) }
+
+// AudienceURL is a Policy AudienceMatch which compares URLs after
+// normalization. The scheme and the host are case-insensitive, default ports
+// (80 for http and 443 for https) are omitted, and trailing slashes in the
+// path don't matter. Values other than absolute URLs must be exactly equal.
+func AudienceURL(accept, aud string) bool {
+	if accept == aud {
+		return true
+	}
+	a, ok := normalURL(accept)
+	if !ok {
+		return false
+	}
+	b, ok := normalURL(aud)
+	return ok && a == b
+}
+
+func normalURL(s string) (string, bool) {
+	u, err := url.Parse(s)
+	if err != nil || u.Scheme == "" || u.Host == "" || u.Opaque != "" {
+		return "", false
+	}
+	u.Scheme = strings.ToLower(u.Scheme)
+	host, port := strings.ToLower(u.Hostname()), u.Port()
+	if (u.Scheme == "https" && port == "443") || (u.Scheme == "http" && port == "80") {
+		port = ""
+	}
+	if port != "" {
+		u.Host = net.JoinHostPort(host, port)
+	} else if strings.IndexByte(host, ':') >= 0 {
+		u.Host = "[" + host + "]"
+	} else {
+		u.Host = host
+	}
+	u.Path = strings.TrimRight(u.Path, "/")
+	u.RawPath = ""
+	return u.String(), true
+}
+
+// Verifier applies a Policy on each token which checks out with Keys.
+type Verifier struct {
+	// Keys defines the trusted credentials.
+	Keys Checker
+
+	Policy
+
+	// Now is the time source. Nil defaults to time.Now.
+	Now func() time.Time
+}
+
+// Check parses a JWT if, and only if, the signature checks out with Keys,
+// and the claims are in compliance with Policy.
+func (v *Verifier) Check(token []byte) (*Claims, error) {
+	return v.CheckTrace(token, nil)
+}
+
+// CheckDeadline is like Check, including the moment in time until which the
+// result remains valid, i.e., the expiry minus Leeway, or the ValidUntil of
+// Keys, whichever comes first. Keys provide ValidUntil with a method of the
+// same name, like RemoteKeys does. A zero deadline means no limit. Gateways
+// may cache authorization decisions up until the deadline.
+func (v *Verifier) CheckDeadline(token []byte) (c *Claims, deadline time.Time, err error) {
+	c, err = v.Check(token)
+	if err != nil {
+		return nil, time.Time{}, err
+	}
+	// after Check, as verification may refresh the keys
+	var keysDeadline time.Time
+	if keys, ok := v.Keys.(interface{ ValidUntil() time.Time }); ok {
+		keysDeadline = keys.ValidUntil()
+	}
+
+	if c.Expires != nil {
+		deadline = c.Expires.Time().Add(-v.Leeway)
+	}
+	if !keysDeadline.IsZero() && (deadline.IsZero() || keysDeadline.Before(deadline)) {
+		deadline = keysDeadline
+	}
+	return c, deadline, nil
+}
+
+// CheckWithPolicy parses a JWT if, and only if, the signature checks out, and
+// the claims are in compliance with p at the current time. Key selection is
+// conform Check. It is a shorthand for a Verifier with keys as Keys.
+func (keys *KeyRegister) CheckWithPolicy(token []byte, p *Policy) (*Claims, error) {
+	c, err := keys.Check(token)
+	if err != nil {
+		return nil, err
+	}
+	if err := p.Apply(c, time.Now()); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+// Has returns whether the claim is present, including JSON null.
+func (c *Claims) has(name string) bool {
+	switch name {
+	case issuer:
+		if c.Issuer != "" {
+			return true
+		}
+	case subject:
+		if c.Subject != "" {
+			return true
+		}
+	case audience:
+		if c.Audiences != nil {
+			return true
+		}
+	case expires:
+		if c.Expires != nil {
+			return true
+		}
+	case notBefore:
+		if c.NotBefore != nil {
+			return true
+		}
+	case issued:
+		if c.Issued != nil {
+			return true
+		}
+	case id:
+		if c.ID != "" {
+			return true
+		}
+	case sessionID:
+		if c.SessionID != "" {
+			return true
+		}
+	case nonce:
+		if c.Nonce != "" {
+			return true
+		}
+	case events:
+		if c.Events != nil {
+			return true
+		}
+	}
+
+	_, ok := c.Set[name]
+	return ok
+}
+
+func containsString(a []string, s string) bool {
+	for _, o := range a {
+		if o == s {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,299 @@
+//go:build !jwt_hmac_only && !jwt_no_ecdsa
+// +build !jwt_hmac_only,!jwt_no_ecdsa
+
+package jwt
+
+import (
+	"crypto/ed25519"
+	"encoding/json"
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestPolicyApply(t *testing.T) {
+	now := time.Unix(1600000000, 0)
+
+	var c Claims
+	c.Issuer = "a"
+	c.Audiences = []string{"b", "c"}
+	c.Expires = NewNumericTime(now.Add(time.Minute))
+	c.NotBefore = NewNumericTime(now.Add(-time.Minute))
+	c.Issued = NewNumericTime(now.Add(30 * time.Second))
+	if _, err := c.HMACSign(HS256, []byte("guest")); err != nil {
+		t.Fatal("sign error:", err)
+	}
+
+	golden := []struct {
+		policy Policy
+		now    time.Time
+		want   error
+	}{
+		{Policy{}, now, nil},
+		{Policy{Issuers: []string{"x", "a"}}, now, nil},
+		{Policy{Issuers: []string{"x"}}, now, ErrIssuer},
+		{Policy{Audiences: []string{"c"}}, now, nil},
+		{Policy{Audiences: []string{"x"}}, now, ErrAudience},
+		{Policy{Algs: []string{ES256, HS256}}, now, nil},
+		{Policy{Algs: []string{ES256}}, now, AlgError(HS256)},
+		{Policy{Require: []string{"iss", "exp"}}, now, nil},
+		{Policy{}, now.Add(time.Minute), ErrExpired},
+		{Policy{Leeway: time.Second}, now.Add(time.Minute), nil},
+		{Policy{}, now.Add(-2 * time.Minute), ErrNotBefore},
+		{Policy{Leeway: time.Minute}, now.Add(-2 * time.Minute), nil},
+		{Policy{RejectFutureIssued: true}, now, ErrIssuedAt},
+		{Policy{RejectFutureIssued: true, Leeway: 31 * time.Second}, now, nil},
+		{Policy{RejectFutureIssued: true}, now.Add(30 * time.Second), nil},
+	}
+	for i, gold := range golden {
+		if err := gold.policy.Apply(&c, gold.now); err != gold.want {
+			t.Errorf("%d: got error %v, want %v", i, err, gold.want)
+		}
+	}
+
+	err := (&Policy{Require: []string{"sub"}}).Apply(&c, now)
+	if !errors.Is(err, ErrClaimMiss) {
+		t.Errorf("got error %v for absent claim, want %v", err, ErrClaimMiss)
+	}
+}
+
+func TestPolicyNumericStrings(t *testing.T) {
+	now := time.Unix(1600000000, 0)
+	c := &Claims{Raw: json.RawMessage(`{"exp":"1599999999","iat":" 1599999000.5 ","nbf":"soon","level":"7"}`)}
+	if err := c.applyPayload(); err != nil {
+		t.Fatal(err)
+	}
+	if err := new(Policy).Apply(c, now); err != nil {
+		t.Error("apply error without coercion:", err)
+	}
+	if _, ok := c.Number("level"); ok {
+		t.Error("got number for string claim")
+	}
+	if n, ok := c.Numeric("level"); !ok || n != 7 {
+		t.Errorf("got numeric %f, want 7", n)
+	}
+
+	if err := (&Policy{NumericStrings: true}).Apply(c, now); err != ErrExpired {
+		t.Errorf("got error %v with coercion, want %v", err, ErrExpired)
+	}
+	if c.Issued == nil || *c.Issued != 1599999000.5 {
+		t.Errorf("got iat %v, want 1599999000.5", c.Issued)
+	}
+	if c.NotBefore != nil || c.Set["nbf"] != "soon" {
+		t.Error("non-numeric nbf string coerced")
+	}
+	if _, ok := c.Set["level"]; !ok {
+		t.Error("custom claim coerced")
+	}
+}
+
+func TestPolicyNonce(t *testing.T) {
+	challenges := map[string]bool{"n-0S6_WzA2Mj": true}
+	p := Policy{Nonce: func(value string) bool {
+		ok := challenges[value]
+		delete(challenges, value) // single use
+		return ok
+	}}
+
+	c := new(Claims)
+	c.Nonce = "n-0S6_WzA2Mj"
+	if err := p.Apply(c, time.Now()); err != nil {
+		t.Error("apply error:", err)
+	}
+	if err := p.Apply(c, time.Now()); err != ErrNonce {
+		t.Errorf("got error %v on replay, want %v", err, ErrNonce)
+	}
+	if err := p.Apply(new(Claims), time.Now()); !errors.Is(err, ErrClaimMiss) {
+		t.Errorf("got error %v without nonce, want %v", err, ErrClaimMiss)
+	}
+
+	p.NonceClaim = "dpop_nonce"
+	challenges["eyJ7S_zG"] = true
+	c = &Claims{Set: map[string]interface{}{"dpop_nonce": "eyJ7S_zG"}}
+	if err := p.Apply(c, time.Now()); err != nil {
+		t.Error("apply error with named claim:", err)
+	}
+}
+
+func TestPolicySoftFail(t *testing.T) {
+	now := time.Now()
+	var reported []string
+	p := Policy{
+		Audiences:     []string{"api"},
+		Algs:          []string{EdDSA},
+		Issuers:       []string{"https://example.com"},
+		SoftFail:      []string{"aud_mismatch", "alg_unsupported"},
+		SoftFailUntil: now.Add(time.Hour),
+		Report: func(c *Claims, err error) {
+			reported = append(reported, ErrorCode(err))
+		},
+	}
+
+	c := &Claims{RawHeader: json.RawMessage(`{"alg":"HS256"}`)}
+	c.Issuer = "https://example.com"
+	c.Audiences = []string{"legacy"}
+	if err := p.Apply(c, now); err != nil {
+		t.Error("apply error in soft-fail mode:", err)
+	}
+	if want := []string{"alg_unsupported", "aud_mismatch"}; !reflect.DeepEqual(reported, want) {
+		t.Errorf("got reports %q, want %q", reported, want)
+	}
+
+	c.Issuer = "https://example.org"
+	if err := p.Apply(c, now); err != ErrIssuer {
+		t.Errorf("got error %v, want %v enforced", err, ErrIssuer)
+	}
+
+	c.Issuer = "https://example.com"
+	reported = nil
+	if err := p.Apply(c, now.Add(time.Hour)); err != AlgError(HS256) {
+		t.Errorf("got error %v after soft-fail expiry, want %v", err, AlgError(HS256))
+	}
+	if len(reported) != 0 {
+		t.Errorf("got reports %q after soft-fail expiry", reported)
+	}
+}
+
+func TestVerifier(t *testing.T) {
+	now := time.Unix(1600000000, 0)
+
+	var c Claims
+	c.Issuer = "a"
+	c.Expires = NewNumericTime(now)
+	token, err := c.EdDSASign(testKeyEd25519Private)
+	if err != nil {
+		t.Fatal("sign error:", err)
+	}
+
+	v := Verifier{
+		Keys:   &KeyRegister{EdDSAs: []ed25519.PublicKey{testKeyEd25519Public}},
+		Policy: Policy{Issuers: []string{"a"}},
+		Now:    func() time.Time { return now.Add(-time.Second) },
+	}
+	if _, err := v.Check(token); err != nil {
+		t.Error("check error:", err)
+	}
+	v.Now = func() time.Time { return now }
+	if _, err := v.Check(token); err != ErrExpired {
+		t.Errorf("got error %v, want %v", err, ErrExpired)
+	}
+	v.Keys = new(KeyRegister)
+	if _, err := v.Check(token); err != ErrSigMiss {
+		t.Errorf("got error %v, want %v", err, ErrSigMiss)
+	}
+}
+
+func TestCheckWithPolicy(t *testing.T) {
+	var c Claims
+	c.Issuer = "a"
+	c.Expires = NewNumericTime(time.Now().Add(time.Hour))
+	token, err := c.EdDSASign(testKeyEd25519Private)
+	if err != nil {
+		t.Fatal("sign error:", err)
+	}
+
+	keys := KeyRegister{EdDSAs: []ed25519.PublicKey{testKeyEd25519Public}}
+	if _, err := keys.CheckWithPolicy(token, &Policy{Issuers: []string{"a"}, Algs: []string{EdDSA}}); err != nil {
+		t.Error("check error:", err)
+	}
+	if _, err := keys.CheckWithPolicy(token, &Policy{Issuers: []string{"b"}}); err != ErrIssuer {
+		t.Errorf("got error %v, want %v", err, ErrIssuer)
+	}
+	if _, err := keys.CheckWithPolicy(token, &Policy{Algs: []string{ES256}}); err != AlgError(EdDSA) {
+		t.Errorf("got error %v, want %v", err, AlgError(EdDSA))
+	}
+	if _, err := new(KeyRegister).CheckWithPolicy(token, new(Policy)); err != ErrSigMiss {
+		t.Errorf("got error %v, want %v", err, ErrSigMiss)
+	}
+}
+
+func TestCheckDeadline(t *testing.T) {
+	now := time.Unix(1600000000, 0)
+
+	var c Claims
+	c.Expires = NewNumericTime(now.Add(time.Hour))
+	token, err := c.EdDSASign(testKeyEd25519Private)
+	if err != nil {
+		t.Fatal("sign error:", err)
+	}
+
+	v := Verifier{
+		Keys:   &KeyRegister{EdDSAs: []ed25519.PublicKey{testKeyEd25519Public}},
+		Policy: Policy{Leeway: time.Minute},
+		Now:    func() time.Time { return now },
+	}
+	_, deadline, err := v.CheckDeadline(token)
+	if err != nil {
+		t.Fatal("check error:", err)
+	}
+	if want := now.Add(59 * time.Minute); !deadline.Equal(want) {
+		t.Errorf("got deadline %s, want %s", deadline, want)
+	}
+
+	v.Keys = deadlineKeys{v.Keys, now.Add(time.Minute)}
+	_, deadline, err = v.CheckDeadline(token)
+	if err != nil {
+		t.Fatal("check error:", err)
+	}
+	if want := now.Add(time.Minute); !deadline.Equal(want) {
+		t.Errorf("got deadline %s, want key expiry %s", deadline, want)
+	}
+
+	token, err = new(Claims).EdDSASign(testKeyEd25519Private)
+	if err != nil {
+		t.Fatal("sign error:", err)
+	}
+	v.Keys = deadlineKeys{v.Keys, time.Time{}}
+	if _, deadline, err := v.CheckDeadline(token); err != nil {
+		t.Error("check error:", err)
+	} else if !deadline.IsZero() {
+		t.Errorf("got deadline %s, want none", deadline)
+	}
+}
+
+type deadlineKeys struct {
+	Checker
+	until time.Time
+}
+
+func (keys deadlineKeys) ValidUntil() time.Time { return keys.until }
+
+func TestAudienceMatch(t *testing.T) {
+	golden := []struct {
+		accept, aud       string
+		exact, fold, norm bool
+	}{
+		{"api", "api", true, true, true},
+		{"api", "API", false, true, false},
+		{"https://api.example.com", "https://api.example.com/", false, false, true},
+		{"https://api.example.com", "HTTPS://API.Example.com:443", false, false, true},
+		{"http://api.example.com:80/v1/", "http://api.example.com/v1", false, false, true},
+		{"https://api.example.com/v1", "https://api.example.com/V1", false, true, false},
+		{"https://api.example.com:8443", "https://api.example.com", false, false, false},
+		{"http://api.example.com", "https://api.example.com", false, false, false},
+		{"https://[::1]:443/", "https://[::1]", false, false, true},
+		{"urn:example:api", "urn:example:api/", false, false, false},
+	}
+	for _, gold := range golden {
+		if got := AudienceExact(gold.accept, gold.aud); got != gold.exact {
+			t.Errorf("exact got %t for %q and %q", got, gold.accept, gold.aud)
+		}
+		if got := AudienceFold(gold.accept, gold.aud); got != gold.fold {
+			t.Errorf("fold got %t for %q and %q", got, gold.accept, gold.aud)
+		}
+		if got := AudienceURL(gold.accept, gold.aud); got != gold.norm {
+			t.Errorf("URL got %t for %q and %q", got, gold.accept, gold.aud)
+		}
+	}
+
+	c := Claims{Registered: Registered{Audiences: []string{"https://api.example.com/"}}}
+	p := Policy{Audiences: []string{"https://api.example.com"}}
+	if err := p.Apply(&c, time.Now()); err != ErrAudience {
+		t.Errorf("got error %v, want %v", err, ErrAudience)
+	}
+	p.AudienceMatch = AudienceURL
+	if err := p.Apply(&c, time.Now()); err != nil {
+		t.Error("apply error:", err)
+	}
+}
@@ -0,0 +1,301 @@
+package jwt
+
+import (
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Google key locations.
+const (
+	GoogleJWKSURL   = "https://www.googleapis.com/oauth2/v3/certs"
+	FirebaseJWKSURL = "https://www.googleapis.com/service_accounts/v1/jwk/securetoken@system.gserviceaccount.com"
+)
+
+// Apple key location.
+const AppleJWKSURL = "https://appleid.apple.com/auth/keys"
+
+// EntraJWKSURL has the Microsoft Entra ID key location, with a placeholder
+// for the tenant.
+const EntraJWKSURL = "https://login.microsoftonline.com/%s/discovery/v2.0/keys"
+
+// Google returns a Verifier for ID tokens from “Sign In With Google”. Only
+// tokens issued to any of the OAuth 2.0 client IDs pass. The client ID is
+// mandatory, as Google signs the tokens of all clients with the same keys.
+func Google(clientID string, moreClientIDs ...string) *Verifier {
+	return &Verifier{
+		Keys: &RemoteKeys{URL: GoogleJWKSURL},
+		Policy: Policy{
+			Issuers:   []string{"https://accounts.google.com", "accounts.google.com"},
+			Audiences: append([]string{clientID}, moreClientIDs...),
+			Algs:      []string{RS256},
+			Require:   []string{subject, expires, issued},
+			Leeway:    5 * time.Minute,
+		},
+	}
+}
+
+var (
+	errAuthTime     = errors.New("jwt: auth_time in the future")
+	errAuthTimeType = errors.New("jwt: want number for claim auth_time")
+)
+
+// Firebase returns a Verifier for ID tokens from Firebase Authentication.
+// Only tokens issued for the project ID pass.
+func Firebase(projectID string) *Verifier {
+	return &Verifier{
+		Keys: &RemoteKeys{URL: FirebaseJWKSURL},
+		Policy: Policy{
+			Issuers:   []string{"https://securetoken.google.com/" + projectID},
+			Audiences: []string{projectID},
+			Algs:      []string{RS256},
+			Require:   []string{subject, expires, issued, "auth_time"},
+			Leeway:    5 * time.Minute,
+			Func: func(c *Claims, now time.Time) error {
+				authTime, ok := c.Number("auth_time")
+				if !ok {
+					return errAuthTimeType
+				}
+				if (*NumericTime)(&authTime).Time().After(now.Add(5 * time.Minute)) {
+					return errAuthTime
+				}
+				return nil
+			},
+		},
+	}
+}
+
+// Apple returns a Verifier for identity tokens from “Sign in with Apple”.
+// Only tokens issued to any of the client IDs (the bundle ID for native apps
+// and the services ID for the web) pass. Use AppleNonce to complete the
+// verification when a nonce was included in the authorization request. The
+// client ID is mandatory, as Apple signs the tokens of all clients with the
+// same keys.
+func Apple(clientID string, moreClientIDs ...string) *Verifier {
+	return &Verifier{
+		Keys: &RemoteKeys{URL: AppleJWKSURL},
+		Policy: Policy{
+			Issuers:   []string{"https://appleid.apple.com"},
+			Audiences: append([]string{clientID}, moreClientIDs...),
+			Algs:      []string{RS256},
+			Require:   []string{subject, expires, issued},
+			Leeway:    5 * time.Minute,
+		},
+	}
+}
+
+// AppleNonce returns whether the "nonce" claim matches the raw nonce from
+// the authorization request. Both the nonce as is, and its SHA-256 digest in
+// (lower case) hexadecimal pass, as native apps commonly submit the latter.
+// An absent nonce only passes when "nonce_supported" is false, i.e., when the
+// platform lacks support.
+func AppleNonce(c *Claims, nonce string) bool {
+	got, ok := c.String("nonce")
+	if !ok {
+		supported, ok := c.Set["nonce_supported"].(bool)
+		return ok && !supported
+	}
+
+	sum := sha256.Sum256([]byte(nonce))
+	digest := hex.EncodeToString(sum[:])
+	return subtle.ConstantTimeCompare([]byte(got), []byte(nonce)) == 1 ||
+		subtle.ConstantTimeCompare([]byte(got), []byte(digest)) == 1
+}
+
+// AppleTransferSubject returns the "transfer_sub" claim, which is present
+// during the transfer of an app to another team. Map the value to the user
+// known by the previous team with Apple's user migration API.
+func AppleTransferSubject(c *Claims) (sub string, ok bool) {
+	return c.String("transfer_sub")
+}
+
+var (
+	errEntraVersion = errors.New("jwt: unknown Entra token version")
+	errEntraTenant  = errors.New("jwt: Entra tenant not accepted")
+)
+
+// Entra returns a Verifier for tokens from Microsoft Entra ID (formerly Azure
+// Active Directory). Only tokens from the tenant ID, and issued to any of the
+// audiences (the application ID and/or application ID URI) pass. Both v1.0
+// and v2.0 tokens are accepted. See EntraMultiTenant for the common endpoint.
+// The audience is mandatory, as Entra signs the tokens of all applications
+// with the same keys.
+func Entra(tenantID, audience string, moreAudiences ...string) *Verifier {
+	v := EntraMultiTenant([]string{tenantID}, audience, moreAudiences...)
+	v.Keys = &RemoteKeys{URL: fmt.Sprintf(EntraJWKSURL, tenantID)}
+	return v
+}
+
+// EntraMultiTenant returns a Verifier for tokens from Microsoft Entra ID with
+// any of the tenant IDs. The issuer must match the "tid" claim (for the
+// respective token version) in any case, as the keys from the common endpoint
+// are shared by all tenants. The audience is mandatory, like with Entra.
+//
+// An empty tenant ID list accepts tokens from every tenant, i.e., any Entra
+// organisation, including free ones which anyone can create. Applications
+// must then authorize the "tid" claim themselves.
+func EntraMultiTenant(tenantIDs []string, audience string, moreAudiences ...string) *Verifier {
+	return &Verifier{
+		Keys: &RemoteKeys{URL: fmt.Sprintf(EntraJWKSURL, "common")},
+		Policy: Policy{
+			Audiences: append([]string{audience}, moreAudiences...),
+			Algs:      []string{RS256},
+			Require:   []string{subject, expires, "tid"},
+			Leeway:    5 * time.Minute,
+			Func: func(c *Claims, now time.Time) error {
+				tid, _ := c.String("tid")
+				if len(tenantIDs) != 0 && !containsString(tenantIDs, tid) {
+					return errEntraTenant
+				}
+
+				var want string
+				switch ver, _ := c.String("ver"); ver {
+				case "1.0":
+					want = "https://sts.windows.net/" + tid + "/"
+				case "2.0":
+					want = "https://login.microsoftonline.com/" + tid + "/v2.0"
+				default:
+					return errEntraVersion
+				}
+				if c.Issuer != want {
+					return ErrIssuer
+				}
+				return nil
+			},
+		},
+	}
+}
+
+// EntraClientID returns the application ID of the client, which is the "azp"
+// claim in v2.0 tokens, and the "appid" claim in v1.0 tokens.
+func EntraClientID(c *Claims) (appID string, ok bool) {
+	if s, ok := c.String("azp"); ok {
+		return s, true
+	}
+	return c.String("appid")
+}
+
+var (
+	errCognitoTokenUse = errors.New("jwt: Cognito token_use not accepted")
+	errCognitoClient   = errors.New("jwt: Cognito client_id not accepted")
+)
+
+// Cognito returns a Verifier for tokens from an Amazon Cognito user pool. The
+// token use must be either "id" or "access". Only tokens issued to any of the
+// app client IDs pass, which is the "aud" claim for ID tokens, and the
+// "client_id" claim for access tokens. The client ID is mandatory, as all app
+// clients of a user pool share the same keys.
+func Cognito(region, userPoolID, tokenUse, clientID string, moreClientIDs ...string) *Verifier {
+	iss := "https://cognito-idp." + region + ".amazonaws.com/" + userPoolID
+	clientIDs := append([]string{clientID}, moreClientIDs...)
+
+	v := &Verifier{
+		Keys: &RemoteKeys{URL: iss + "/.well-known/jwks.json"},
+		Policy: Policy{
+			Issuers: []string{iss},
+			Algs:    []string{RS256},
+			Require: []string{subject, expires, "token_use"},
+			Leeway:  5 * time.Minute,
+		},
+	}
+
+	if tokenUse == "id" {
+		v.Audiences = clientIDs
+	}
+	v.Func = func(c *Claims, now time.Time) error {
+		if s, _ := c.String("token_use"); s != tokenUse {
+			return errCognitoTokenUse
+		}
+		if tokenUse != "id" {
+			s, _ := c.String("client_id")
+			if !containsString(clientIDs, s) {
+				return errCognitoClient
+			}
+		}
+		return nil
+	}
+	return v
+}
+
+// Auth0 returns a Verifier for access tokens from an Auth0 tenant domain,
+// e.g., "example.eu.auth0.com" or a custom domain. Only tokens issued to the
+// API audience pass. The keys are resolved with OpenID Connect Discovery.
+func Auth0(domain, audience string) *Verifier {
+	iss := "https://" + domain + "/"
+	return &Verifier{
+		Keys: &RemoteKeys{Issuer: iss},
+		Policy: Policy{
+			Issuers:   []string{iss},
+			Audiences: []string{audience},
+			Algs:      []string{RS256},
+			Require:   []string{subject, expires},
+			Leeway:    time.Minute,
+		},
+	}
+}
+
+var (
+	errKeycloakType = errors.New("jwt: Keycloak typ not Bearer")
+	errKeycloakAZP  = errors.New("jwt: Keycloak azp not accepted")
+)
+
+// Keycloak returns a Verifier for access tokens from a Keycloak realm. The
+// base URL includes any context path, e.g., "https://sso.example.com" or
+// "https://example.com/auth" for legacy deployments. Only tokens authorized
+// for any of the client IDs ("azp" claim) pass. Audiences remain unchecked,
+// as Keycloak populates them with audience mappers only. The client ID is
+// mandatory, as Keycloak signs the tokens of all clients in a realm with the
+// same keys.
+func Keycloak(baseURL, realm, clientID string, moreClientIDs ...string) *Verifier {
+	iss := strings.TrimSuffix(baseURL, "/") + "/realms/" + realm
+	clientIDs := append([]string{clientID}, moreClientIDs...)
+	return &Verifier{
+		Keys: &RemoteKeys{URL: iss + "/protocol/openid-connect/certs"},
+		Policy: Policy{
+			Issuers: []string{iss},
+			Algs:    []string{RS256},
+			Require: []string{expires},
+			Leeway:  time.Minute,
+			Func: func(c *Claims, now time.Time) error {
+				if typ, _ := c.String("typ"); typ != "Bearer" {
+					return errKeycloakType
+				}
+				if azp, _ := c.String("azp"); !containsString(clientIDs, azp) {
+					return errKeycloakAZP
+				}
+				return nil
+			},
+		},
+	}
+}
+
+// KeycloakRealmRoles returns the realm roles of the subject, as listed by the
+// "realm_access" claim.
+func KeycloakRealmRoles(c *Claims) []string {
+	access, _ := c.Set["realm_access"].(map[string]interface{})
+	return stringsFromArray(access["roles"])
+}
+
+// KeycloakClientRoles returns the client roles of the subject, as listed by
+// the "resource_access" claim.
+func KeycloakClientRoles(c *Claims, clientID string) []string {
+	resources, _ := c.Set["resource_access"].(map[string]interface{})
+	access, _ := resources[clientID].(map[string]interface{})
+	return stringsFromArray(access["roles"])
+}
+
+// StringsFromArray returns the string elements of a JSON array, if any.
+func stringsFromArray(v interface{}) []string {
+	a, _ := v.([]interface{})
+	var values []string
+	for _, o := range a {
+		if s, ok := o.(string); ok {
+			values = append(values, s)
+		}
+	}
+	return values
+}
@@ -0,0 +1,302 @@
+//go:build !jwt_hmac_only && !jwt_no_rsa
+// +build !jwt_hmac_only,!jwt_no_rsa
+
+package jwt
+
+import (
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/hex"
+	"testing"
+	"time"
+)
+
+func TestFirebase(t *testing.T) {
+	now := time.Unix(1600000000, 0)
+
+	var c Claims
+	c.Issuer = "https://securetoken.google.com/demo"
+	c.Subject = "u1"
+	c.Audiences = []string{"demo"}
+	c.Issued = NewNumericTime(now)
+	c.Expires = NewNumericTime(now.Add(time.Hour))
+	c.Set = map[string]interface{}{"auth_time": float64(now.Unix())}
+	token, err := c.RSASign(RS256, testKeyRSA2048)
+	if err != nil {
+		t.Fatal("sign error:", err)
+	}
+
+	v := Firebase("demo")
+	if v.Keys.(*RemoteKeys).URL != FirebaseJWKSURL {
+		t.Errorf("got JWKS URL %q, want %q", v.Keys.(*RemoteKeys).URL, FirebaseJWKSURL)
+	}
+	v.Keys = &KeyRegister{RSAs: []*rsa.PublicKey{&testKeyRSA2048.PublicKey}}
+	v.Now = func() time.Time { return now }
+	if _, err := v.Check(token); err != nil {
+		t.Error("check error:", err)
+	}
+
+	v.Now = func() time.Time { return now.Add(-time.Hour) }
+	if _, err := v.Check(token); err != errAuthTime {
+		t.Errorf("got error %v, want %v", err, errAuthTime)
+	}
+
+	v = Firebase("other")
+	v.Keys = &KeyRegister{RSAs: []*rsa.PublicKey{&testKeyRSA2048.PublicKey}}
+	v.Now = func() time.Time { return now }
+	if _, err := v.Check(token); err != ErrIssuer {
+		t.Errorf("got error %v for other project, want %v", err, ErrIssuer)
+	}
+}
+
+func TestGoogle(t *testing.T) {
+	now := time.Unix(1600000000, 0)
+
+	var c Claims
+	c.Issuer = "accounts.google.com"
+	c.Subject = "110169484474386276334"
+	c.Audiences = []string{"1008719970978-hb24n2dstb40o45d4feuo2ukqmcc6381.apps.googleusercontent.com"}
+	c.Issued = NewNumericTime(now)
+	c.Expires = NewNumericTime(now.Add(time.Hour))
+	token, err := c.RSASign(RS256, testKeyRSA2048)
+	if err != nil {
+		t.Fatal("sign error:", err)
+	}
+
+	v := Google("other.apps.googleusercontent.com", c.Audiences[0])
+	v.Keys = &KeyRegister{RSAs: []*rsa.PublicKey{&testKeyRSA2048.PublicKey}}
+	v.Now = func() time.Time { return now }
+	if _, err := v.Check(token); err != nil {
+		t.Error("check error:", err)
+	}
+
+	token, err = c.RSASign(PS256, testKeyRSA2048)
+	if err != nil {
+		t.Fatal("sign error:", err)
+	}
+	if _, err := v.Check(token); err != AlgError(PS256) {
+		t.Errorf("got error %v, want %v", err, AlgError(PS256))
+	}
+}
+
+func TestApple(t *testing.T) {
+	now := time.Unix(1600000000, 0)
+
+	sum := sha256.Sum256([]byte("n0nce"))
+
+	var c Claims
+	c.Issuer = "https://appleid.apple.com"
+	c.Subject = "001234.abcd"
+	c.Audiences = []string{"com.example.app"}
+	c.Issued = NewNumericTime(now)
+	c.Expires = NewNumericTime(now.Add(time.Hour))
+	c.Set = map[string]interface{}{
+		"nonce":        hex.EncodeToString(sum[:]),
+		"transfer_sub": "000999.team",
+	}
+	token, err := c.RSASign(RS256, testKeyRSA2048)
+	if err != nil {
+		t.Fatal("sign error:", err)
+	}
+
+	v := Apple("com.example.app")
+	v.Keys = &KeyRegister{RSAs: []*rsa.PublicKey{&testKeyRSA2048.PublicKey}}
+	v.Now = func() time.Time { return now }
+	got, err := v.Check(token)
+	if err != nil {
+		t.Fatal("check error:", err)
+	}
+
+	if !AppleNonce(got, "n0nce") {
+		t.Error("digest nonce not accepted")
+	}
+	if AppleNonce(got, "other") {
+		t.Error("other nonce accepted")
+	}
+	if sub, ok := AppleTransferSubject(got); !ok || sub != "000999.team" {
+		t.Errorf("got transfer subject %q, %t", sub, ok)
+	}
+
+	raw := Claims{Set: map[string]interface{}{"nonce": "n0nce"}}
+	if !AppleNonce(&raw, "n0nce") {
+		t.Error("raw nonce not accepted")
+	}
+	if AppleNonce(new(Claims), "n0nce") {
+		t.Error("absent nonce accepted")
+	}
+	unsupported := Claims{Set: map[string]interface{}{"nonce_supported": false}}
+	if !AppleNonce(&unsupported, "n0nce") {
+		t.Error("absent nonce on unsupported platform not accepted")
+	}
+}
+
+func TestEntra(t *testing.T) {
+	const tenant = "72f988bf-86f1-41af-91ab-2d7cd011db47"
+	now := time.Unix(1600000000, 0)
+
+	golden := []struct {
+		ver, iss, tid string
+		want          error
+	}{
+		{"2.0", "https://login.microsoftonline.com/" + tenant + "/v2.0", tenant, nil},
+		{"1.0", "https://sts.windows.net/" + tenant + "/", tenant, nil},
+		{"1.0", "https://login.microsoftonline.com/" + tenant + "/v2.0", tenant, ErrIssuer},
+		{"2.0", "https://login.microsoftonline.com/" + tenant + "/v2.0", "other", errEntraTenant},
+		{"3.0", "https://login.microsoftonline.com/" + tenant + "/v2.0", tenant, errEntraVersion},
+	}
+	for i, gold := range golden {
+		var c Claims
+		c.Issuer = gold.iss
+		c.Subject = "s"
+		c.Audiences = []string{"api://demo"}
+		c.Expires = NewNumericTime(now.Add(time.Hour))
+		c.Set = map[string]interface{}{"ver": gold.ver, "tid": gold.tid, "appid": "a"}
+		token, err := c.RSASign(RS256, testKeyRSA2048)
+		if err != nil {
+			t.Fatal("sign error:", err)
+		}
+
+		v := Entra(tenant, "api://demo")
+		v.Keys = &KeyRegister{RSAs: []*rsa.PublicKey{&testKeyRSA2048.PublicKey}}
+		v.Now = func() time.Time { return now }
+		got, err := v.Check(token)
+		if err != gold.want {
+			t.Errorf("%d: got error %v, want %v", i, err, gold.want)
+			continue
+		}
+		if err != nil {
+			continue
+		}
+		if id, ok := EntraClientID(got); !ok || id != "a" {
+			t.Errorf("%d: got client ID %q, %t", i, id, ok)
+		}
+
+		// any tenant
+		v = EntraMultiTenant(nil, "api://demo")
+		v.Keys = &KeyRegister{RSAs: []*rsa.PublicKey{&testKeyRSA2048.PublicKey}}
+		v.Now = func() time.Time { return now }
+		if _, err := v.Check(token); err != nil {
+			t.Errorf("%d: multi-tenant check error: %s", i, err)
+		}
+	}
+}
+
+func TestCognito(t *testing.T) {
+	const iss = "https://cognito-idp.eu-west-1.amazonaws.com/eu-west-1_AbC"
+	now := time.Unix(1600000000, 0)
+
+	var id Claims
+	id.Issuer = iss
+	id.Subject = "s"
+	id.Audiences = []string{"client1"}
+	id.Expires = NewNumericTime(now.Add(time.Hour))
+	id.Set = map[string]interface{}{"token_use": "id"}
+	idToken, err := id.RSASign(RS256, testKeyRSA2048)
+	if err != nil {
+		t.Fatal("sign error:", err)
+	}
+
+	var access Claims
+	access.Issuer = iss
+	access.Subject = "s"
+	access.Expires = NewNumericTime(now.Add(time.Hour))
+	access.Set = map[string]interface{}{"token_use": "access", "client_id": "client1"}
+	accessToken, err := access.RSASign(RS256, testKeyRSA2048)
+	if err != nil {
+		t.Fatal("sign error:", err)
+	}
+
+	golden := []struct {
+		tokenUse, clientID string
+		token              []byte
+		want               error
+	}{
+		{"id", "client1", idToken, nil},
+		{"id", "client2", idToken, ErrAudience},
+		{"access", "client1", idToken, errCognitoTokenUse},
+		{"access", "client1", accessToken, nil},
+		{"access", "client2", accessToken, errCognitoClient},
+		{"id", "client1", accessToken, ErrAudience},
+	}
+	for i, gold := range golden {
+		v := Cognito("eu-west-1", "eu-west-1_AbC", gold.tokenUse, gold.clientID)
+		if want := iss + "/.well-known/jwks.json"; v.Keys.(*RemoteKeys).URL != want {
+			t.Errorf("%d: got JWKS URL %q, want %q", i, v.Keys.(*RemoteKeys).URL, want)
+		}
+		v.Keys = &KeyRegister{RSAs: []*rsa.PublicKey{&testKeyRSA2048.PublicKey}}
+		v.Now = func() time.Time { return now }
+		if _, err := v.Check(gold.token); err != gold.want {
+			t.Errorf("%d: got error %v, want %v", i, err, gold.want)
+		}
+	}
+}
+
+func TestAuth0(t *testing.T) {
+	v := Auth0("example.eu.auth0.com", "https://api.example.com")
+	if got, want := v.Keys.(*RemoteKeys).Issuer, "https://example.eu.auth0.com/"; got != want {
+		t.Errorf("got discovery issuer %q, want %q", got, want)
+	}
+	if got := v.Issuers; len(got) != 1 || got[0] != "https://example.eu.auth0.com/" {
+		t.Errorf("got issuers %q", got)
+	}
+}
+
+func TestKeycloak(t *testing.T) {
+	const iss = "https://sso.example.com/realms/demo"
+	now := time.Unix(1600000000, 0)
+
+	var c Claims
+	c.Issuer = iss
+	c.Expires = NewNumericTime(now.Add(time.Hour))
+	c.Set = map[string]interface{}{
+		"typ": "Bearer",
+		"azp": "web",
+		"realm_access": map[string]interface{}{
+			"roles": []interface{}{"offline_access", "admin"},
+		},
+		"resource_access": map[string]interface{}{
+			"web": map[string]interface{}{
+				"roles": []interface{}{"editor"},
+			},
+		},
+	}
+	token, err := c.RSASign(RS256, testKeyRSA2048)
+	if err != nil {
+		t.Fatal("sign error:", err)
+	}
+
+	v := Keycloak("https://sso.example.com/", "demo", "web")
+	if want := iss + "/protocol/openid-connect/certs"; v.Keys.(*RemoteKeys).URL != want {
+		t.Errorf("got JWKS URL %q, want %q", v.Keys.(*RemoteKeys).URL, want)
+	}
+	v.Keys = &KeyRegister{RSAs: []*rsa.PublicKey{&testKeyRSA2048.PublicKey}}
+	v.Now = func() time.Time { return now }
+	got, err := v.Check(token)
+	if err != nil {
+		t.Fatal("check error:", err)
+	}
+
+	if roles := KeycloakRealmRoles(got); len(roles) != 2 || roles[0] != "offline_access" || roles[1] != "admin" {
+		t.Errorf("got realm roles %q", roles)
+	}
+	if roles := KeycloakClientRoles(got, "web"); len(roles) != 1 || roles[0] != "editor" {
+		t.Errorf("got client roles %q", roles)
+	}
+	if roles := KeycloakClientRoles(got, "other"); len(roles) != 0 {
+		t.Errorf("got roles %q for other client", roles)
+	}
+
+	v = Keycloak("https://sso.example.com", "demo", "mobile")
+	v.Keys = &KeyRegister{RSAs: []*rsa.PublicKey{&testKeyRSA2048.PublicKey}}
+	v.Now = func() time.Time { return now }
+	if _, err := v.Check(token); err != errKeycloakAZP {
+		t.Errorf("got error %v, want %v", err, errKeycloakAZP)
+	}
+
+	v = Keycloak("https://sso.example.com", "demo", "mobile", "web")
+	v.Keys = &KeyRegister{RSAs: []*rsa.PublicKey{&testKeyRSA2048.PublicKey}}
+	v.Now = func() time.Time { return now }
+	if _, err := v.Check(token); err != nil {
+		t.Error("check error with more client IDs:", err)
+	}
+}
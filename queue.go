@@ -0,0 +1,183 @@
+package jwt
+
+import (
+	"context"
+	"errors"
+	"sync"
+)
+
+// ErrQueueFull signals backpressure from a SignQueue.
+var ErrQueueFull = errors.New("jwt: sign queue full")
+
+// BatchSigner is a Signer with a bulk operation, like the batch APIs of some
+// KMS and HSM products, which amortises the round trip over many signatures.
+type BatchSigner interface {
+	Signer
+
+	// SignBatch returns the signature of each data element, in order.
+	SignBatch(ctx context.Context, data [][]byte) (sigs [][]byte, err error)
+}
+
+// SignQueue is a Signer which bounds the load on another Signer, typically one
+// with a remote key in a KMS or HSM. At most MaxConcurrent signer calls run at
+// once, with up to MaxQueue requests waiting for their turn. Requests beyond
+// that fail with ErrQueueFull immediately, rather than to accumulate latency.
+//
+// Concurrent requests for the key are coalesced when Signer implements the
+// BatchSigner interface. Each call then takes all requests waiting, up to
+// MaxBatch, such that a burst costs a few round trips only.
+//
+// Multiple goroutines may invoke methods on a SignQueue simultaneously.
+type SignQueue struct {
+	Signer Signer
+
+	// MaxConcurrent is the limit for signer calls in progress. Zero
+	// defaults to one.
+	MaxConcurrent int
+
+	// MaxQueue is the limit for requests waiting. Zero rejects any
+	// request beyond MaxConcurrent.
+	MaxQueue int
+
+	// MaxBatch is the limit for requests per SignBatch. Zero means no
+	// limit other than MaxQueue.
+	MaxBatch int
+
+	mutex   sync.Mutex
+	running int         // signer calls in progress
+	pending []*signCall // waiting in order of arrival
+}
+
+// SignCall is a request in a SignQueue.
+type signCall struct {
+	ctx   context.Context // of the requester
+	data  []byte
+	done  chan struct{} // closed when sig and err are set
+	sig   []byte
+	err   error
+	batch *signBatch // set once taken from pending
+}
+
+// SignBatch tracks the requesters of a SignBatch call.
+type signBatch struct {
+	remain int                // requesters still waiting
+	cancel context.CancelFunc // aborts the call
+}
+
+// Alg implements the Signer interface.
+func (q *SignQueue) Alg() string { return q.Signer.Alg() }
+
+// Sign implements the Signer interface. The context applies to the request
+// only, i.e., a cancel does not affect any of the other requests coalesced.
+func (q *SignQueue) Sign(ctx context.Context, data []byte) ([]byte, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	maxConcurrent := q.MaxConcurrent
+	if maxConcurrent <= 0 {
+		maxConcurrent = 1
+	}
+
+	call := &signCall{ctx: ctx, data: data, done: make(chan struct{})}
+	q.mutex.Lock()
+	if q.running >= maxConcurrent && len(q.pending) >= q.MaxQueue {
+		q.mutex.Unlock()
+		return nil, ErrQueueFull
+	}
+	q.pending = append(q.pending, call)
+	if q.running < maxConcurrent {
+		q.running++
+		go q.work()
+	}
+	q.mutex.Unlock()
+
+	select {
+	case <-call.done:
+		return call.sig, call.err
+	case <-ctx.Done():
+		q.abandon(call)
+		return nil, ctx.Err()
+	}
+}
+
+// Abandon withdraws a request on behalf of its context.
+func (q *SignQueue) abandon(call *signCall) {
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+
+	if call.batch == nil {
+		for i, c := range q.pending {
+			if c == call {
+				q.pending = append(q.pending[:i], q.pending[i+1:]...)
+				break
+			}
+		}
+		return
+	}
+	call.batch.remain--
+	if call.batch.remain == 0 {
+		call.batch.cancel()
+	}
+}
+
+// Work runs signer calls until no more requests are pending.
+func (q *SignQueue) work() {
+	batchSigner, canBatch := q.Signer.(BatchSigner)
+
+	for {
+		q.mutex.Lock()
+		n := len(q.pending)
+		if n == 0 {
+			q.running--
+			q.mutex.Unlock()
+			return
+		}
+		if !canBatch {
+			n = 1
+		} else if q.MaxBatch > 0 && n > q.MaxBatch {
+			n = q.MaxBatch
+		}
+		calls := make([]*signCall, n)
+		copy(calls, q.pending)
+		q.pending = q.pending[n:]
+
+		// A single request runs with its own context. Batches run
+		// until each of their requesters is gone.
+		ctx := calls[0].ctx
+		var cancel context.CancelFunc
+		if n > 1 {
+			ctx, cancel = context.WithCancel(context.Background())
+		} else {
+			ctx, cancel = context.WithCancel(ctx)
+		}
+		batch := &signBatch{remain: n, cancel: cancel}
+		for _, c := range calls {
+			c.batch = batch
+		}
+		q.mutex.Unlock()
+
+		if n == 1 {
+			calls[0].sig, calls[0].err = q.Signer.Sign(ctx, calls[0].data)
+		} else {
+			data := make([][]byte, n)
+			for i, c := range calls {
+				data[i] = c.data
+			}
+			sigs, err := batchSigner.SignBatch(ctx, data)
+			if err == nil && len(sigs) != n {
+				err = errors.New("jwt: batch signer returned wrong number of signatures")
+			}
+			for i, c := range calls {
+				if err != nil {
+					c.err = err
+				} else {
+					c.sig = sigs[i]
+				}
+			}
+		}
+		cancel()
+		for _, c := range calls {
+			close(c.done)
+		}
+	}
+}
@@ -0,0 +1,162 @@
+package jwt
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// BlockSigner signs after release, with a count of invocations.
+type blockSigner struct {
+	Signer
+	release chan struct{}
+	calls   int32
+}
+
+func (s *blockSigner) Sign(ctx context.Context, data []byte) ([]byte, error) {
+	atomic.AddInt32(&s.calls, 1)
+	<-s.release
+	return s.Signer.Sign(ctx, data)
+}
+
+// BatchBlockSigner is a blockSigner with batches, which are recorded by size.
+type batchBlockSigner struct {
+	blockSigner
+	mutex sync.Mutex
+	sizes []int
+}
+
+func (s *batchBlockSigner) SignBatch(ctx context.Context, data [][]byte) ([][]byte, error) {
+	s.mutex.Lock()
+	s.sizes = append(s.sizes, len(data))
+	s.mutex.Unlock()
+	<-s.release
+
+	sigs := make([][]byte, len(data))
+	for i := range data {
+		sig, err := s.Signer.Sign(ctx, data[i])
+		if err != nil {
+			return nil, err
+		}
+		sigs[i] = sig
+	}
+	return sigs, nil
+}
+
+// AwaitPending blocks until q has n requests waiting.
+func awaitPending(q *SignQueue, n int) {
+	for {
+		q.mutex.Lock()
+		pending := len(q.pending)
+		q.mutex.Unlock()
+		if pending == n {
+			return
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func TestSignQueue(t *testing.T) {
+	hmac, err := NewSigner(HS256, []byte("guest"))
+	if err != nil {
+		t.Fatal("signer error:", err)
+	}
+	s := &blockSigner{Signer: hmac, release: make(chan struct{})}
+	q := &SignQueue{Signer: s, MaxConcurrent: 1, MaxQueue: 2}
+
+	var wg sync.WaitGroup
+	sign := func(data string) {
+		defer wg.Done()
+		if _, err := q.Sign(context.Background(), []byte(data)); err != nil {
+			t.Errorf("sign %q error: %v", data, err)
+		}
+	}
+	wg.Add(2)
+	go sign("a")
+	for atomic.LoadInt32(&s.calls) == 0 {
+		time.Sleep(time.Millisecond)
+	}
+	go sign("b") // queued
+	awaitPending(q, 1)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error)
+	go func() {
+		_, err := q.Sign(ctx, []byte("c"))
+		done <- err
+	}()
+	awaitPending(q, 2)
+	if _, err := q.Sign(context.Background(), []byte("d")); err != ErrQueueFull {
+		t.Errorf("got error %v beyond queue, want %v", err, ErrQueueFull)
+	}
+	cancel()
+	if err := <-done; err != context.Canceled {
+		t.Errorf("got error %v on cancel, want %v", err, context.Canceled)
+	}
+	awaitPending(q, 1)
+
+	close(s.release)
+	wg.Wait()
+	if n := atomic.LoadInt32(&s.calls); n != 2 {
+		t.Errorf("got %d signer invocations, want 2", n)
+	}
+	if q.Alg() != HS256 {
+		t.Errorf("got algorithm %q, want %q", q.Alg(), HS256)
+	}
+}
+
+func TestSignQueueBatch(t *testing.T) {
+	hmac, err := NewSigner(HS256, []byte("guest"))
+	if err != nil {
+		t.Fatal("signer error:", err)
+	}
+	s := &batchBlockSigner{blockSigner: blockSigner{Signer: hmac, release: make(chan struct{})}}
+	q := &SignQueue{Signer: s, MaxConcurrent: 1, MaxQueue: 3}
+
+	var wg sync.WaitGroup
+	sign := func(data string) {
+		defer wg.Done()
+		sig, err := q.Sign(context.Background(), []byte(data))
+		if err != nil {
+			t.Errorf("sign %q error: %v", data, err)
+			return
+		}
+		want, _ := hmac.Sign(context.Background(), []byte(data))
+		if string(sig) != string(want) {
+			t.Errorf("sign %q got signature %x, want %x", data, sig, want)
+		}
+	}
+	wg.Add(3)
+	go sign("a")
+	for atomic.LoadInt32(&s.calls) == 0 {
+		time.Sleep(time.Millisecond)
+	}
+	go sign("b")
+	go sign("c")
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error)
+	go func() {
+		_, err := q.Sign(ctx, []byte("d"))
+		done <- err
+	}()
+	awaitPending(q, 3)
+
+	// let the batch start, and then leave it
+	s.release <- struct{}{}
+	awaitPending(q, 0)
+	cancel()
+	if err := <-done; err != context.Canceled {
+		t.Errorf("got error %v on cancel, want %v", err, context.Canceled)
+	}
+
+	close(s.release)
+	wg.Wait()
+	if n := atomic.LoadInt32(&s.calls); n != 1 {
+		t.Errorf("got %d single invocations, want 1", n)
+	}
+	if len(s.sizes) != 1 || s.sizes[0] != 3 {
+		t.Errorf("got batch sizes %d, want [3]", s.sizes)
+	}
+}
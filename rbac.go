@@ -0,0 +1,60 @@
+package jwt
+
+import "strings"
+
+// Permission grants an action on a resource. The asterisk "*" matches any
+// action or resource. A resource which ends with "/*" matches any resource
+// with the preceding prefix, e.g., "orders/*" matches "orders/7".
+type Permission struct {
+	Action   string
+	Resource string
+}
+
+func (p Permission) match(action, resource string) bool {
+	if p.Action != "*" && p.Action != action {
+		return false
+	}
+	switch {
+	case p.Resource == "*", p.Resource == resource:
+		return true
+	case strings.HasSuffix(p.Resource, "/*"):
+		return strings.HasPrefix(resource, p.Resource[:len(p.Resource)-1])
+	}
+	return false
+}
+
+// RolePermissions maps role names to their permissions.
+type RolePermissions struct {
+	// Claim names the roles claim, as a JSON array of strings, or as a
+	// space separated string. The empty string defaults to "roles".
+	Claim string
+
+	// Roles has the permissions per role name. Unknown roles have no
+	// permissions.
+	Roles map[string][]Permission
+}
+
+// Roles returns the roles from the claims.
+func (p *RolePermissions) roles(c *Claims) []string {
+	name := p.Claim
+	if name == "" {
+		name = "roles"
+	}
+	if s, ok := c.String(name); ok {
+		return strings.Fields(s)
+	}
+	return stringsFromArray(c.Set[name])
+}
+
+// Can returns whether any of the roles in the claims grants action on resource,
+// according to perms.
+func (c *Claims) Can(perms *RolePermissions, action, resource string) bool {
+	for _, role := range perms.roles(c) {
+		for _, p := range perms.Roles[role] {
+			if p.match(action, resource) {
+				return true
+			}
+		}
+	}
+	return false
+}
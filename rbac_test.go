@@ -0,0 +1,46 @@
+package jwt
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestClaimsCan(t *testing.T) {
+	perms := &RolePermissions{Roles: map[string][]Permission{
+		"viewer": {{"read", "*"}},
+		"editor": {{"write", "orders/*"}, {"write", "catalog"}},
+		"admin":  {{"*", "*"}},
+	}}
+
+	golden := []struct {
+		claims           string
+		action, resource string
+		want             bool
+	}{
+		{`{"roles":["viewer"]}`, "read", "orders/7", true},
+		{`{"roles":["viewer"]}`, "write", "orders/7", false},
+		{`{"roles":["viewer","editor"]}`, "write", "orders/7", true},
+		{`{"roles":"viewer editor"}`, "write", "catalog", true},
+		{`{"roles":["editor"]}`, "write", "orders", false},
+		{`{"roles":["editor"]}`, "write", "catalog/7", false},
+		{`{"roles":["admin"]}`, "delete", "users/1", true},
+		{`{"roles":["guest"]}`, "read", "orders/7", false},
+		{`{"groups":["admin"]}`, "read", "orders/7", false},
+		{`{"roles":[1,"admin"]}`, "read", "orders/7", true},
+	}
+	for _, gold := range golden {
+		c := &Claims{Raw: json.RawMessage(gold.claims)}
+		if err := c.applyPayload(); err != nil {
+			t.Fatal(err)
+		}
+		if got := c.Can(perms, gold.action, gold.resource); got != gold.want {
+			t.Errorf("%s: got %t for %s on %q, want %t", gold.claims, got, gold.action, gold.resource, gold.want)
+		}
+	}
+
+	perms.Claim = "groups"
+	c := &Claims{Set: map[string]interface{}{"groups": []interface{}{"admin"}}}
+	if !c.Can(perms, "read", "orders/7") {
+		t.Error("custom claim name not applied")
+	}
+}
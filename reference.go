@@ -0,0 +1,186 @@
+package jwt
+
+import (
+	"context"
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// ErrNoReference signals an unknown or expired reference token.
+var ErrNoReference = errors.New("jwt: reference token unknown")
+
+// ReferenceStore holds tokens by the TokenDigest of their handle. Implementations
+// may use shared storage, like Redis or a database, to resolve across instances.
+type ReferenceStore interface {
+	// Put saves the token under key. Records may be discarded once
+	// expired.
+	Put(ctx context.Context, key string, token []byte, expires time.Time) error
+
+	// Get returns the token under key, with nil for absence.
+	Get(ctx context.Context, key string) (token []byte, err error)
+
+	// Delete discards the token under key, if any.
+	Delete(ctx context.Context, key string) error
+}
+
+// ReferenceMemory is a ReferenceStore in memory.
+//
+// Multiple goroutines may invoke methods on a ReferenceMemory simultaneously.
+type ReferenceMemory struct {
+	// Now is the time source. Nil defaults to time.Now.
+	Now func() time.Time
+
+	mutex   sync.Mutex
+	entries map[string]reference
+	swept   time.Time
+}
+
+type reference struct {
+	token   []byte
+	expires time.Time
+}
+
+func (m *ReferenceMemory) now() time.Time {
+	if m.Now != nil {
+		return m.Now()
+	}
+	return time.Now()
+}
+
+// Put implements the ReferenceStore interface.
+func (m *ReferenceMemory) Put(ctx context.Context, key string, token []byte, expires time.Time) error {
+	now := m.now()
+
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	if m.entries == nil {
+		m.entries = make(map[string]reference)
+		m.swept = now
+	}
+	if now.Sub(m.swept) >= time.Minute {
+		for k, e := range m.entries {
+			if !now.Before(e.expires) {
+				delete(m.entries, k)
+			}
+		}
+		m.swept = now
+	}
+
+	m.entries[key] = reference{token: token, expires: expires}
+	return nil
+}
+
+// Get implements the ReferenceStore interface.
+func (m *ReferenceMemory) Get(ctx context.Context, key string) (token []byte, err error) {
+	now := m.now()
+
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	e, ok := m.entries[key]
+	if !ok || !now.Before(e.expires) {
+		return nil, nil
+	}
+	return e.token, nil
+}
+
+// Delete implements the ReferenceStore interface.
+func (m *ReferenceMemory) Delete(ctx context.Context, key string) error {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	delete(m.entries, key)
+	return nil
+}
+
+// ReferenceTokens hands out opaque handles in place of JWTs, for clients which
+// must not see the claims, like browsers and partners. The signed tokens stay
+// with the Store. Resource servers resolve the handles to the claims.
+//
+// Multiple goroutines may invoke methods on a ReferenceTokens simultaneously.
+type ReferenceTokens struct {
+	// Issuer signs the tokens. Its TTL is mandatory.
+	Issuer *Issuer
+
+	// Keys verifies the signature on resolution.
+	Keys Checker
+
+	// Store holds the tokens.
+	Store ReferenceStore
+}
+
+var errReferenceTTL = errors.New("jwt: reference token without expiry")
+
+// Issue returns a new handle for a token from Issuer.
+func (r *ReferenceTokens) Issue(ctx context.Context, subject string, extraClaims map[string]interface{}) (handle string, err error) {
+	c, s, err := r.Issuer.claims(subject, extraClaims)
+	if err != nil {
+		return "", err
+	}
+	token, err := r.Issuer.sign(ctx, c, s)
+	if err != nil {
+		return "", err
+	}
+	return r.put(ctx, token, c)
+}
+
+// Exchange returns a new handle for a token. The token must pass Keys, and it
+// must have an "exp" claim.
+func (r *ReferenceTokens) Exchange(ctx context.Context, token []byte) (handle string, err error) {
+	c, err := r.Keys.Check(token)
+	if err != nil {
+		return "", err
+	}
+	return r.put(ctx, token, c)
+}
+
+func (r *ReferenceTokens) put(ctx context.Context, token []byte, c *Claims) (handle string, err error) {
+	if c.Expires == nil {
+		return "", errReferenceTTL
+	}
+
+	var bytes [32]byte
+	if _, err := rand.Read(bytes[:]); err != nil {
+		return "", fmt.Errorf("jwt: reference handle unavailable: %w", err)
+	}
+	handle = encoding.EncodeToString(bytes[:])
+
+	err = r.Store.Put(ctx, TokenDigest([]byte(handle)), token, c.Expires.Time())
+	if err != nil {
+		return "", err
+	}
+	return handle, nil
+}
+
+// Resolve returns the token of a handle, with its claims verified by Keys.
+// Unknown, revoked and expired handles get ErrNoReference.
+func (r *ReferenceTokens) Resolve(ctx context.Context, handle string) (token []byte, c *Claims, err error) {
+	token, err = r.Store.Get(ctx, TokenDigest([]byte(handle)))
+	if err != nil {
+		return nil, nil, err
+	}
+	if token == nil {
+		return nil, nil, ErrNoReference
+	}
+
+	c, err = r.Keys.Check(token)
+	if err != nil {
+		return nil, nil, err
+	}
+	now := time.Now
+	if r.Issuer != nil && r.Issuer.Now != nil {
+		now = r.Issuer.Now
+	}
+	if !c.Valid(now()) {
+		return nil, nil, ErrNoReference
+	}
+	return token, c, nil
+}
+
+// Revoke discards the token of a handle.
+func (r *ReferenceTokens) Revoke(ctx context.Context, handle string) error {
+	return r.Store.Delete(ctx, TokenDigest([]byte(handle)))
+}
@@ -0,0 +1,83 @@
+//go:build !jwt_hmac_only
+// +build !jwt_hmac_only
+
+package jwt
+
+import (
+	"context"
+	"crypto/ed25519"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestReferenceTokens(t *testing.T) {
+	s, err := NewSigner(EdDSA, testKeyEd25519Private)
+	if err != nil {
+		t.Fatal("signer error:", err)
+	}
+	now := time.Now()
+	iss := NewIssuer(s, "")
+	iss.TTL = time.Minute
+	iss.Now = func() time.Time { return now }
+	store := new(ReferenceMemory)
+	r := &ReferenceTokens{
+		Issuer: iss,
+		Keys:   &KeyRegister{EdDSAs: []ed25519.PublicKey{testKeyEd25519Public}},
+		Store:  store,
+	}
+
+	ctx := context.Background()
+	handle, err := r.Issue(ctx, "alice", map[string]interface{}{"tier": "gold"})
+	if err != nil {
+		t.Fatal("issue error:", err)
+	}
+	if len(handle) != 43 || strings.Contains(handle, ".") {
+		t.Errorf("got handle %q, want 43 opaque characters", handle)
+	}
+	if _, ok := store.entries[handle]; ok {
+		t.Error("store holds handle as is, want digest")
+	}
+
+	token, c, err := r.Resolve(ctx, handle)
+	if err != nil {
+		t.Fatal("resolve error:", err)
+	}
+	if c.Subject != "alice" || c.Set["tier"] != "gold" || len(token) == 0 {
+		t.Errorf("got claims %+v", c)
+	}
+
+	if err := r.Revoke(ctx, handle); err != nil {
+		t.Fatal("revoke error:", err)
+	}
+	if _, _, err := r.Resolve(ctx, handle); err != ErrNoReference {
+		t.Errorf("got error %v after revoke, want %v", err, ErrNoReference)
+	}
+
+	// exchange
+	handle, err = r.Exchange(ctx, token)
+	if err != nil {
+		t.Fatal("exchange error:", err)
+	}
+	if _, _, err := r.Resolve(ctx, handle); err != nil {
+		t.Error("resolve error after exchange:", err)
+	}
+	now = now.Add(time.Hour)
+	if _, _, err := r.Resolve(ctx, handle); err != ErrNoReference {
+		t.Errorf("got error %v after expiry, want %v", err, ErrNoReference)
+	}
+}
+
+func TestReferenceTokensNoExpiry(t *testing.T) {
+	r := &ReferenceTokens{
+		Keys:  &KeyRegister{EdDSAs: []ed25519.PublicKey{testKeyEd25519Public}},
+		Store: new(ReferenceMemory),
+	}
+	token, err := new(Claims).EdDSASign(testKeyEd25519Private)
+	if err != nil {
+		t.Fatal("sign error:", err)
+	}
+	if _, err := r.Exchange(context.Background(), token); err != errReferenceTTL {
+		t.Errorf("got error %v, want %v", err, errReferenceTTL)
+	}
+}
@@ -4,7 +4,6 @@ import (
 	"bytes"
 	"crypto/ecdsa"
 	"crypto/ed25519"
-	"crypto/elliptic"
 	"crypto/hmac"
 	"crypto/rsa"
 	"crypto/x509"
@@ -36,53 +35,59 @@ type KeyRegister struct {
 // Check parses a JWT if, and only if, the signature checks out.
 // Use Claims.Valid to complete the verification.
 func (keys *KeyRegister) Check(token []byte) (*Claims, error) {
+	return keys.check(token, nil)
+}
+
+// CheckTrace is like Check, with each verification step recorded in trace.
+func (keys *KeyRegister) CheckTrace(token []byte, trace *Trace) (*Claims, error) {
+	return keys.check(token, trace)
+}
+
+func (keys *KeyRegister) check(token []byte, trace *Trace) (*Claims, error) {
 	var c Claims
+	start := trace.start()
 	lastDot, sig, alg, err := c.scan(token)
+	trace.add(StageParse, "", "", err, start)
 	if err != nil {
 		return nil, err
 	}
-	body := token[:lastDot]
+	return keys.verify(&c, alg, token[:lastDot], sig, trace)
+}
+
+// Verify completes c if, and only if, sig of body checks out with any of the
+// keys for alg.
+func (keys *KeyRegister) verify(c *Claims, alg string, body, sig []byte, trace *Trace) (*Claims, error) {
 	buf := sig[len(sig):]
 
 	switch hashAlg, err := hashLookup(alg, HMACAlgs); err.(type) {
 	case nil:
-		hMACOptions := keys.HMACs
-		if c.KeyID != "" {
-			for i, kid := range keys.HMACIDs {
-				if kid == c.KeyID && i < len(hMACOptions) {
-					hMACOptions = hMACOptions[i : i+1]
-					break
-				}
-			}
-		}
-		for _, h := range hMACOptions {
+		lo, hi := keyRange(keys.HMACIDs, c.KeyID, len(keys.HMACs))
+		for i := lo; i < hi; i++ {
+			h := keys.HMACs[i]
 			if h.alg == alg {
+				start := trace.start()
 				digest := h.digests.Get().(hash.Hash)
 				digest.Reset()
 				digest.Write(body)
 				sum := digest.Sum(buf)
 				h.digests.Put(digest)
-				if hmac.Equal(sig, sum) {
-					return &c, c.applyPayload()
+				match := hmac.Equal(sig, sum)
+				trace.addKey(keys.HMACIDs, i, nil, match, start)
+				if match {
+					return c.complete(trace)
 				}
 			}
 		}
 
-		keyOptions := keys.Secrets
-		if c.KeyID != "" {
-			for i, kid := range keys.SecretIDs {
-				if kid == c.KeyID && i < len(keyOptions) {
-					keyOptions = keyOptions[i : i+1]
-					break
-				}
-			}
-		}
-
-		for _, secret := range keyOptions {
-			digest := hmac.New(hashAlg.New, secret)
+		lo, hi = keyRange(keys.SecretIDs, c.KeyID, len(keys.Secrets))
+		for i := lo; i < hi; i++ {
+			start := trace.start()
+			digest := hmac.New(hashAlg.New, keys.Secrets[i])
 			digest.Write(body)
-			if hmac.Equal(sig, digest.Sum(buf)) {
-				return &c, c.applyPayload()
+			match := hmac.Equal(sig, digest.Sum(buf))
+			trace.addKey(keys.SecretIDs, i, nil, match, start)
+			if match {
+				return c.complete(trace)
 			}
 		}
 		return nil, ErrSigMiss
@@ -94,84 +99,38 @@ func (keys *KeyRegister) Check(token []byte) (*Claims, error) {
 	}
 
 	if alg == EdDSA {
-		keyOptions := keys.EdDSAs
-		if c.KeyID != "" {
-			for i, kid := range keys.EdDSAIDs {
-				if kid == c.KeyID && i < len(keyOptions) {
-					keyOptions = keyOptions[i : i+1]
-					break
-				}
-			}
-		}
-
-		for _, key := range keyOptions {
-			if ed25519.Verify(key, body, sig) {
-				return &c, c.applyPayload()
-			}
-		}
-		return nil, ErrSigMiss
+		return keys.checkEdDSA(c, body, sig, trace)
 	}
 
-	switch hash, err := hashLookup(alg, RSAAlgs); err.(type) {
-	case nil:
-		keyOptions := keys.RSAs
-		if c.KeyID != "" {
-			for i, kid := range keys.RSAIDs {
-				if kid == c.KeyID && i < len(keyOptions) {
-					keyOptions = keyOptions[i : i+1]
-					break
-				}
-			}
-		}
-
-		digest := hash.New()
-		digest.Write(body)
-		digestSum := digest.Sum(buf)
-		for _, key := range keyOptions {
-			if alg != "" && alg[0] == 'P' {
-				err = rsa.VerifyPSS(key, hash, digestSum, sig, &pSSOptions)
-			} else {
-				err = rsa.VerifyPKCS1v15(key, hash, digestSum, sig)
-			}
-			if err == nil {
-				return &c, c.applyPayload()
-			}
-		}
-		return nil, ErrSigMiss
-
+	switch claims, err := keys.checkRSA(c, alg, body, sig, trace); err.(type) {
 	case AlgError:
 		break // next
 	default:
-		return nil, err
+		return claims, err
 	}
 
-	switch hash, err := hashLookup(alg, ECDSAAlgs); err {
-	case nil:
-		keyOptions := keys.ECDSAs
-		if c.KeyID != "" {
-			for i, kid := range keys.ECDSAIDs {
-				if kid == c.KeyID && i < len(keyOptions) {
-					keyOptions = keyOptions[i : i+1]
-					break
-				}
-			}
-		}
+	return keys.checkECDSA(c, alg, body, sig, trace)
+}
 
-		r := new(big.Int).SetBytes(sig[:len(sig)/2])
-		s := new(big.Int).SetBytes(sig[len(sig)/2:])
-		digest := hash.New()
-		digest.Write(body)
-		digestSum := digest.Sum(buf)
-		for _, key := range keyOptions {
-			if ecdsa.Verify(key, digestSum, r, s) {
-				return &c, c.applyPayload()
+// KeyRange returns the index range of the keys to try. A key ID match, if any,
+// limits the range to one.
+func keyRange(ids []string, kid string, n int) (lo, hi int) {
+	if kid != "" {
+		for i, s := range ids {
+			if s == kid && i < n {
+				return i, i + 1
 			}
 		}
-		return nil, ErrSigMiss
-
-	default:
-		return nil, err
 	}
+	return 0, n
+}
+
+// Complete applies the payload from a verified token.
+func (c *Claims) complete(trace *Trace) (*Claims, error) {
+	start := trace.start()
+	err := c.applyPayload()
+	trace.add(StagePayload, "", "", err, start)
+	return c, err
 }
 
 var errUnencryptedPEM = errors.New("jwt: unencrypted PEM rejected due password expectation")
@@ -241,6 +200,48 @@ func (keys *KeyRegister) LoadPEM(text, password []byte) (keysAdded int, err erro
 	}
 }
 
+var errDERUnknown = errors.New("jwt: DER content not recognized as key nor certificate")
+
+// LoadDER adds the key from DER-encoded data, i.e., binary ASN.1 without PEM
+// armour. The data is either a PKIX public key, a PKCS #1 public key, a PKCS #8
+// private key, a PKCS #1 private key, an SEC 1 (EC) private key, or one or more
+// X.509 certificates.
+func (keys *KeyRegister) LoadDER(der []byte) (keysAdded int, err error) {
+	if key, err := x509.ParsePKIXPublicKey(der); err == nil {
+		return keys.addOne(key)
+	}
+	if key, err := x509.ParsePKCS8PrivateKey(der); err == nil {
+		return keys.addOne(key)
+	}
+	if key, err := x509.ParsePKCS1PrivateKey(der); err == nil {
+		return keys.addOne(key)
+	}
+	if key, err := x509.ParseECPrivateKey(der); err == nil {
+		return keys.addOne(key)
+	}
+	if key, err := x509.ParsePKCS1PublicKey(der); err == nil {
+		return keys.addOne(key)
+	}
+	if certs, err := x509.ParseCertificates(der); err == nil && len(certs) != 0 {
+		for _, c := range certs {
+			if err := keys.add(c.PublicKey, ""); err != nil {
+				return keysAdded, err
+			}
+			keysAdded++
+		}
+		return keysAdded, nil
+	}
+	return 0, errDERUnknown
+}
+
+// AddOne adds key, with the count for LoadDER.
+func (keys *KeyRegister) addOne(key interface{}) (keysAdded int, err error) {
+	if err := keys.add(key, ""); err != nil {
+		return 0, err
+	}
+	return 1, nil
+}
+
 func (keys *KeyRegister) add(key interface{}, kid string) error {
 	var i int
 	var ids *[]string
@@ -312,6 +313,63 @@ func (keys *KeyRegister) PEM() ([]byte, error) {
 	return buf.Bytes(), nil
 }
 
+// JWKS exports the (public) keys as a JSON Web Key Set, including the key ID
+// when present. Each JWK has a "use" of "sig". Keys which are bound to one
+// algorithm, i.e., EdDSA and ECDSA per curve, have their "alg" set too.
+// Elements from the Secret field, if any, are not included.
+func (keys *KeyRegister) JWKS() ([]byte, error) {
+	set := struct {
+		Keys []map[string]interface{} `json:"keys"`
+	}{Keys: []map[string]interface{}{}}
+
+	var err error
+	add := func(key interface{}, ids []string, i int, alg string) {
+		if err != nil {
+			return
+		}
+		var members []byte
+		members, err = thumbprintMembers(key)
+		if err != nil {
+			return
+		}
+		var j map[string]interface{}
+		if err = json.Unmarshal(members, &j); err != nil {
+			return
+		}
+		j["use"] = "sig"
+		if alg != "" {
+			j["alg"] = alg
+		}
+		if i < len(ids) && ids[i] != "" {
+			j["kid"] = ids[i]
+		}
+		set.Keys = append(set.Keys, j)
+	}
+
+	for i, key := range keys.ECDSAs {
+		var alg string
+		switch key.Curve.Params().Name {
+		case "P-256":
+			alg = ES256
+		case "P-384":
+			alg = ES384
+		case "P-521":
+			alg = ES512
+		}
+		add(key, keys.ECDSAIDs, i, alg)
+	}
+	for i, key := range keys.EdDSAs {
+		add(key, keys.EdDSAIDs, i, EdDSA)
+	}
+	for i, key := range keys.RSAs {
+		add(key, keys.RSAIDs, i, "")
+	}
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(&set)
+}
+
 func encodePEM(buf *bytes.Buffer, key interface{}) error {
 	der, err := x509.MarshalPKIXPublicKey(key)
 	if err != nil {
@@ -331,6 +389,9 @@ type jwk struct {
 	Crv string
 
 	K, X, Y, N, E *string
+
+	// private parameters
+	D, P, Q, DP, DQ, QI *string
 }
 
 // LoadJWK adds keys from the JSON data to the register, including the key ID,
@@ -366,82 +427,79 @@ var (
 )
 
 func (keys *KeyRegister) addJWK(j *jwk) error {
+	key, err := j.publicKey()
+	if err != nil {
+		return err
+	}
+	return keys.add(key, j.Kid)
+}
+
+// PublicKey returns the key from the public parameters, with []byte for oct.
+func (j *jwk) publicKey() (interface{}, error) {
 	// See RFC 7518, subsection 6.1
 
 	if j.Kty == nil {
-		return errJWKNoKty
+		return nil, errJWKNoKty
 	}
 	switch *j.Kty {
 	default:
-		return fmt.Errorf("jwt: JWK with unsupported key type %q", *j.Kty)
+		return nil, fmt.Errorf("jwt: JWK with unsupported key type %q", *j.Kty)
 
 	case "EC":
-		var curve elliptic.Curve
-		switch j.Crv {
-		case "P-256":
-			curve = elliptic.P256()
-		case "P-384":
-			curve = elliptic.P384()
-		case "P-521":
-			curve = elliptic.P521()
-		default:
-			return fmt.Errorf("jwt: JWK with unsupported elliptic curve %q", j.Crv)
-		}
-
-		x, err := intParam(j.X)
-		if err != nil {
-			return err
-		}
-		y, err := intParam(j.Y)
-		if err != nil {
-			return err
-		}
-
-		size := (curve.Params().BitSize + 7) / 8
-		xSize, ySize := (x.BitLen()+7)/8, (y.BitLen()+7)/8
-		if xSize != size || ySize != size {
-			return errJWKCurveSize
-		}
-
-		if !curve.IsOnCurve(x, y) {
-			return errJWKCurveMiss
-		}
-
-		keys.add(&ecdsa.PublicKey{Curve: curve, X: x, Y: y}, j.Kid)
+		return ecJWK(j)
 
 	case "RSA":
-		n, err := intParam(j.N)
-		if err != nil {
-			return err
-		}
-		e, err := intParam(j.E)
-		if err != nil {
-			return err
-		}
-
-		keys.add(&rsa.PublicKey{N: n, E: int(e.Int64())}, j.Kid)
+		return rsaJWK(j)
 
 	case "oct":
 		bytes, err := dataParam(j.K)
 		if err != nil {
-			return err
+			return nil, err
 		}
-		keys.add(bytes, j.Kid)
+		return bytes, nil
 
 	case "OKP":
-		switch j.Crv {
-		case "Ed25519":
-			bytes, err := dataParam(j.X)
-			if err != nil {
-				return err
-			}
-			keys.add(ed25519.PublicKey(bytes), j.Kid)
-		default:
-			return fmt.Errorf("jwt: JWK with unsupported elliptic curve %q", j.Crv)
-		}
+		return okpJWK(j)
 	}
+}
 
-	return nil
+var errJWKPrivate = errors.New("jwt: JWK private parameters don't match the public key")
+
+// ParseJWK reads a single JSON Web Key. The key type is one of *ecdsa.PublicKey,
+// *ecdsa.PrivateKey, ed25519.PublicKey, ed25519.PrivateKey, *rsa.PublicKey,
+// *rsa.PrivateKey or []byte for "oct" secrets. Private keys are returned when
+// the private parameters are present ("d"). RSA private keys must include the
+// primes ("p" & "q"). The private keys are ready to use with the respective
+// Sign methods, and with NewSigner.
+func ParseJWK(data []byte) (key interface{}, kid string, err error) {
+	j := new(jwk)
+	if err := json.Unmarshal(data, j); err != nil {
+		return nil, "", err
+	}
+	if j.Keys != nil {
+		return nil, "", errors.New("jwt: JWKS where JWK expected")
+	}
+	pub, err := j.publicKey()
+	if err != nil {
+		return nil, "", err
+	}
+	if j.D == nil {
+		return pub, j.Kid, nil
+	}
+
+	// See RFC 7518, subsection 6.2.2 and 6.3.2
+	switch pub := pub.(type) {
+	case *ecdsa.PublicKey:
+		key, err = ecPrivateJWK(j, pub)
+	case ed25519.PublicKey:
+		key, err = okpPrivateJWK(j, pub)
+	case *rsa.PublicKey:
+		key, err = rsaPrivateJWK(j, pub)
+	default:
+		// symmetric keys have no private parameters
+		return nil, "", fmt.Errorf("jwt: JWK with private parameter for key type %q", *j.Kty)
+	}
+	return key, j.Kid, err
 }
 
 func dataParam(p *string) ([]byte, error) {
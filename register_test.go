@@ -1,3 +1,6 @@
+//go:build !jwt_hmac_only && !jwt_no_rsa && !jwt_no_ecdsa
+// +build !jwt_hmac_only,!jwt_no_rsa,!jwt_no_ecdsa
+
 package jwt
 
 import (
@@ -11,6 +14,7 @@ import (
 	"crypto/x509"
 	"encoding/json"
 	"errors"
+	"fmt"
 	"math/big"
 	"testing"
 )
@@ -271,6 +275,53 @@ gLxOTyMAdriP4NLRkuu+9Idty3qmEizRC0N81j84E213/LuqLqnsrgfyiw==
 	}
 }
 
+func TestKeyRegisterLoadDER(t *testing.T) {
+	pkix, err := x509.MarshalPKIXPublicKey(testKeyEd25519Public)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pkcs8, err := x509.MarshalPKCS8PrivateKey(testKeyEC384)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sec1, err := x509.MarshalECPrivateKey(testKeyEC256)
+	if err != nil {
+		t.Fatal(err)
+	}
+	template := &x509.Certificate{SerialNumber: big.NewInt(1)}
+	cert, err := x509.CreateCertificate(rand.Reader, template, template, &testKeyRSA2048.PublicKey, testKeyRSA2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	golden := []struct {
+		der                  []byte
+		ecdsas, eddsas, rsas int
+	}{
+		{pkix, 0, 1, 0},
+		{pkcs8, 1, 0, 0},
+		{sec1, 1, 0, 0},
+		{x509.MarshalPKCS1PrivateKey(testKeyRSA1024), 0, 0, 1},
+		{x509.MarshalPKCS1PublicKey(&testKeyRSA1024.PublicKey), 0, 0, 1},
+		{cert, 0, 0, 1},
+	}
+	for i, gold := range golden {
+		var keys KeyRegister
+		n, err := keys.LoadDER(gold.der)
+		if err != nil {
+			t.Errorf("%d: load error: %s", i, err)
+			continue
+		}
+		if n != 1 || len(keys.ECDSAs) != gold.ecdsas || len(keys.EdDSAs) != gold.eddsas || len(keys.RSAs) != gold.rsas {
+			t.Errorf("%d: got %d keys added with %d ECDSA, %d EdDSA and %d RSA", i, n, len(keys.ECDSAs), len(keys.EdDSAs), len(keys.RSAs))
+		}
+	}
+
+	if _, err := new(KeyRegister).LoadDER([]byte("garbage")); err != errDERUnknown {
+		t.Errorf("got error %v, want %v", err, errDERUnknown)
+	}
+}
+
 func TestKeyRegisterLoadUnkownType(t *testing.T) {
 	n, err := new(KeyRegister).LoadPEM([]byte(`
 -----BEGIN SPECIAL KEY-----
@@ -729,6 +780,141 @@ func TestKeyRegisterLoadJWKs(t *testing.T) {
 	}
 }
 
+func TestKeyRegisterJWKS(t *testing.T) {
+	for _, gold := range GoldenJWKs {
+		keys := new(KeyRegister)
+		if _, err := keys.LoadJWK([]byte(gold.Serial)); err != nil {
+			t.Fatal("load error:", err)
+		}
+		jwks, err := keys.JWKS()
+		if err != nil {
+			t.Error("JWKS encoding error:", err)
+			continue
+		}
+
+		again := new(KeyRegister)
+		if _, err := again.LoadJWK(jwks); err != nil {
+			t.Errorf("reload error for %s: %s", jwks, err)
+			continue
+		}
+		pem, err := again.PEM()
+		if err != nil {
+			t.Error("PEM encoding error:", err)
+			continue
+		}
+		if string(pem) != gold.PEM {
+			t.Errorf("got PEM %q after JWKS round trip,\nwant %q", pem, gold.PEM)
+		}
+	}
+
+	keys := new(KeyRegister)
+	if _, err := keys.LoadJWK([]byte(testJWKSEd25519)); err != nil {
+		t.Fatal("load error:", err)
+	}
+	keys.Secrets = [][]byte{[]byte("guest")}
+	jwks, err := keys.JWKS()
+	if err != nil {
+		t.Fatal("JWKS encoding error:", err)
+	}
+	const want = `{"keys":[{"alg":"EdDSA","crv":"Ed25519","kid":"rfc","kty":"OKP","use":"sig","x":"11qYAYKxCrfVS_7TyWQHOg7hcvPapiMlrwIaaPcHURo"}]}`
+	if string(jwks) != want {
+		t.Errorf("got JWKS %s, want %s", jwks, want)
+	}
+}
+
+func TestParseJWKPrivate(t *testing.T) {
+	// RFC 8037, appendix A.1
+	key, kid, err := ParseJWK([]byte(`{"kty":"OKP","crv":"Ed25519","kid":"a",
+		"d":"nWGxne_9WmC6hEr0kuwsxERJxWl7MmkZcDusAxyuf2A",
+		"x":"11qYAYKxCrfVS_7TyWQHOg7hcvPapiMlrwIaaPcHURo"}`))
+	if err != nil {
+		t.Fatal("Ed25519 parse error:", err)
+	}
+	if _, ok := key.(ed25519.PrivateKey); !ok || kid != "a" {
+		t.Errorf("got %T with key ID %q, want ed25519.PrivateKey with a", key, kid)
+	}
+
+	// RFC 7517, appendix A.2
+	key, _, err = ParseJWK([]byte(`{"kty":"EC","crv":"P-256",
+		"x":"MKBCTNIcKUSDii11ySs3526iDZ8AiTo7Tu6KPAqv7D4",
+		"y":"4Etl6SRW2YiLUrN5vfvVHuhp7x8PxltmWWlbbM4IFyM",
+		"d":"870MB6gfuTJ4HtUnUvYMyJpr5eUZNP4Bk43bVdj3eAE"}`))
+	if err != nil {
+		t.Fatal("EC parse error:", err)
+	}
+	ecKey, ok := key.(*ecdsa.PrivateKey)
+	if !ok {
+		t.Fatalf("got %T, want *ecdsa.PrivateKey", key)
+	}
+	token, err := new(Claims).ECDSASign(ES256, ecKey)
+	if err != nil {
+		t.Fatal("sign error:", err)
+	}
+	if _, err := ECDSACheck(token, &ecKey.PublicKey); err != nil {
+		t.Error("check error:", err)
+	}
+
+	k := testKeyRSA1024
+	b64 := func(i *big.Int) string { return encoding.EncodeToString(i.Bytes()) }
+	rsaJWK := fmt.Sprintf(`{"kty":"RSA","n":%q,"e":%q,"d":%q,"p":%q,"q":%q,"dp":%q,"dq":%q,"qi":%q}`,
+		b64(k.N), b64(big.NewInt(int64(k.E))), b64(k.D), b64(k.Primes[0]), b64(k.Primes[1]),
+		b64(k.Precomputed.Dp), b64(k.Precomputed.Dq), b64(k.Precomputed.Qinv))
+	key, _, err = ParseJWK([]byte(rsaJWK))
+	if err != nil {
+		t.Fatal("RSA parse error:", err)
+	}
+	rsaKey, ok := key.(*rsa.PrivateKey)
+	if !ok {
+		t.Fatalf("got %T, want *rsa.PrivateKey", key)
+	}
+	token, err = new(Claims).RSASign(RS256, rsaKey)
+	if err != nil {
+		t.Fatal("sign error:", err)
+	}
+	if _, err := RSACheck(token, &k.PublicKey); err != nil {
+		t.Error("check error:", err)
+	}
+
+	key, _, err = ParseJWK([]byte(`{"kty":"oct","k":"Z3Vlc3Q"}`))
+	if err != nil {
+		t.Fatal("oct parse error:", err)
+	}
+	if secret, ok := key.([]byte); !ok || string(secret) != "guest" {
+		t.Errorf("got %T %q, want []byte guest", key, key)
+	}
+
+	key, _, err = ParseJWK([]byte(testJWKSEd25519[9 : len(testJWKSEd25519)-2]))
+	if err != nil {
+		t.Fatal("public parse error:", err)
+	}
+	if _, ok := key.(ed25519.PublicKey); !ok {
+		t.Errorf("got %T, want ed25519.PublicKey", key)
+	}
+}
+
+func TestParseJWKPrivateErrors(t *testing.T) {
+	golden := []struct {
+		jwk  string
+		want error
+	}{
+		// d of RFC 8037 with other x
+		{`{"kty":"OKP","crv":"Ed25519","d":"nWGxne_9WmC6hEr0kuwsxERJxWl7MmkZcDusAxyuf2A","x":"AAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAA"}`, errJWKPrivate},
+		{`{"kty":"EC","crv":"P-256","x":"MKBCTNIcKUSDii11ySs3526iDZ8AiTo7Tu6KPAqv7D4","y":"4Etl6SRW2YiLUrN5vfvVHuhp7x8PxltmWWlbbM4IFyM","d":"AQ"}`, errJWKPrivate},
+		{`{"kty":"RSA","n":"AQ","e":"AQAB","d":"AQ"}`, errJWKParam},
+	}
+	for _, gold := range golden {
+		if _, _, err := ParseJWK([]byte(gold.jwk)); err != gold.want {
+			t.Errorf("got error %v for %s, want %v", err, gold.jwk, gold.want)
+		}
+	}
+	if _, _, err := ParseJWK([]byte(`{"kty":"oct","k":"Z3Vlc3Q","d":"AQ"}`)); err == nil {
+		t.Error("no error for oct with private parameter")
+	}
+	if _, _, err := ParseJWK([]byte(testJWKSEd25519)); err == nil {
+		t.Error("no error for JWKS")
+	}
+}
+
 var GoldenJWKErrors = []struct {
 	JWK string
 	Err error
@@ -0,0 +1,387 @@
+package jwt
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Remote key defaults.
+const (
+	DefaultMinRefresh = time.Minute
+	DefaultMaxRefresh = 24 * time.Hour
+)
+
+// JWKSLimit is the maximum number of bytes read from a JWKS response.
+const jwksLimit = 1 << 20
+
+// RemoteKeys is a KeyRegister loaded from a JWKS (JSON Web Key Set) URL.
+// The HTTP Cache-Control max-age directive determines the refresh interval,
+// within MinRefresh and MaxRefresh. Signature mismatches cause a refresh too,
+// to pick up on key rotation, yet no more than once per MinRefresh.
+//
+// Multiple goroutines may invoke methods on a RemoteKeys simultaneously.
+type RemoteKeys struct {
+	// URL locates the JWKS.
+	URL string
+
+	// Mirrors locate copies of the JWKS, which are tried in order when
+	// the primary location, either URL or discovered, fails.
+	Mirrors []string
+
+	// Issuer is used to resolve the JWKS location with OpenID Connect
+	// Discovery when URL is empty.
+	Issuer string
+
+	// Client is used for the HTTP requests. Nil defaults to
+	// http.DefaultClient.
+	Client *http.Client
+
+	// MinRefresh is the minimum amount of time between two requests.
+	// Zero defaults to DefaultMinRefresh.
+	MinRefresh time.Duration
+
+	// MaxRefresh is the maximum amount of time between two requests.
+	// Zero defaults to DefaultMaxRefresh.
+	MaxRefresh time.Duration
+
+	// StaleWhileRevalidate is the amount of time after expiry in which
+	// the keys remain in use while a refresh runs in the background.
+	// Zero disables background refreshes.
+	StaleWhileRevalidate time.Duration
+
+	mutex        sync.Mutex
+	url          string       // URL or discovered
+	keys         *KeyRegister // nil before first fetch
+	fetched      time.Time    // last request attempt
+	expires      time.Time    // next request due
+	revalidating bool         // background refresh pending
+}
+
+// Check parses a JWT if, and only if, the signature checks out.
+// Use Claims.Valid to complete the verification.
+func (r *RemoteKeys) Check(token []byte) (*Claims, error) {
+	return r.CheckTrace(token, nil)
+}
+
+// Keys returns the current register. Any expired content is refreshed first.
+// The register must not be modified.
+func (r *RemoteKeys) Keys(ctx context.Context) (*KeyRegister, error) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	now := time.Now()
+	if r.keys != nil && (now.Before(r.expires) || !r.remote()) {
+		return r.keys, nil
+	}
+	if r.keys != nil && now.Before(r.expires.Add(r.StaleWhileRevalidate)) {
+		if !r.revalidating && now.Sub(r.fetched) >= r.minRefresh() {
+			r.revalidating = true
+			r.fetched = now
+			go r.revalidate(r.urls())
+		}
+		return r.keys, nil
+	}
+	if err := r.fetch(ctx); err != nil {
+		if r.keys == nil {
+			return nil, err
+		}
+		// stale keys remain in use until MinRefresh
+	}
+	return r.keys, nil
+}
+
+// Bundle installs the keys from a JWKS which ships with the executable, e.g.,
+// with a go:embed directive, as follows.
+//
+//	//go:embed jwks.json
+//	var bundledJWKS []byte
+//
+// The bundled keys remain in use until a fetch succeeds. Failed fetches retry
+// no sooner than MinRefresh. RemoteKeys without URL, Mirrors and Issuer don't
+// fetch at all, i.e., the bundled keys are final.
+func (r *RemoteKeys) Bundle(jwks []byte) error {
+	keys := new(KeyRegister)
+	if _, err := keys.LoadJWK(jwks); err != nil {
+		return fmt.Errorf("jwt: bundled JWKS unusable: %w", err)
+	}
+
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	r.keys = keys
+	r.expires = time.Time{} // fetch on first use
+	return nil
+}
+
+// ValidUntil returns the moment in time at which the current keys are due for
+// a refresh, with the zero value for none fetched yet.
+func (r *RemoteKeys) ValidUntil() time.Time {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	if r.keys == nil {
+		return time.Time{}
+	}
+	return r.expires
+}
+
+// Refresh fetches the keys, regardless of expiry.
+func (r *RemoteKeys) Refresh(ctx context.Context) error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	return r.fetch(ctx)
+}
+
+// Refresh fetches the keys when the register still matches current, and when
+// the previous request is at least MinRefresh ago.
+func (r *RemoteKeys) refresh(ctx context.Context, current *KeyRegister) (*KeyRegister, error) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	if r.keys == current && r.remote() && time.Since(r.fetched) >= r.minRefresh() {
+		if err := r.fetch(ctx); err != nil {
+			return nil, err
+		}
+	}
+	return r.keys, nil
+}
+
+func (r *RemoteKeys) minRefresh() time.Duration {
+	if r.MinRefresh > 0 {
+		return r.MinRefresh
+	}
+	return DefaultMinRefresh
+}
+
+func (r *RemoteKeys) maxRefresh() time.Duration {
+	if r.MaxRefresh > 0 {
+		return r.MaxRefresh
+	}
+	return DefaultMaxRefresh
+}
+
+// Fetch updates the register. The caller must hold the mutex.
+func (r *RemoteKeys) fetch(ctx context.Context) error {
+	r.fetched = time.Now()
+	// retry failures no sooner than MinRefresh
+	r.expires = r.fetched.Add(r.minRefresh())
+
+	var discoveryErr error
+	if r.URL == "" && r.Issuer != "" && r.url == "" {
+		config, err := FetchOpenIDConfiguration(ctx, r.Client, r.Issuer)
+		if err != nil {
+			// mirrors may work still
+			discoveryErr = err
+		} else {
+			r.url = config.JWKSURI
+		}
+	}
+
+	keys, maxAge, err := fetchJWKSFailover(ctx, r.Client, r.urls())
+	if err != nil {
+		if discoveryErr != nil {
+			if err == errNoJWKSURL {
+				return discoveryErr
+			}
+			return joinErrors([]error{discoveryErr, err})
+		}
+		return err
+	}
+	r.apply(keys, maxAge)
+	return nil
+}
+
+// Revalidate fetches the keys without holding the mutex.
+func (r *RemoteKeys) revalidate(urls []string) {
+	keys, maxAge, err := fetchJWKSFailover(context.Background(), r.Client, urls)
+
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	r.revalidating = false
+	if err == nil {
+		r.apply(keys, maxAge)
+	}
+}
+
+// Remote returns whether any JWKS location is configured.
+func (r *RemoteKeys) remote() bool {
+	return r.URL != "" || r.Issuer != "" || len(r.Mirrors) != 0
+}
+
+// URLs returns the JWKS locations in order of preference. The primary location
+// is absent when unknown, i.e., when discovery failed.
+func (r *RemoteKeys) urls() []string {
+	url := r.URL
+	if url == "" {
+		url = r.url
+	}
+	urls := make([]string, 0, 1+len(r.Mirrors))
+	if url != "" {
+		urls = append(urls, url)
+	}
+	for _, mirror := range r.Mirrors {
+		if mirror != "" {
+			urls = append(urls, mirror)
+		}
+	}
+	return urls
+}
+
+// Apply installs a fetch result. The caller must hold the mutex.
+func (r *RemoteKeys) apply(keys *KeyRegister, maxAge time.Duration) {
+	switch {
+	case maxAge < r.minRefresh():
+		maxAge = r.minRefresh()
+	case maxAge > r.maxRefresh():
+		maxAge = r.maxRefresh()
+	}
+	r.keys = keys
+	r.expires = r.fetched.Add(maxAge)
+}
+
+var errNoJWKSURL = errors.New("jwt: no JWKS URL")
+
+// FetchJWKSFailover returns the key set from the first of urls which works. The
+// error has each location which failed, in order.
+func fetchJWKSFailover(ctx context.Context, client *http.Client, urls []string) (keys *KeyRegister, maxAge time.Duration, err error) {
+	if len(urls) == 0 {
+		return nil, 0, errNoJWKSURL
+	}
+	errs := make([]error, 0, len(urls))
+	for _, url := range urls {
+		keys, maxAge, err = fetchJWKS(ctx, client, url)
+		if err == nil {
+			return keys, maxAge, nil
+		}
+		errs = append(errs, err)
+	}
+	return nil, 0, joinErrors(errs)
+}
+
+// JoinErrors combines errs, of which the first one remains available for
+// errors.Is and errors.As.
+func joinErrors(errs []error) error {
+	if len(errs) == 1 {
+		return errs[0]
+	}
+	msgs := make([]string, len(errs)-1)
+	for i, err := range errs[1:] {
+		msgs[i] = err.Error()
+	}
+	return fmt.Errorf("%w; %s", errs[0], strings.Join(msgs, "; "))
+}
+
+// FetchJWKS returns the key set from URL, including the max-age from the
+// Cache-Control header, if any.
+func fetchJWKS(ctx context.Context, client *http.Client, url string) (keys *KeyRegister, maxAge time.Duration, err error) {
+	if url == "" {
+		return nil, 0, errNoJWKSURL
+	}
+	if client == nil {
+		client = http.DefaultClient
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, 0, err
+	}
+	req.Header.Set("Accept", "application/jwk-set+json, application/json")
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		io.Copy(ioutil.Discard, io.LimitReader(resp.Body, jwksLimit))
+		return nil, 0, fmt.Errorf("jwt: JWKS %q got HTTP %q", url, resp.Status)
+	}
+	body, err := ioutil.ReadAll(io.LimitReader(resp.Body, jwksLimit))
+	if err != nil {
+		return nil, 0, fmt.Errorf("jwt: JWKS %q unavailable: %w", url, err)
+	}
+
+	keys = new(KeyRegister)
+	if _, err := keys.LoadJWK(body); err != nil {
+		return nil, 0, fmt.Errorf("jwt: JWKS %q unusable: %w", url, err)
+	}
+	return keys, cacheMaxAge(resp.Header), nil
+}
+
+// CacheMaxAge returns the max-age directive from the Cache-Control header,
+// with zero for absence and zero for no-cache or no-store.
+func cacheMaxAge(h http.Header) time.Duration {
+	var maxAge time.Duration
+	for _, directive := range strings.Split(h.Get("Cache-Control"), ",") {
+		directive = strings.ToLower(strings.TrimSpace(directive))
+		switch {
+		case directive == "no-cache", directive == "no-store":
+			return 0
+		case strings.HasPrefix(directive, "max-age="):
+			seconds, err := strconv.ParseUint(directive[len("max-age="):], 10, 32)
+			if err == nil {
+				maxAge = time.Duration(seconds) * time.Second
+			}
+		}
+	}
+	return maxAge
+}
+
+// OpenIDConfiguration is the provider metadata from “OpenID Connect Discovery
+// 1.0”, section 3. Only the fields in use by this package are present.
+type OpenIDConfiguration struct {
+	Issuer                string   `json:"issuer"`
+	JWKSURI               string   `json:"jwks_uri"`
+	AuthorizationEndpoint string   `json:"authorization_endpoint,omitempty"`
+	TokenEndpoint         string   `json:"token_endpoint,omitempty"`
+	UserInfoEndpoint      string   `json:"userinfo_endpoint,omitempty"`
+	IDTokenSigningAlgs    []string `json:"id_token_signing_alg_values_supported,omitempty"`
+}
+
+// FetchOpenIDConfiguration resolves the provider metadata of an issuer. The
+// client is optional, with nil for http.DefaultClient.
+func FetchOpenIDConfiguration(ctx context.Context, client *http.Client, issuer string) (*OpenIDConfiguration, error) {
+	if client == nil {
+		client = http.DefaultClient
+	}
+
+	url := strings.TrimSuffix(issuer, "/") + "/.well-known/openid-configuration"
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", "application/json")
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		io.Copy(ioutil.Discard, io.LimitReader(resp.Body, jwksLimit))
+		return nil, fmt.Errorf("jwt: OpenID configuration %q got HTTP %q", url, resp.Status)
+	}
+	config := new(OpenIDConfiguration)
+	if err := json.NewDecoder(io.LimitReader(resp.Body, jwksLimit)).Decode(config); err != nil {
+		return nil, fmt.Errorf("jwt: OpenID configuration %q unusable: %w", url, err)
+	}
+
+	// “The issuer value returned MUST be identical to the Issuer URL that
+	// was used as the prefix to /.well-known/openid-configuration to
+	// retrieve the configuration information.”
+	// — “OpenID Connect Discovery 1.0”, section 4.3
+	if config.Issuer != issuer {
+		return nil, fmt.Errorf("jwt: OpenID configuration %q has issuer %q", url, config.Issuer)
+	}
+	if config.JWKSURI == "" {
+		return nil, fmt.Errorf("jwt: OpenID configuration %q has no jwks_uri", url)
+	}
+	return config, nil
+}
@@ -0,0 +1,256 @@
+//go:build !jwt_hmac_only
+// +build !jwt_hmac_only
+
+package jwt
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// JWK from RFC 8037, appendix A.2
+const testJWKSEd25519 = `{"keys":[{"kty":"OKP","crv":"Ed25519","kid":"rfc","x":"11qYAYKxCrfVS_7TyWQHOg7hcvPapiMlrwIaaPcHURo"}]}`
+
+func TestRemoteKeys(t *testing.T) {
+	token, err := new(Claims).EdDSASign(testKeyEd25519Private)
+	if err != nil {
+		t.Fatal("sign error:", err)
+	}
+
+	var reqCount int32
+	var jwks atomic.Value
+	jwks.Store(`{"keys":[]}`)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&reqCount, 1)
+		w.Header().Set("Cache-Control", "public, max-age=3600")
+		w.Write([]byte(jwks.Load().(string)))
+	}))
+	defer srv.Close()
+
+	keys := RemoteKeys{URL: srv.URL, MinRefresh: time.Hour}
+	if !keys.ValidUntil().IsZero() {
+		t.Error("valid until set before fetch")
+	}
+	if _, err := keys.Check(token); err != ErrSigMiss {
+		t.Errorf("got error %v, want %v", err, ErrSigMiss)
+	}
+	if n := atomic.LoadInt32(&reqCount); n != 1 {
+		t.Errorf("got %d HTTP requests, want 1 within MinRefresh", n)
+	}
+
+	// rotate
+	jwks.Store(testJWKSEd25519)
+	keys.MinRefresh = time.Nanosecond
+	if _, err := keys.Check(token); err != nil {
+		t.Error("check error after rotation:", err)
+	}
+	if n := atomic.LoadInt32(&reqCount); n != 2 {
+		t.Errorf("got %d HTTP requests, want 2", n)
+	}
+	if _, err := keys.Check(token); err != nil {
+		t.Error("check error:", err)
+	}
+	if n := atomic.LoadInt32(&reqCount); n != 2 {
+		t.Errorf("got %d HTTP requests, want 2 within max-age", n)
+	}
+	if d := time.Until(keys.ValidUntil()); d <= 59*time.Minute || d > time.Hour {
+		t.Errorf("got valid until %s from now, want max-age", d)
+	}
+}
+
+func TestRemoteKeysError(t *testing.T) {
+	srv := httptest.NewServer(http.NotFoundHandler())
+	defer srv.Close()
+
+	keys := RemoteKeys{URL: srv.URL}
+	_, err := keys.Check([]byte("eyJhbGciOiJFZERTQSJ9.e30.e30"))
+	if want := `jwt: JWKS "` + srv.URL + `" got HTTP "404 Not Found"`; err == nil || err.Error() != want {
+		t.Errorf("got error %v, want %s", err, want)
+	}
+
+	if _, err := new(RemoteKeys).Check(nil); err != errNoJWKSURL {
+		t.Errorf("got error %v, want %v", err, errNoJWKSURL)
+	}
+}
+
+func TestRemoteKeysMirrors(t *testing.T) {
+	down := httptest.NewServer(http.NotFoundHandler())
+	defer down.Close()
+	up := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(testJWKSEd25519))
+	}))
+	defer up.Close()
+
+	token, err := new(Claims).EdDSASign(testKeyEd25519Private)
+	if err != nil {
+		t.Fatal("sign error:", err)
+	}
+	keys := RemoteKeys{URL: down.URL, Mirrors: []string{down.URL + "/other", up.URL}}
+	if _, err := keys.Check(token); err != nil {
+		t.Error("check error with mirror:", err)
+	}
+
+	keys = RemoteKeys{URL: down.URL, Mirrors: []string{down.URL + "/other"}}
+	_, err = keys.Check(token)
+	if want := `jwt: JWKS "` + down.URL + `" got HTTP "404 Not Found"; jwt: JWKS "` + down.URL + `/other" got HTTP "404 Not Found"`; err == nil || err.Error() != want {
+		t.Errorf("got error %v, want %s", err, want)
+	}
+
+	// discovery down
+	keys = RemoteKeys{Issuer: down.URL, Mirrors: []string{up.URL}}
+	if _, err := keys.Check(token); err != nil {
+		t.Error("check error with mirror on discovery failure:", err)
+	}
+	keys = RemoteKeys{Issuer: down.URL, Mirrors: []string{down.URL + "/other"}}
+	_, err = keys.Check(token)
+	if err == nil || !strings.Contains(err.Error(), "openid-configuration") || !strings.Contains(err.Error(), down.URL+"/other") {
+		t.Errorf("got error %v, want both discovery and mirror failure", err)
+	}
+}
+
+func TestRemoteKeysStaleWhileRevalidate(t *testing.T) {
+	var reqCount int32
+	release := make(chan struct{})
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(&reqCount, 1) > 1 {
+			<-release
+		}
+		w.Write([]byte(testJWKSEd25519))
+	}))
+	defer srv.Close()
+
+	keys := RemoteKeys{URL: srv.URL, MinRefresh: time.Nanosecond, StaleWhileRevalidate: time.Hour}
+	first, err := keys.Keys(context.Background())
+	if err != nil {
+		t.Fatal("initial fetch error:", err)
+	}
+	time.Sleep(time.Millisecond)
+
+	// background refresh blocks on release
+	got, err := keys.Keys(context.Background())
+	if err != nil {
+		t.Fatal("stale fetch error:", err)
+	}
+	if got != first {
+		t.Error("stale keys not served")
+	}
+	close(release)
+
+	for deadline := time.Now().Add(time.Second); ; time.Sleep(time.Millisecond) {
+		keys.mutex.Lock()
+		current, pending := keys.keys, keys.revalidating
+		keys.mutex.Unlock()
+		if current != first && !pending {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("keys not revalidated in background")
+		}
+	}
+	if n := atomic.LoadInt32(&reqCount); n != 2 {
+		t.Errorf("got %d HTTP requests, want 2", n)
+	}
+}
+
+func TestCacheMaxAge(t *testing.T) {
+	golden := []struct {
+		header string
+		want   time.Duration
+	}{
+		{"", 0},
+		{"max-age=60", time.Minute},
+		{"public, Max-Age=3600, must-revalidate", time.Hour},
+		{"max-age=60, no-cache", 0},
+		{"max-age=bad", 0},
+	}
+	for _, gold := range golden {
+		h := make(http.Header)
+		h.Set("Cache-Control", gold.header)
+		if got := cacheMaxAge(h); got != gold.want {
+			t.Errorf("got %s for %q, want %s", got, gold.header, gold.want)
+		}
+	}
+}
+
+func TestRemoteKeysDiscovery(t *testing.T) {
+	token, err := new(Claims).EdDSASign(testKeyEd25519Private)
+	if err != nil {
+		t.Fatal("sign error:", err)
+	}
+
+	var srv *httptest.Server
+	srv = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/tenant/.well-known/openid-configuration":
+			fmt.Fprintf(w, `{"issuer":%q,"jwks_uri":%q}`, srv.URL+"/tenant/", srv.URL+"/keys")
+		case "/keys":
+			w.Write([]byte(testJWKSEd25519))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer srv.Close()
+
+	keys := RemoteKeys{Issuer: srv.URL + "/tenant/"}
+	if _, err := keys.Check(token); err != nil {
+		t.Error("check error:", err)
+	}
+
+	keys = RemoteKeys{Issuer: srv.URL + "/tenant"}
+	_, err = keys.Check(token)
+	if want := fmt.Sprintf("jwt: OpenID configuration %q has issuer %q", srv.URL+"/tenant/.well-known/openid-configuration", srv.URL+"/tenant/"); err == nil || err.Error() != want {
+		t.Errorf("got error %v, want %s", err, want)
+	}
+}
+
+func TestRemoteKeysBundle(t *testing.T) {
+	token, err := new(Claims).EdDSASign(testKeyEd25519Private)
+	if err != nil {
+		t.Fatal("sign error:", err)
+	}
+
+	var static RemoteKeys
+	if err := static.Bundle([]byte(testJWKSEd25519)); err != nil {
+		t.Fatal("bundle error:", err)
+	}
+	if _, err := static.Check(token); err != nil {
+		t.Error("check error with bundled keys:", err)
+	}
+	if _, err := static.Check([]byte("eyJhbGciOiJFZERTQSJ9.e30.e30")); err != ErrSigMiss {
+		t.Errorf("got error %v, want %v", err, ErrSigMiss)
+	}
+
+	if err := static.Bundle([]byte(`{"keys":[{}]}`)); err == nil {
+		t.Error("bundle of malformed JWKS got no error")
+	}
+
+	// air-gapped
+	down := httptest.NewServer(http.NotFoundHandler())
+	defer down.Close()
+	offline := RemoteKeys{URL: down.URL, MinRefresh: time.Hour}
+	if err := offline.Bundle([]byte(testJWKSEd25519)); err != nil {
+		t.Fatal("bundle error:", err)
+	}
+	if _, err := offline.Check(token); err != nil {
+		t.Error("check error with bundled keys on failed fetch:", err)
+	}
+
+	// rotation
+	up := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"keys":[]}`))
+	}))
+	defer up.Close()
+	online := RemoteKeys{URL: up.URL}
+	if err := online.Bundle([]byte(testJWKSEd25519)); err != nil {
+		t.Fatal("bundle error:", err)
+	}
+	if _, err := online.Check(token); err != ErrSigMiss {
+		t.Errorf("got error %v, want %v after remote rotation", err, ErrSigMiss)
+	}
+}
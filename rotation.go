@@ -0,0 +1,166 @@
+package jwt
+
+import (
+	"context"
+	"crypto"
+	"errors"
+	"time"
+)
+
+// Rotation schedules signing keys. Each key is generated and published first,
+// then activated after the Propagation delay, so that verifiers have the key
+// before any token uses it. The key which was active before is retired at
+// that point, and it is unpublished after the Overlap period.
+//
+// The first key activates immediately. Keys remain in memory only, unless
+// persisted by OnGenerate.
+type Rotation struct {
+	// Alg is the signature algorithm for each key.
+	Alg string
+
+	// Generate returns a new private key for Alg.
+	Generate func() (crypto.Signer, error)
+
+	// Interval is the amount of time between two key generations.
+	Interval time.Duration
+
+	// Propagation is the amount of time between the publication and the
+	// activation of a key. Use at least the refresh interval of verifiers.
+	Propagation time.Duration
+
+	// Overlap is the amount of time a key remains published after its
+	// retirement. Use at least the time to live of tokens.
+	Overlap time.Duration
+
+	// OnGenerate, when not nil, receives each new key, e.g., to persist.
+	OnGenerate func(key crypto.Signer, kid string) error
+
+	// OnPublish, when not nil, receives the public keys on each change,
+	// e.g., to serve as a JWKS. The register must not be modified.
+	OnPublish func(keys *KeyRegister) error
+
+	// OnActivate receives the signing key on each activation, e.g., for
+	// Issuer.SetSigner.
+	OnActivate func(s Signer, kid string) error
+
+	keys   []*rotationKey // in order of generation
+	active *rotationKey
+}
+
+type rotationKey struct {
+	signer    Signer
+	public    crypto.PublicKey
+	kid       string
+	created   time.Time
+	activates time.Time
+	retires   time.Time // zero when in use
+}
+
+var errRotationConfig = errors.New("jwt: rotation needs Generate, OnActivate and Interval")
+
+// Run executes the schedule until ctx is done. The return is either the first
+// error from a callback, or the one from ctx.
+func (r *Rotation) Run(ctx context.Context) error {
+	if r.Generate == nil || r.OnActivate == nil || r.Interval <= 0 {
+		return errRotationConfig
+	}
+	for {
+		next, err := r.step(time.Now())
+		if err != nil {
+			return err
+		}
+		timer := time.NewTimer(time.Until(next))
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return ctx.Err()
+		case <-timer.C:
+			break
+		}
+	}
+}
+
+// Step applies each transition due at now. The return is the moment in time of
+// the next transition.
+func (r *Rotation) step(now time.Time) (next time.Time, err error) {
+	var changed bool
+
+	// generate
+	if len(r.keys) == 0 || !now.Before(r.keys[len(r.keys)-1].created.Add(r.Interval)) {
+		key, err := r.Generate()
+		if err != nil {
+			return time.Time{}, err
+		}
+		s, err := NewSigner(r.Alg, key)
+		if err != nil {
+			return time.Time{}, err
+		}
+		kid, err := Thumbprint(key.Public())
+		if err != nil {
+			return time.Time{}, err
+		}
+		if r.OnGenerate != nil {
+			if err := r.OnGenerate(key, kid); err != nil {
+				return time.Time{}, err
+			}
+		}
+		k := &rotationKey{signer: s, public: key.Public(), kid: kid, created: now, activates: now}
+		if len(r.keys) != 0 {
+			k.activates = now.Add(r.Propagation)
+		}
+		r.keys = append(r.keys, k)
+		changed = true
+	}
+
+	// unpublish
+	for i := 0; i < len(r.keys); i++ {
+		if k := r.keys[i]; !k.retires.IsZero() && !now.Before(k.retires) {
+			r.keys = append(r.keys[:i], r.keys[i+1:]...)
+			i--
+			changed = true
+		}
+	}
+
+	if changed && r.OnPublish != nil {
+		keys := new(KeyRegister)
+		for _, k := range r.keys {
+			if err := keys.add(k.public, k.kid); err != nil {
+				return time.Time{}, err
+			}
+		}
+		if err := r.OnPublish(keys); err != nil {
+			return time.Time{}, err
+		}
+	}
+
+	// activate the most recent key due
+	for i := len(r.keys) - 1; i >= 0; i-- {
+		k := r.keys[i]
+		if now.Before(k.activates) {
+			continue
+		}
+		if k != r.active && k.retires.IsZero() {
+			if err := r.OnActivate(k.signer, k.kid); err != nil {
+				return time.Time{}, err
+			}
+			for _, o := range r.keys[:i] {
+				if o.retires.IsZero() {
+					o.retires = now.Add(r.Overlap)
+				}
+			}
+			r.active = k
+		}
+		break
+	}
+
+	next = r.keys[len(r.keys)-1].created.Add(r.Interval)
+	for _, k := range r.keys {
+		if k.activates.After(now) && k.activates.Before(next) {
+			next = k.activates
+		}
+		if !k.retires.IsZero() && k.retires.Before(next) {
+			next = k.retires
+		}
+	}
+	return next, nil
+}
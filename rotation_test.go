@@ -0,0 +1,83 @@
+//go:build !jwt_hmac_only
+// +build !jwt_hmac_only
+
+package jwt
+
+import (
+	"context"
+	"crypto"
+	"crypto/ed25519"
+	"crypto/rand"
+	"testing"
+	"time"
+)
+
+func TestRotation(t *testing.T) {
+	var generated, published []string
+	var active string
+	r := Rotation{
+		Alg: EdDSA,
+		Generate: func() (crypto.Signer, error) {
+			_, key, err := ed25519.GenerateKey(rand.Reader)
+			return key, err
+		},
+		Interval:    10 * time.Hour,
+		Propagation: time.Hour,
+		Overlap:     2 * time.Hour,
+		OnGenerate: func(key crypto.Signer, kid string) error {
+			generated = append(generated, kid)
+			return nil
+		},
+		OnPublish: func(keys *KeyRegister) error {
+			published = keys.EdDSAIDs
+			return nil
+		},
+		OnActivate: func(s Signer, kid string) error {
+			active = kid
+			return nil
+		},
+	}
+
+	t0 := time.Unix(1600000000, 0)
+	golden := []struct {
+		at        time.Duration
+		next      time.Duration
+		generated int
+		published []int // generated indices
+		active    int
+	}{
+		{0, 10 * time.Hour, 1, []int{0}, 0},
+		{10 * time.Hour, 11 * time.Hour, 2, []int{0, 1}, 0},
+		{11 * time.Hour, 13 * time.Hour, 2, []int{0, 1}, 1},
+		{13 * time.Hour, 20 * time.Hour, 2, []int{1}, 1},
+		{20 * time.Hour, 21 * time.Hour, 3, []int{1, 2}, 1},
+	}
+	for _, gold := range golden {
+		next, err := r.step(t0.Add(gold.at))
+		if err != nil {
+			t.Fatalf("%s: step error: %s", gold.at, err)
+		}
+		if want := t0.Add(gold.next); !next.Equal(want) {
+			t.Errorf("%s: got next transition at %s, want %s", gold.at, next.Sub(t0), gold.next)
+		}
+		if len(generated) != gold.generated {
+			t.Fatalf("%s: got %d keys generated, want %d", gold.at, len(generated), gold.generated)
+		}
+		if len(published) != len(gold.published) {
+			t.Errorf("%s: got published %q, want %d keys", gold.at, published, len(gold.published))
+		} else {
+			for i, index := range gold.published {
+				if published[i] != generated[index] {
+					t.Errorf("%s: got published %q, want key %d at %d", gold.at, published, index, i)
+				}
+			}
+		}
+		if active != generated[gold.active] {
+			t.Errorf("%s: got active key %q, want %d", gold.at, active, gold.active)
+		}
+	}
+
+	if err := new(Rotation).Run(context.Background()); err != errRotationConfig {
+		t.Errorf("got error %v, want %v", err, errRotationConfig)
+	}
+}
@@ -0,0 +1,68 @@
+package jwt
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// Route binds an issuer to its trusted credentials.
+type Route struct {
+	// Issuer is the "iss" claim value.
+	Issuer string
+
+	// Type, when not empty, is the "typ" header value required.
+	Type string
+
+	// Keys are exclusive to Issuer.
+	Keys Checker
+}
+
+// Router dispatches tokens to the credentials of their issuer. Keys of one
+// Route never validate a token which claims the issuer of another Route.
+//
+// Multiple goroutines may invoke methods on a Router simultaneously. The
+// exported fields must not be modified after first use.
+type Router struct {
+	// Routes are evaluated in order of appearance.
+	Routes []Route
+}
+
+// Check parses a JWT if, and only if, the signature checks out with the Keys
+// of the first Route which matches the (unverified) issuer and type. The
+// return is ErrIssuer when no Route applies.
+func (r *Router) Check(token []byte) (*Claims, error) {
+	peek, err := ParseWithoutCheck(token)
+	if err != nil {
+		return nil, err
+	}
+	var header struct {
+		Type string `json:"typ"`
+	}
+	if err := json.Unmarshal([]byte(peek.RawHeader), &header); err != nil {
+		return nil, fmt.Errorf("jwt: malformed JOSE header: %w", err)
+	}
+
+	for i := range r.Routes {
+		route := &r.Routes[i]
+		if route.Issuer != peek.Issuer {
+			continue
+		}
+		// “MIME Media Type values are case insensitive.”
+		// — “JSON Web Signature (JWS)” RFC 7515, subsection 4.1.9
+		if route.Type != "" && !strings.EqualFold(route.Type, header.Type) {
+			continue
+		}
+
+		c, err := route.Keys.Check(token)
+		if err != nil {
+			return nil, err
+		}
+		// paranoia: the verified claims must be the ones routed
+		if c.Issuer != route.Issuer {
+			return nil, ErrIssuer
+		}
+		return c, nil
+	}
+	return nil, ErrIssuer
+}
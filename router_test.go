@@ -0,0 +1,64 @@
+//go:build !jwt_hmac_only && !jwt_no_rsa
+// +build !jwt_hmac_only,!jwt_no_rsa
+
+package jwt
+
+import (
+	"crypto/ed25519"
+	"crypto/rsa"
+	"encoding/json"
+	"testing"
+)
+
+func TestRouter(t *testing.T) {
+	r := Router{Routes: []Route{
+		{Issuer: "a", Keys: &KeyRegister{EdDSAs: []ed25519.PublicKey{testKeyEd25519Public}}},
+		{Issuer: "b", Type: "at+jwt", Keys: &KeyRegister{RSAs: []*rsa.PublicKey{&testKeyRSA2048.PublicKey}}},
+	}}
+
+	var c Claims
+	c.Issuer = "a"
+	tokenA, err := c.EdDSASign(testKeyEd25519Private)
+	if err != nil {
+		t.Fatal("sign error:", err)
+	}
+	if got, err := r.Check(tokenA); err != nil {
+		t.Error("check error:", err)
+	} else if got.Issuer != "a" {
+		t.Errorf("got issuer %q, want a", got.Issuer)
+	}
+
+	// issuer a with key of b
+	tokenAB, err := c.RSASign(RS256, testKeyRSA2048, json.RawMessage(`{"typ":"at+jwt"}`))
+	if err != nil {
+		t.Fatal("sign error:", err)
+	}
+	if _, err := r.Check(tokenAB); err != ErrSigMiss {
+		t.Errorf("got error %v for key of other issuer, want %v", err, ErrSigMiss)
+	}
+
+	c.Issuer = "b"
+	tokenB, err := c.RSASign(RS256, testKeyRSA2048, json.RawMessage(`{"typ":"AT+JWT"}`))
+	if err != nil {
+		t.Fatal("sign error:", err)
+	}
+	if _, err := r.Check(tokenB); err != nil {
+		t.Error("check error:", err)
+	}
+	tokenB, err = c.RSASign(RS256, testKeyRSA2048)
+	if err != nil {
+		t.Fatal("sign error:", err)
+	}
+	if _, err := r.Check(tokenB); err != ErrIssuer {
+		t.Errorf("got error %v for type mismatch, want %v", err, ErrIssuer)
+	}
+
+	c.Issuer = "x"
+	tokenX, err := c.EdDSASign(testKeyEd25519Private)
+	if err != nil {
+		t.Fatal("sign error:", err)
+	}
+	if _, err := r.Check(tokenX); err != ErrIssuer {
+		t.Errorf("got error %v for unknown issuer, want %v", err, ErrIssuer)
+	}
+}
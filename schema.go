@@ -0,0 +1,34 @@
+package jwt
+
+import "encoding/json"
+
+// SchemaValidator checks the structure of claims, e.g., with a JSON Schema or
+// with CUE. Adapters for such libraries need little more than a compiled schema
+// and a call to its validation function.
+type SchemaValidator interface {
+	// Validate returns an error for violations. The payload is the JSON
+	// claims set, as is in the token.
+	Validate(payload json.RawMessage) error
+}
+
+// SchemaFunc is a SchemaValidator function.
+type SchemaFunc func(payload json.RawMessage) error
+
+// Validate implements the SchemaValidator interface.
+func (f SchemaFunc) Validate(payload json.RawMessage) error {
+	return f(payload)
+}
+
+// SchemaError signals a rejection from a SchemaValidator. Err has the details,
+// as provided by the validator.
+type SchemaError struct {
+	Err error // cause
+}
+
+// Error honors the error interface.
+func (e *SchemaError) Error() string {
+	return "jwt: claims violate schema: " + e.Err.Error()
+}
+
+// Unwrap honors the errors package conventions.
+func (e *SchemaError) Unwrap() error { return e.Err }
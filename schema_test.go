@@ -0,0 +1,61 @@
+package jwt
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+)
+
+var errNoTenant = errors.New("tenant: string required")
+
+// tenantSchema requires a "tenant" string.
+var tenantSchema = SchemaFunc(func(payload json.RawMessage) error {
+	var doc struct {
+		Tenant *string `json:"tenant"`
+	}
+	if err := json.Unmarshal(payload, &doc); err != nil {
+		return err
+	}
+	if doc.Tenant == nil {
+		return errNoTenant
+	}
+	return nil
+})
+
+func TestPolicySchema(t *testing.T) {
+	p := &Policy{Schema: tenantSchema}
+	now := time.Now()
+
+	c := &Claims{Raw: json.RawMessage(`{"sub":"alice","tenant":"acme"}`)}
+	if err := c.applyPayload(); err != nil {
+		t.Fatal(err)
+	}
+	if err := p.Apply(c, now); err != nil {
+		t.Error("apply error:", err)
+	}
+
+	c = &Claims{Raw: json.RawMessage(`{"sub":"alice","tenant":42}`)}
+	if err := c.applyPayload(); err != nil {
+		t.Fatal(err)
+	}
+	err := p.Apply(c, now)
+	var schemaErr *SchemaError
+	if !errors.As(err, &schemaErr) {
+		t.Fatalf("got error %v, want a SchemaError", err)
+	}
+	if got := ErrorCode(err); got != "claim_invalid" {
+		t.Errorf("got error code %q, want claim_invalid", got)
+	}
+
+	// without Raw
+	c = new(Claims)
+	c.Subject = "alice"
+	if err := p.Apply(c, now); !errors.Is(err, errNoTenant) {
+		t.Errorf("got error %v, want %v", err, errNoTenant)
+	}
+	c.Set = map[string]interface{}{"tenant": "acme"}
+	if err := p.Apply(c, now); err != nil {
+		t.Error("apply error:", err)
+	}
+}
@@ -0,0 +1,112 @@
+package jwt
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// SETHeader is the JOSE header extension for Security Event Tokens, conform
+// RFC 8417, subsection 2.3. Use json.RawMessage(SETHeader) as an extra header
+// with any of the Sign methods.
+const SETHeader = `{"typ":"secevent+jwt"}`
+
+// SET constraint violations.
+var (
+	errSETType    = errors.New("jwt: SET without secevent+jwt type")
+	errSETEvents  = errors.New("jwt: SET events claim absent or malformed")
+	errSETExpires = errors.New("jwt: SET with expiry")
+)
+
+// SET has the claims specific to Security Event Tokens, as defined by RFC 8417.
+type SET struct {
+	// Events maps event type identifiers to their payload. Payloads are
+	// JSON objects, with an empty one for events without any data.
+	Events map[string]map[string]interface{}
+
+	// Transaction is the optional "txn" claim.
+	Transaction string
+
+	// EventTime is the optional "toe" claim.
+	EventTime time.Time
+}
+
+// Apply sets the claims on c. Note that SETs require the "iss", "iat" and
+// "jti" claims too, which Apply does not set.
+func (s *SET) Apply(c *Claims) {
+	if c.Set == nil {
+		c.Set = make(map[string]interface{})
+	}
+
+	c.Events = make(map[string]map[string]interface{}, len(s.Events))
+	for name, payload := range s.Events {
+		if payload == nil {
+			payload = map[string]interface{}{}
+		}
+		c.Events[name] = payload
+	}
+
+	if s.Transaction != "" {
+		c.Set["txn"] = s.Transaction
+	}
+	if n := NewNumericTime(s.EventTime); n != nil {
+		c.Set["toe"] = float64(*n)
+	}
+}
+
+// ParseSET returns the SET claims, with validation of the requirements from
+// RFC 8417, sections 2.2 and 2.3. The claims must come from a verified token,
+// as the JOSE header needs the secevent+jwt type. Expiry is not permitted, to
+// prevent confusion with regular access tokens.
+func ParseSET(c *Claims) (*SET, error) {
+	var header struct {
+		Type string `json:"typ"`
+	}
+	if err := json.Unmarshal([]byte(c.RawHeader), &header); err != nil {
+		return nil, fmt.Errorf("jwt: malformed JOSE header: %w", err)
+	}
+	typ := header.Type
+	if len(typ) > len("application/") && strings.EqualFold(typ[:len("application/")], "application/") {
+		typ = typ[len("application/"):]
+	}
+	if !strings.EqualFold(typ, "secevent+jwt") {
+		return nil, errSETType
+	}
+
+	for _, name := range []string{issuer, issued, id} {
+		if !c.has(name) {
+			return nil, fmt.Errorf("%w: %q", ErrClaimMiss, name)
+		}
+	}
+	if c.has(expires) {
+		return nil, errSETExpires
+	}
+
+	if len(c.Events) == 0 {
+		return nil, errSETEvents
+	}
+	s := &SET{Events: c.Events}
+
+	if txn, ok := c.Set["txn"]; ok {
+		s.Transaction, ok = txn.(string)
+		if !ok {
+			return nil, errors.New("jwt: SET txn claim not a string")
+		}
+	}
+	if toe, ok := c.Set["toe"]; ok {
+		f, ok := toe.(float64)
+		if !ok {
+			return nil, errors.New("jwt: SET toe claim not a number")
+		}
+		s.EventTime = (*NumericTime)(&f).Time()
+	}
+	return s, nil
+}
+
+// CheckSET is a Policy Func which applies the ParseSET validation.
+func CheckSET(c *Claims, now time.Time) error {
+	_, err := ParseSET(c)
+	return err
+}
@@ -0,0 +1,95 @@
+//go:build !jwt_hmac_only
+// +build !jwt_hmac_only
+
+package jwt
+
+import (
+	"crypto/ed25519"
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestSET(t *testing.T) {
+	const eventType = "https://schemas.openid.net/secevent/risc/event-type/account-disabled"
+	toe := time.Unix(1600000000, 0)
+
+	var c Claims
+	c.Issuer = "https://idp.example.com/"
+	c.Issued = NewNumericTime(toe.Add(time.Second))
+	c.ID = "756E69717565206964656E746966696572"
+	s := SET{
+		Events: map[string]map[string]interface{}{
+			eventType: {"reason": "hijacking"},
+		},
+		Transaction: "8675309",
+		EventTime:   toe,
+	}
+	s.Apply(&c)
+	token, err := c.EdDSASign(testKeyEd25519Private, json.RawMessage(SETHeader))
+	if err != nil {
+		t.Fatal("sign error:", err)
+	}
+
+	v := Verifier{
+		Keys:   &KeyRegister{EdDSAs: []ed25519.PublicKey{testKeyEd25519Public}},
+		Policy: Policy{Func: CheckSET},
+	}
+	got, err := v.Check(token)
+	if err != nil {
+		t.Fatal("check error:", err)
+	}
+	parsed, err := ParseSET(got)
+	if err != nil {
+		t.Fatal("parse error:", err)
+	}
+	if parsed.Transaction != "8675309" || !parsed.EventTime.Equal(toe) {
+		t.Errorf("got transaction %q and event time %s", parsed.Transaction, parsed.EventTime)
+	}
+	if reason := parsed.Events[eventType]["reason"]; reason != "hijacking" {
+		t.Errorf("got event payload %v", parsed.Events)
+	}
+}
+
+func TestSETViolations(t *testing.T) {
+	valid := func() *Claims {
+		c := new(Claims)
+		c.Issuer = "a"
+		c.Issued = NewNumericTime(time.Unix(1600000000, 0))
+		c.ID = "1"
+		(&SET{Events: map[string]map[string]interface{}{"urn:example:event": nil}}).Apply(c)
+		return c
+	}
+
+	golden := []struct {
+		edit   func(*Claims)
+		header string
+		want   error
+	}{
+		{func(*Claims) {}, SETHeader, nil},
+		{func(*Claims) {}, `{"typ":"application/SECEVENT+JWT"}`, nil},
+		{func(*Claims) {}, `{"typ":"JWT"}`, errSETType},
+		{func(*Claims) {}, `{"kid":"k1"}`, errSETType},
+		{func(c *Claims) { c.Expires = c.Issued }, SETHeader, errSETExpires},
+		{func(c *Claims) { c.Events, c.Set["events"] = nil, map[string]interface{}{} }, SETHeader, errSETEvents},
+		{func(c *Claims) { c.Events, c.Set["events"] = nil, map[string]interface{}{"urn:example:event": true} }, SETHeader, errSETEvents},
+		{func(c *Claims) { c.Events = nil }, SETHeader, errSETEvents},
+		{func(c *Claims) { c.ID = "" }, SETHeader, ErrClaimMiss},
+	}
+	for i, gold := range golden {
+		c := valid()
+		gold.edit(c)
+		token, err := c.HMACSign(HS256, []byte("guest"), json.RawMessage(gold.header))
+		if err != nil {
+			t.Fatal("sign error:", err)
+		}
+		got, err := HMACCheck(token, []byte("guest"))
+		if err != nil {
+			t.Fatal("check error:", err)
+		}
+		if _, err := ParseSET(got); !errors.Is(err, gold.want) && err != gold.want {
+			t.Errorf("%d: got error %v, want %v", i, err, gold.want)
+		}
+	}
+}
@@ -2,16 +2,12 @@ package jwt
 
 import (
 	"bytes"
-	"crypto/ecdsa"
-	"crypto/ed25519"
+	"context"
 	"crypto/hmac"
-	"crypto/rand"
-	"crypto/rsa"
 	"encoding/json"
 	"errors"
 	"fmt"
 	"hash"
-	"strconv"
 )
 
 // FormatWithoutSign updates the Raw fields and returns a new JWT, with only the
@@ -26,77 +22,6 @@ func (c *Claims) FormatWithoutSign(alg string, extraHeaders ...json.RawMessage)
 	return c.newToken(alg, 0, extraHeaders)
 }
 
-// ECDSASign updates the Raw fields and returns a new JWT.
-// The return is an AlgError when alg is not in ECDSAAlgs.
-// The caller must use the correct key for the respective algorithm (P-256 for
-// ES256, P-384 for ES384 and P-521 for ES512) or risk malformed token production.
-//
-// The JOSE header (content) can be extended with extraHeaders, in the form of
-// JSON objects. Redundant and/or duplicate keys are applied as provided.
-func (c *Claims) ECDSASign(alg string, key *ecdsa.PrivateKey, extraHeaders ...json.RawMessage) (token []byte, err error) {
-	hash, err := hashLookup(alg, ECDSAAlgs)
-	if err != nil {
-		return nil, err
-	}
-	digest := hash.New()
-
-	// signature contains pair (r, s) as per RFC 7518, subsection 3.4
-	paramLen := (key.Curve.Params().BitSize + 7) / 8
-	token, err = c.newToken(alg, encoding.EncodedLen(paramLen*2), extraHeaders)
-	if err != nil {
-		return nil, err
-	}
-	digest.Write(token)
-
-	buf := token[len(token):]
-	r, s, err := ecdsa.Sign(rand.Reader, key, digest.Sum(buf))
-	if err != nil {
-		return nil, err
-	}
-
-	token = append(token, '.')
-	sig := token[len(token):cap(token)]
-	// serialize r and s, using sig as a buffer
-	i := len(sig)
-	for _, word := range s.Bits() {
-		for bitCount := strconv.IntSize; bitCount > 0; bitCount -= 8 {
-			i--
-			sig[i] = byte(word)
-			word >>= 8
-		}
-	}
-	// i might have exceeded paramLen due to the word size
-	i = len(sig) - paramLen
-	for _, word := range r.Bits() {
-		for bitCount := strconv.IntSize; bitCount > 0; bitCount -= 8 {
-			i--
-			sig[i] = byte(word)
-			word >>= 8
-		}
-	}
-
-	// encoder won't overhaul source space
-	encoding.Encode(sig, sig[len(sig)-2*paramLen:])
-	return token[:cap(token)], nil
-}
-
-// EdDSASign updates the Raw fields and returns a new JWT.
-//
-// The JOSE header (content) can be extended with extraHeaders, in the form of
-// JSON objects. Redundant and/or duplicate keys are applied as provided.
-func (c *Claims) EdDSASign(key ed25519.PrivateKey, extraHeaders ...json.RawMessage) (token []byte, err error) {
-	token, err = c.newToken(EdDSA, encoding.EncodedLen(ed25519.SignatureSize), extraHeaders)
-	if err != nil {
-		return nil, err
-	}
-
-	sig := ed25519.Sign(key, token)
-
-	token = append(token, '.')
-	encoding.Encode(token[len(token):cap(token)], sig)
-	return token[:cap(token)], nil
-}
-
 // HMACSign updates the Raw fields and returns a new JWT.
 // The return is an AlgError when alg is not in HMACAlgs.
 //
@@ -123,6 +48,7 @@ func (c *Claims) HMACSign(alg string, secret []byte, extraHeaders ...json.RawMes
 	i := cap(token) - digest.Size()
 	buf := token[i:i]
 	encoding.Encode(token[len(token):cap(token)], digest.Sum(buf))
+	c.audit(context.Background(), alg)
 	return token[:cap(token)], nil
 }
 
@@ -145,40 +71,7 @@ func (h *HMAC) Sign(c *Claims, extraHeaders ...json.RawMessage) (token []byte, e
 	i := cap(token) - digest.Size()
 	buf := token[i:i]
 	encoding.Encode(token[len(token):cap(token)], digest.Sum(buf))
-	return token[:cap(token)], nil
-}
-
-// RSASign updates the Raw fields and returns a new JWT.
-// The return is an AlgError when alg is not in RSAAlgs.
-//
-// The JOSE header (content) can be extended with extraHeaders, in the form of
-// JSON objects. Redundant and/or duplicate keys are applied as provided.
-func (c *Claims) RSASign(alg string, key *rsa.PrivateKey, extraHeaders ...json.RawMessage) (token []byte, err error) {
-	hash, err := hashLookup(alg, RSAAlgs)
-	if err != nil {
-		return nil, err
-	}
-	digest := hash.New()
-
-	token, err = c.newToken(alg, encoding.EncodedLen(key.Size()), extraHeaders)
-	if err != nil {
-		return nil, err
-	}
-	digest.Write(token)
-
-	var sig []byte
-	buf := token[len(token):]
-	if alg != "" && alg[0] == 'P' {
-		sig, err = rsa.SignPSS(rand.Reader, key, hash, digest.Sum(buf), &pSSOptions)
-	} else {
-		sig, err = rsa.SignPKCS1v15(rand.Reader, key, hash, digest.Sum(buf))
-	}
-	if err != nil {
-		return nil, err
-	}
-
-	token = append(token, '.')
-	encoding.Encode(token[len(token):cap(token)], sig)
+	c.audit(context.Background(), h.alg)
 	return token[:cap(token)], nil
 }
 
@@ -198,7 +91,30 @@ var (
 	headerRS512 = []byte(`{"alg":"RS512"}`)
 )
 
+// Audit passes a SignEvent to AuditSign, if set. It must be called only after
+// a signature completed.
+func (c *Claims) audit(ctx context.Context, alg string) {
+	if AuditSign == nil {
+		return
+	}
+	requestID, _ := ctx.Value(requestIDKey{}).(string)
+	AuditSign(SignEvent{
+		Alg:       alg,
+		KeyID:     c.KeyID,
+		Subject:   c.Subject,
+		Audiences: c.Audiences,
+		Expires:   c.Expires,
+		Issued:    c.Issued,
+		ID:        c.ID,
+		RequestID: requestID,
+	})
+}
+
+// NewToken returns the token without signature, with capacity for encSigLen
+// plus the separator.
 func (c *Claims) newToken(alg string, encSigLen int, extraHeaders []json.RawMessage) ([]byte, error) {
+	c.RawSignature = nil // stale
+
 	var payload interface{}
 	if c.Set == nil {
 		payload = &c.Registered
@@ -231,6 +147,19 @@ func (c *Claims) newToken(alg string, encSigLen int, extraHeaders []json.RawMess
 		if c.ID != "" {
 			c.Set[id] = c.ID
 		}
+		if c.SessionID != "" {
+			c.Set[sessionID] = c.SessionID
+		}
+		if c.Nonce != "" {
+			c.Set[nonce] = c.Nonce
+		}
+		if len(c.Events) != 0 {
+			object := make(map[string]interface{}, len(c.Events))
+			for name, payload := range c.Events {
+				object[name] = payload
+			}
+			c.Set[events] = object
+		}
 	}
 
 	// define Claims.Raw
@@ -239,6 +168,15 @@ func (c *Claims) newToken(alg string, encSigLen int, extraHeaders []json.RawMess
 	} else {
 		c.Raw = json.RawMessage(bytes)
 	}
+	body := []byte(c.Raw)
+	if c.Compress > 0 && len(body) > c.Compress {
+		compressed, err := deflate(body)
+		if err != nil {
+			return nil, err
+		}
+		body = compressed
+		extraHeaders = append([]json.RawMessage{json.RawMessage(zipHeader)}, extraHeaders...)
+	}
 
 	// try fixed JOSE header
 	if len(extraHeaders) == 0 && c.KeyID == "" {
@@ -286,10 +224,10 @@ func (c *Claims) newToken(alg string, encSigLen int, extraHeaders []json.RawMess
 		}
 
 		if fixed != "" {
-			l := len(fixed) + encoding.EncodedLen(len(c.Raw))
+			l := len(fixed) + encoding.EncodedLen(len(body))
 			token := make([]byte, l, l+1+encSigLen)
 			copy(token, fixed)
-			encoding.Encode(token[len(fixed):], c.Raw)
+			encoding.Encode(token[len(fixed):], body)
 			return token, nil
 		}
 	}
@@ -316,10 +254,10 @@ func (c *Claims) newToken(alg string, encSigLen int, extraHeaders []json.RawMess
 
 	// compose token
 	headerLen := encoding.EncodedLen(header.Len())
-	l := headerLen + 1 + encoding.EncodedLen(len(c.Raw))
+	l := headerLen + 1 + encoding.EncodedLen(len(body))
 	token := make([]byte, l, l+1+encSigLen)
 	encoding.Encode(token, header.Bytes())
 	token[headerLen] = '.'
-	encoding.Encode(token[headerLen+1:], c.Raw)
+	encoding.Encode(token[headerLen+1:], body)
 	return token, nil
 }
@@ -0,0 +1,202 @@
+package jwt
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/asn1"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"hash"
+	"math/big"
+)
+
+// Signer produces signatures, e.g., with a KMS or HSM. Multiple goroutines may
+// invoke methods on a Signer simultaneously.
+type Signer interface {
+	// Alg returns the algorithm identifier.
+	Alg() string
+
+	// Sign returns the signature of data in its JWS representation,
+	// i.e., before base64 encoding. ECDSA signatures consist of R and S,
+	// as described in RFC 7518, subsection 3.4. Implementations should
+	// return promptly after ctx is done.
+	Sign(ctx context.Context, data []byte) (sig []byte, err error)
+}
+
+// SignContext updates the Raw fields and returns a new JWT. The signature is
+// aborted when ctx is done before completion.
+//
+// The JOSE header (content) can be extended with extraHeaders, in the form of
+// JSON objects. Redundant and/or duplicate keys are applied as provided.
+func (c *Claims) SignContext(ctx context.Context, s Signer, extraHeaders ...json.RawMessage) (token []byte, err error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	token, err = c.newToken(s.Alg(), 0, extraHeaders)
+	if err != nil {
+		return nil, err
+	}
+	token, err = appendSignature(ctx, s, token)
+	if err != nil {
+		return nil, err
+	}
+	c.audit(ctx, s.Alg())
+	return token, nil
+}
+
+// AppendSignature completes a token without signature with one from s.
+func appendSignature(ctx context.Context, s Signer, token []byte) ([]byte, error) {
+	sig, err := s.Sign(ctx, token)
+	if err != nil {
+		return nil, err
+	}
+
+	i := len(token) + 1
+	token = append(token, make([]byte, 1+encoding.EncodedLen(len(sig)))...)
+	token[i-1] = '.'
+	encoding.Encode(token[i:], sig)
+	return token, nil
+}
+
+var errSignerKey = errors.New("jwt: key type does not match algorithm")
+
+// NewSigner returns a Signer for the key, which is either a []byte secret for
+// HMAC algorithms, or a crypto.Signer. Private keys from the standard library
+// (*ecdsa.PrivateKey, ed25519.PrivateKey and *rsa.PrivateKey) are signers, as
+// are the keys from most KMS and HSM libraries. Context cancellation applies
+// up to the invocation of crypto.Signer.Sign only.
+func NewSigner(alg string, key interface{}) (Signer, error) {
+	if secret, ok := key.([]byte); ok {
+		h, err := NewHMAC(alg, secret)
+		if err != nil {
+			return nil, err
+		}
+		return hmacSigner{h}, nil
+	}
+
+	signer, ok := key.(crypto.Signer)
+	if !ok {
+		return nil, fmt.Errorf("jwt: unsupported key type %T", key)
+	}
+	s := &cryptoSigner{alg: alg, signer: signer}
+
+	var err error
+	switch pub := signer.Public().(type) {
+	case *ecdsa.PublicKey:
+		s.hash, err = hashLookup(alg, ECDSAAlgs)
+		s.paramLen = (pub.Curve.Params().BitSize + 7) / 8
+	case ed25519.PublicKey:
+		if alg != EdDSA || !edDSAInUse {
+			err = AlgError(alg)
+		}
+	case *rsa.PublicKey:
+		s.hash, err = hashLookup(alg, RSAAlgs)
+		if alg != "" && alg[0] == 'P' {
+			s.opts = &rsa.PSSOptions{SaltLength: rsa.PSSSaltLengthEqualsHash, Hash: s.hash}
+		}
+	default:
+		return nil, fmt.Errorf("jwt: unsupported public key type %T", pub)
+	}
+	if err != nil {
+		if _, ok := err.(AlgError); ok && algInUse(alg) {
+			return nil, errSignerKey
+		}
+		return nil, err
+	}
+	if s.opts == nil {
+		s.opts = s.hash
+	}
+	return s, nil
+}
+
+// AlgInUse returns whether alg is in any of the algorithm registrations.
+func algInUse(alg string) bool {
+	_, ecdsa := ECDSAAlgs[alg]
+	_, hmac := HMACAlgs[alg]
+	_, rsa := RSAAlgs[alg]
+	return ecdsa || hmac || rsa || (alg == EdDSA && edDSAInUse)
+}
+
+type hmacSigner struct{ *HMAC }
+
+// Alg implements the Signer interface.
+func (h hmacSigner) Alg() string { return h.alg }
+
+// Sign implements the Signer interface.
+func (h hmacSigner) Sign(ctx context.Context, data []byte) ([]byte, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	digest := h.digests.Get().(hash.Hash)
+	defer h.digests.Put(digest)
+	digest.Reset()
+	digest.Write(data)
+	return digest.Sum(nil), nil
+}
+
+type cryptoSigner struct {
+	alg      string
+	hash     crypto.Hash // zero for EdDSA
+	opts     crypto.SignerOpts
+	paramLen int // ECDSA only
+	signer   crypto.Signer
+}
+
+// Alg implements the Signer interface.
+func (s *cryptoSigner) Alg() string { return s.alg }
+
+// Public returns the public key.
+func (s *cryptoSigner) Public() crypto.PublicKey { return s.signer.Public() }
+
+// Sign implements the Signer interface.
+func (s *cryptoSigner) Sign(ctx context.Context, data []byte) ([]byte, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	if s.hash == 0 {
+		return s.signer.Sign(rand.Reader, data, crypto.Hash(0))
+	}
+	digest := s.hash.New()
+	digest.Write(data)
+	sig, err := s.signer.Sign(rand.Reader, digest.Sum(nil), s.opts)
+	if err != nil || s.paramLen == 0 {
+		return sig, err
+	}
+
+	// ASN.1 to R and S
+	var rs struct{ R, S *big.Int }
+	if rest, err := asn1.Unmarshal(sig, &rs); err != nil || len(rest) != 0 {
+		return nil, errors.New("jwt: malformed ECDSA signature from signer")
+	}
+	buf := make([]byte, 2*s.paramLen)
+	copy(buf[:s.paramLen], padBytes(rs.R, s.paramLen))
+	copy(buf[s.paramLen:], padBytes(rs.S, s.paramLen))
+	return buf, nil
+}
+
+// ReSign verifies token with keys, and it returns a new token with the same
+// claims, signed by s. Claims outside of the Registered fields are preserved
+// as is. The edit function, when not nil, may modify the claims in between,
+// e.g., to change the audience or to extend the expiry. The key ID of the
+// original is cleared before edit. Any error from edit is returned as is.
+func ReSign(ctx context.Context, token []byte, keys Checker, s Signer, edit func(*Claims) error) ([]byte, error) {
+	c, err := keys.Check(token)
+	if err != nil {
+		return nil, err
+	}
+
+	c.KeyID = ""
+	if edit != nil {
+		if err := edit(c); err != nil {
+			return nil, err
+		}
+	}
+	return c.SignContext(ctx, s)
+}
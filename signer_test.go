@@ -0,0 +1,148 @@
+//go:build !jwt_hmac_only && !jwt_no_rsa && !jwt_no_ecdsa
+// +build !jwt_hmac_only,!jwt_no_rsa,!jwt_no_ecdsa
+
+package jwt
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"io"
+	"testing"
+	"time"
+)
+
+func TestSignContext(t *testing.T) {
+	keys := KeyRegister{
+		ECDSAs:  []*ecdsa.PublicKey{&testKeyEC256.PublicKey, &testKeyEC384.PublicKey, &testKeyEC521.PublicKey},
+		EdDSAs:  []ed25519.PublicKey{testKeyEd25519Public},
+		RSAs:    []*rsa.PublicKey{&testKeyRSA2048.PublicKey},
+		Secrets: [][]byte{[]byte("guest")},
+	}
+
+	golden := []struct {
+		alg string
+		key interface{}
+	}{
+		{ES256, testKeyEC256},
+		{ES384, testKeyEC384},
+		{ES512, testKeyEC521},
+		{EdDSA, testKeyEd25519Private},
+		{HS384, []byte("guest")},
+		{PS256, testKeyRSA2048},
+		{RS512, testKeyRSA2048},
+		// opaque crypto.Signer, like KMS libraries
+		{ES256, opaqueSigner{testKeyEC256}},
+		{PS384, opaqueSigner{testKeyRSA2048}},
+	}
+	for _, gold := range golden {
+		s, err := NewSigner(gold.alg, gold.key)
+		if err != nil {
+			t.Errorf("%s with %T: signer error: %s", gold.alg, gold.key, err)
+			continue
+		}
+		c := Claims{KeyID: "k1"}
+		c.Subject = "test"
+		token, err := c.SignContext(context.Background(), s)
+		if err != nil {
+			t.Errorf("%s with %T: sign error: %s", gold.alg, gold.key, err)
+			continue
+		}
+		got, err := keys.Check(token)
+		if err != nil {
+			t.Errorf("%s with %T: check error: %s", gold.alg, gold.key, err)
+			continue
+		}
+		if got.Subject != "test" || got.KeyID != "k1" {
+			t.Errorf("%s with %T: got claims %s with header %s", gold.alg, gold.key, got.Raw, got.RawHeader)
+		}
+	}
+}
+
+func TestSignContextDone(t *testing.T) {
+	s, err := NewSigner(EdDSA, testKeyEd25519Private)
+	if err != nil {
+		t.Fatal("signer error:", err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := new(Claims).SignContext(ctx, s); err != context.Canceled {
+		t.Errorf("got error %v, want %v", err, context.Canceled)
+	}
+}
+
+func TestNewSignerErrors(t *testing.T) {
+	if _, err := NewSigner(RS256, testKeyEC256); err != errSignerKey {
+		t.Errorf("got error %v for RSA algorithm with ECDSA key, want %v", err, errSignerKey)
+	}
+	if _, err := NewSigner(ES256, testKeyEd25519Private); err != errSignerKey {
+		t.Errorf("got error %v for ECDSA algorithm with EdDSA key, want %v", err, errSignerKey)
+	}
+	if _, err := NewSigner("ES1", testKeyEC256); err != AlgError("ES1") {
+		t.Errorf("got error %v for unknown algorithm, want %v", err, AlgError("ES1"))
+	}
+	if _, err := NewSigner(HS256, nil); err == nil {
+		t.Error("no error for nil key")
+	}
+	if _, err := NewSigner(HS256, []byte{}); err != errNoSecret {
+		t.Errorf("got error %v for empty secret, want %v", err, errNoSecret)
+	}
+}
+
+// OpaqueSigner hides the concrete key type.
+type opaqueSigner struct {
+	key crypto.Signer
+}
+
+func (s opaqueSigner) Public() crypto.PublicKey {
+	return s.key.Public()
+}
+
+func (s opaqueSigner) Sign(rand io.Reader, digest []byte, opts crypto.SignerOpts) ([]byte, error) {
+	return s.key.Sign(rand, digest, opts)
+}
+
+func TestReSign(t *testing.T) {
+	in := Claims{KeyID: "partner"}
+	in.Issuer = "partner"
+	in.Audiences = []string{"gateway"}
+	in.Expires = NewNumericTime(time.Unix(1600000000, 0))
+	in.Set = map[string]interface{}{"scope": "read"}
+	token, err := in.HMACSign(HS256, []byte("guest"))
+	if err != nil {
+		t.Fatal("sign error:", err)
+	}
+
+	s, err := NewSigner(EdDSA, testKeyEd25519Private)
+	if err != nil {
+		t.Fatal("signer error:", err)
+	}
+	out, err := ReSign(context.Background(), token, &KeyRegister{Secrets: [][]byte{[]byte("guest")}}, s, func(c *Claims) error {
+		c.Audiences = []string{"backend"}
+		c.Expires = NewNumericTime(time.Unix(1600000600, 0))
+		c.KeyID = "gw1"
+		return nil
+	})
+	if err != nil {
+		t.Fatal("re-sign error:", err)
+	}
+
+	got, err := EdDSACheck(out, testKeyEd25519Public)
+	if err != nil {
+		t.Fatal("check error:", err)
+	}
+	const want = `{"aud":["backend"],"exp":1600000600,"iss":"partner","scope":"read"}`
+	if string(got.Raw) != want {
+		t.Errorf("got claims %s, want %s", got.Raw, want)
+	}
+	if got.KeyID != "gw1" {
+		t.Errorf("got key ID %q, want gw1", got.KeyID)
+	}
+
+	_, err = ReSign(context.Background(), token, &KeyRegister{Secrets: [][]byte{[]byte("other")}}, s, nil)
+	if err != ErrSigMiss {
+		t.Errorf("got error %v for other secret, want %v", err, ErrSigMiss)
+	}
+}
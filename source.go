@@ -0,0 +1,132 @@
+package jwt
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Token is an OAuth 2.0 access token. The fields match golang.org/x/oauth2.Token.
+type Token struct {
+	AccessToken string
+	TokenType   string // "Bearer" when empty
+	Expiry      time.Time
+}
+
+// TokenSource supplies tokens. The method set mirrors TokenSource from the
+// golang.org/x/oauth2 package. This package is free of dependencies, which
+// leaves the conversion to the caller. See example/oauth2 for an adapter.
+type TokenSource interface {
+	Token() (*Token, error)
+}
+
+// ExpiryDelta is the amount of time before expiry at which tokens are renewed.
+const expiryDelta = 10 * time.Second
+
+// ReuseSource caches the token from fetch until expiry.
+type reuseSource struct {
+	mutex   sync.Mutex
+	fetch   func() (*Token, error)
+	current *Token
+}
+
+// Token implements the TokenSource interface.
+func (s *reuseSource) Token() (*Token, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	if t := s.current; t != nil && (t.Expiry.IsZero() || time.Until(t.Expiry) > expiryDelta) {
+		return t, nil
+	}
+	t, err := s.fetch()
+	if err != nil {
+		return nil, err
+	}
+	s.current = t
+	return t, nil
+}
+
+// TokenSource returns self-issued tokens for the subject, with Issue. Tokens
+// are reused until shortly before their expiry.
+func (iss *Issuer) TokenSource(subject string, extraClaims map[string]interface{}) TokenSource {
+	return &reuseSource{fetch: func() (*Token, error) {
+		token, err := iss.Issue(subject, extraClaims)
+		if err != nil {
+			return nil, err
+		}
+		c, err := ParseWithoutCheck(token)
+		if err != nil {
+			return nil, err
+		}
+		return &Token{AccessToken: string(token), TokenType: "Bearer", Expiry: c.Expires.Time()}, nil
+	}}
+}
+
+// TokenTimeout is the time limit for each request of a BearerGrant TokenSource.
+const tokenTimeout = 30 * time.Second
+
+// TokenSource returns access tokens from TokenEndpoint in exchange for a new
+// grant each. Access tokens are reused until shortly before their expiry. The
+// client is optional, with nil for http.DefaultClient. Refreshes run on their
+// own, i.e., no context of any caller applies, with a time limit of 30 seconds
+// per request.
+func (g *BearerGrant) TokenSource(client *http.Client) TokenSource {
+	if client == nil {
+		client = http.DefaultClient
+	}
+	return &reuseSource{fetch: func() (*Token, error) {
+		ctx, cancel := context.WithTimeout(context.Background(), tokenTimeout)
+		defer cancel()
+
+		form := make(url.Values)
+		if err := g.SetForm(ctx, form); err != nil {
+			return nil, err
+		}
+		return requestToken(ctx, client, g.TokenEndpoint, form)
+	}}
+}
+
+// RequestToken executes an access token request, conform RFC 6749, section 4.
+func requestToken(ctx context.Context, client *http.Client, endpoint string, form url.Values) (*Token, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, strings.NewReader(form.Encode()))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	var body struct {
+		AccessToken string `json:"access_token"`
+		TokenType   string `json:"token_type"`
+		ExpiresIn   int64  `json:"expires_in"`
+		Error       string `json:"error"`
+		Description string `json:"error_description"`
+	}
+	if err := json.NewDecoder(io.LimitReader(resp.Body, jwksLimit)).Decode(&body); err != nil {
+		io.Copy(ioutil.Discard, io.LimitReader(resp.Body, jwksLimit))
+		return nil, fmt.Errorf("jwt: token endpoint %q got HTTP %q: %w", endpoint, resp.Status, err)
+	}
+	if body.Error != "" {
+		return nil, fmt.Errorf("jwt: token endpoint %q got error %q: %s", endpoint, body.Error, body.Description)
+	}
+	if resp.StatusCode != http.StatusOK || body.AccessToken == "" {
+		return nil, fmt.Errorf("jwt: token endpoint %q got HTTP %q without access token", endpoint, resp.Status)
+	}
+
+	t := &Token{AccessToken: body.AccessToken, TokenType: body.TokenType}
+	if body.ExpiresIn > 0 {
+		t.Expiry = time.Now().Add(time.Duration(body.ExpiresIn) * time.Second)
+	}
+	return t, nil
+}
@@ -0,0 +1,87 @@
+//go:build !jwt_hmac_only && !jwt_no_rsa
+// +build !jwt_hmac_only,!jwt_no_rsa
+
+package jwt
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestIssuerTokenSource(t *testing.T) {
+	s, err := NewSigner(HS256, []byte("guest"))
+	if err != nil {
+		t.Fatal("signer error:", err)
+	}
+	iss := NewIssuer(s, "")
+	iss.TTL = time.Hour
+
+	src := iss.TokenSource("alice", nil)
+	first, err := src.Token()
+	if err != nil {
+		t.Fatal("token error:", err)
+	}
+	if first.TokenType != "Bearer" || time.Until(first.Expiry) <= 59*time.Minute {
+		t.Errorf("got token type %q, expiry %s", first.TokenType, first.Expiry)
+	}
+	second, err := src.Token()
+	if err != nil {
+		t.Fatal("token error:", err)
+	}
+	if second != first {
+		t.Error("token not reused")
+	}
+}
+
+func TestBearerGrantTokenSource(t *testing.T) {
+	var reqCount int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := atomic.AddInt32(&reqCount, 1)
+		if err := r.ParseForm(); err != nil {
+			t.Error("form error:", err)
+		}
+		if r.PostForm.Get("grant_type") != JWTBearerGrantType {
+			w.WriteHeader(http.StatusBadRequest)
+			fmt.Fprint(w, `{"error":"unsupported_grant_type"}`)
+			return
+		}
+		if _, err := RSACheck([]byte(r.PostForm.Get("assertion")), &testKeyRSA2048.PublicKey); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			fmt.Fprintf(w, `{"error":"invalid_grant","error_description":%q}`, err)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprintf(w, `{"access_token":"at%d","token_type":"Bearer","expires_in":3600}`, n)
+	}))
+	defer srv.Close()
+
+	s, err := NewSigner(RS256, testKeyRSA2048)
+	if err != nil {
+		t.Fatal("signer error:", err)
+	}
+	g := BearerGrant{Issuer: "svc", TokenEndpoint: srv.URL, Signer: s}
+	src := g.TokenSource(srv.Client())
+	for i := 0; i < 2; i++ {
+		token, err := src.Token()
+		if err != nil {
+			t.Fatal("token error:", err)
+		}
+		if token.AccessToken != "at1" || time.Until(token.Expiry) <= 59*time.Minute {
+			t.Errorf("got token %+v", token)
+		}
+	}
+
+	other, err := NewSigner(RS256, testKeyRSA1024)
+	if err != nil {
+		t.Fatal("signer error:", err)
+	}
+	g.Signer = other
+	_, err = g.TokenSource(srv.Client()).Token()
+	if want := fmt.Sprintf("jwt: token endpoint %q got error %q: %s", srv.URL, "invalid_grant", ErrSigMiss); err == nil || err.Error() != want {
+		t.Errorf("got error %v, want %s", err, want)
+	}
+}
@@ -0,0 +1,120 @@
+package jwt
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+)
+
+var errNoHandle = errors.New("jwt: key handle not in store")
+
+// KeyHandle identifies a key in a KeyStore.
+type KeyHandle uint64
+
+// KeyStore is a KeyRegister with updates in place. Each update installs a new
+// register (copy-on-write), which keeps the checks free of locks. Checks which
+// are in progress during an update continue with the previous register.
+//
+// Multiple goroutines may invoke methods on a KeyStore simultaneously.
+// The zero value has no keys.
+type KeyStore struct {
+	mutex   sync.Mutex   // serializes updates
+	current atomic.Value // *KeyRegister
+	entries []storeEntry // content of current
+	last    KeyHandle    // sequence
+}
+
+type storeEntry struct {
+	handle KeyHandle
+	key    interface{}
+	kid    string
+}
+
+// Check parses a JWT if, and only if, the signature checks out.
+// Use Claims.Valid to complete the verification.
+func (s *KeyStore) Check(token []byte) (*Claims, error) {
+	return s.register().check(token, nil)
+}
+
+// CheckTrace is like Check, with each verification step recorded in trace.
+func (s *KeyStore) CheckTrace(token []byte, trace *Trace) (*Claims, error) {
+	return s.register().check(token, trace)
+}
+
+// Keys returns the current register. The register must not be modified.
+func (s *KeyStore) Keys(ctx context.Context) (*KeyRegister, error) {
+	return s.register(), nil
+}
+
+func (s *KeyStore) register() *KeyRegister {
+	keys, _ := s.current.Load().(*KeyRegister)
+	if keys == nil {
+		return new(KeyRegister)
+	}
+	return keys
+}
+
+// Add installs a key with an optional key ID. See KeyRegister for the key
+// types, which include HMAC secrets as a byte slice. Private keys install
+// their public key.
+func (s *KeyStore) Add(key interface{}, kid string) (KeyHandle, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	h := s.last + 1
+	entries := append(s.entries[:len(s.entries):len(s.entries)], storeEntry{h, key, kid})
+	if err := s.install(entries); err != nil {
+		return 0, err
+	}
+	s.last = h
+	return h, nil
+}
+
+// Remove uninstalls the key. The return is false when the handle is not in
+// the store, e.g., when removed already.
+func (s *KeyStore) Remove(h KeyHandle) bool {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	for i, e := range s.entries {
+		if e.handle == h {
+			entries := make([]storeEntry, 0, len(s.entries)-1)
+			entries = append(entries, s.entries[:i]...)
+			entries = append(entries, s.entries[i+1:]...)
+			// removal can't fail
+			s.install(entries)
+			return true
+		}
+	}
+	return false
+}
+
+// Replace swaps the key and its key ID in one update. The handle remains valid.
+func (s *KeyStore) Replace(h KeyHandle, key interface{}, kid string) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	for i, e := range s.entries {
+		if e.handle == h {
+			entries := make([]storeEntry, len(s.entries))
+			copy(entries, s.entries)
+			entries[i] = storeEntry{h, key, kid}
+			return s.install(entries)
+		}
+	}
+	return errNoHandle
+}
+
+// Install sets entries as the content. The caller must hold the mutex.
+func (s *KeyStore) install(entries []storeEntry) error {
+	keys := new(KeyRegister)
+	for _, e := range entries {
+		if err := keys.add(e.key, e.kid); err != nil {
+			return err
+		}
+	}
+	s.current.Store(keys)
+	s.entries = entries
+	return nil
+}
@@ -0,0 +1,99 @@
+//go:build !jwt_hmac_only && !jwt_no_ecdsa
+// +build !jwt_hmac_only,!jwt_no_ecdsa
+
+package jwt
+
+import (
+	"context"
+	"sync"
+	"testing"
+)
+
+func TestKeyStore(t *testing.T) {
+	token, err := new(Claims).EdDSASign(testKeyEd25519Private)
+	if err != nil {
+		t.Fatal("sign error:", err)
+	}
+
+	var s KeyStore
+	if _, err := s.Check(token); err != ErrSigMiss {
+		t.Errorf("got error %v from zero value, want %v", err, ErrSigMiss)
+	}
+
+	secret, err := s.Add([]byte("guest"), "s1")
+	if err != nil {
+		t.Fatal("add error:", err)
+	}
+	h, err := s.Add(testKeyEd25519Public, "e1")
+	if err != nil {
+		t.Fatal("add error:", err)
+	}
+	if _, err := s.Check(token); err != nil {
+		t.Error("check error:", err)
+	}
+	if _, err := s.Add("not a key", ""); err == nil {
+		t.Error("no error for unsupported key type")
+	}
+
+	if err := s.Replace(h, testKeyEC256.Public(), "e2"); err != nil {
+		t.Fatal("replace error:", err)
+	}
+	if _, err := s.Check(token); err != ErrSigMiss {
+		t.Errorf("got error %v after replace, want %v", err, ErrSigMiss)
+	}
+	if !s.Remove(h) {
+		t.Error("remove of replaced key failed")
+	}
+	if s.Remove(h) {
+		t.Error("removed twice")
+	}
+	if err := s.Replace(h, testKeyEd25519Public, ""); err != errNoHandle {
+		t.Errorf("got error %v for removed handle, want %v", err, errNoHandle)
+	}
+
+	keys, _ := s.Keys(context.Background())
+	if len(keys.Secrets) != 1 || keys.SecretIDs[0] != "s1" || len(keys.ECDSAs) != 0 {
+		t.Errorf("got register %+v, want secret s1 only", keys)
+	}
+	if !s.Remove(secret) {
+		t.Error("remove of secret failed")
+	}
+}
+
+func TestKeyStoreConcurrency(t *testing.T) {
+	token, err := new(Claims).EdDSASign(testKeyEd25519Private)
+	if err != nil {
+		t.Fatal("sign error:", err)
+	}
+
+	var s KeyStore
+	if _, err := s.Add(testKeyEd25519Public, ""); err != nil {
+		t.Fatal("add error:", err)
+	}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 100; j++ {
+				if _, err := s.Check(token); err != nil {
+					t.Error("check error:", err)
+					return
+				}
+			}
+		}()
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 100; j++ {
+				h, err := s.Add([]byte("guest"), "")
+				if err != nil {
+					t.Error("add error:", err)
+					return
+				}
+				s.Remove(h)
+			}
+		}()
+	}
+	wg.Wait()
+}
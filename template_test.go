@@ -0,0 +1,107 @@
+//go:build !jwt_hmac_only
+// +build !jwt_hmac_only
+
+package jwt
+
+import (
+	"context"
+	"crypto/ed25519"
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestTemplate(t *testing.T) {
+	s, err := NewSigner(EdDSA, testKeyEd25519Private)
+	if err != nil {
+		t.Fatal("signer error:", err)
+	}
+	now := time.Unix(1600000000, 4e8)
+	iss := NewIssuer(s, "fleet")
+	iss.Name = "https://example.com"
+	iss.Audiences = []string{"telemetry"}
+	iss.TTL = time.Hour
+	iss.Now = func() time.Time { return now }
+	extra := map[string]interface{}{"model": "X1", "sub": "overruled", "zones": []interface{}{"eu"}}
+
+	tmpl, err := iss.Template(extra)
+	if err != nil {
+		t.Fatal("template error:", err)
+	}
+
+	var events []SignEvent
+	AuditSign = func(e SignEvent) { events = append(events, e) }
+	defer func() { AuditSign = nil }()
+	token, err := tmpl.Issue(context.Background(), `device "7"`)
+	if err != nil {
+		t.Fatal("issue error:", err)
+	}
+	want, err := iss.Issue(`device "7"`, extra)
+	if err != nil {
+		t.Fatal("issuer error:", err)
+	}
+	if len(events) != 2 {
+		t.Fatalf("got %d audit events, want 2", len(events))
+	}
+	events[0].ID, events[1].ID = "", ""
+	if !reflect.DeepEqual(events[0], events[1]) {
+		t.Errorf("got audit event %+v, want %+v", events[0], events[1])
+	}
+
+	keys := &KeyRegister{EdDSAs: []ed25519.PublicKey{testKeyEd25519Public}, EdDSAIDs: []string{"fleet"}}
+	got, err := keys.Check(token)
+	if err != nil {
+		t.Fatal("check error:", err)
+	}
+	wantClaims, err := keys.Check(want)
+	if err != nil {
+		t.Fatal("check error:", err)
+	}
+	if got.ID == "" || got.ID == wantClaims.ID {
+		t.Errorf("got jti %q, want a new one", got.ID)
+	}
+	got.ID, wantClaims.ID = "", ""
+	if !reflect.DeepEqual(got.Registered, wantClaims.Registered) {
+		t.Errorf("got registered claims %+v, want %+v", got.Registered, wantClaims.Registered)
+	}
+	if !reflect.DeepEqual(got.Set, wantClaims.Set) {
+		t.Errorf("got claims %v, want %v", got.Set, wantClaims.Set)
+	}
+	if string(got.RawHeader) != string(wantClaims.RawHeader) {
+		t.Errorf("got JOSE header %s, want %s", got.RawHeader, wantClaims.RawHeader)
+	}
+}
+
+func TestTemplateMinimal(t *testing.T) {
+	s, err := NewSigner(EdDSA, testKeyEd25519Private)
+	if err != nil {
+		t.Fatal("signer error:", err)
+	}
+	tmpl, err := NewIssuer(s, "").Template(nil)
+	if err != nil {
+		t.Fatal("template error:", err)
+	}
+	token, err := tmpl.Issue(context.Background(), "")
+	if err != nil {
+		t.Fatal("issue error:", err)
+	}
+	c, err := EdDSACheck(token, testKeyEd25519Public)
+	if err != nil {
+		t.Fatal("check error:", err)
+	}
+	if c.Subject != "" || c.Expires != nil || c.Issued == nil || len(c.Set) != 0 {
+		t.Errorf("got claims %s", c.Raw)
+	}
+
+	for _, name := range []string{"iss", "aud", "nbf"} {
+		_, err := NewIssuer(s, "").Template(map[string]interface{}{name: "x"})
+		if !errors.Is(err, errTemplateClaim) {
+			t.Errorf("got error %v for extra claim %q, want %v", err, name, errTemplateClaim)
+		}
+	}
+
+	if _, err := new(Issuer).Template(nil); err != errNoSigner {
+		t.Errorf("got error %v, want %v", err, errNoSigner)
+	}
+}
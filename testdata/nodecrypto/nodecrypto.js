@@ -0,0 +1,238 @@
+'use strict';
+// JOSE counterpart for the interoperability tests in the Go package, with nothing
+// but the Node.js (v16+) standard library.
+//
+//	node nodecrypto.js fixtures > node.json
+//	node nodecrypto.js verify < cases.json
+//
+// The fixtures mode prints newly signed tokens, including deliberate format
+// deviations which must be rejected. The verify mode reads a JSON array of
+// {"name", "alg", "jwk", "token"} and prints an array of {"name", "ok",
+// "error", "header", "claims"} results.
+
+const crypto = require('crypto');
+
+const algs = {
+	HS256: {kind: 'oct', hash: 'sha256'},
+	HS384: {kind: 'oct', hash: 'sha384'},
+	HS512: {kind: 'oct', hash: 'sha512'},
+	RS256: {kind: 'RSA', hash: 'sha256'},
+	RS384: {kind: 'RSA', hash: 'sha384'},
+	RS512: {kind: 'RSA', hash: 'sha512'},
+	PS256: {kind: 'RSA', hash: 'sha256', pss: 32},
+	PS384: {kind: 'RSA', hash: 'sha384', pss: 48},
+	PS512: {kind: 'RSA', hash: 'sha512', pss: 64},
+	ES256: {kind: 'EC', hash: 'sha256', curve: 'P-256'},
+	ES384: {kind: 'EC', hash: 'sha384', curve: 'P-384'},
+	ES512: {kind: 'EC', hash: 'sha512', curve: 'P-521'},
+	EdDSA: {kind: 'OKP'},
+};
+
+const b64u = (data) => Buffer.from(data).toString('base64url');
+
+function newKey(alg) {
+	const a = algs[alg];
+	switch (a.kind) {
+	case 'oct': {
+		const secret = crypto.randomBytes(32);
+		return {secret, jwk: {kty: 'oct', k: b64u(secret)}};
+	}
+	case 'RSA': {
+		const {privateKey, publicKey} = crypto.generateKeyPairSync('rsa', {modulusLength: 2048});
+		return {privateKey, jwk: publicKey.export({format: 'jwk'})};
+	}
+	case 'EC': {
+		const {privateKey, publicKey} = crypto.generateKeyPairSync('ec', {namedCurve: a.curve});
+		return {privateKey, jwk: publicKey.export({format: 'jwk'})};
+	}
+	case 'OKP': {
+		const {privateKey, publicKey} = crypto.generateKeyPairSync('ed25519');
+		return {privateKey, jwk: publicKey.export({format: 'jwk'})};
+	}
+	}
+}
+
+function sign(alg, key, input, options = {}) {
+	const a = algs[alg];
+	switch (a.kind) {
+	case 'oct':
+		return crypto.createHmac(a.hash, key.secret).update(input).digest();
+	case 'RSA':
+		if (a.pss) {
+			return crypto.sign(a.hash, Buffer.from(input), {
+				key: key.privateKey,
+				padding: crypto.constants.RSA_PKCS1_PSS_PADDING,
+				saltLength: a.pss,
+			});
+		}
+		return crypto.sign(a.hash, Buffer.from(input), key.privateKey);
+	case 'EC':
+		return crypto.sign(a.hash, Buffer.from(input), {
+			key: key.privateKey,
+			dsaEncoding: options.der ? 'der' : 'ieee-p1363',
+		});
+	case 'OKP':
+		return crypto.sign(null, Buffer.from(input), key.privateKey);
+	}
+}
+
+function compose(alg, key, header, claims, options = {}) {
+	const input = b64u(JSON.stringify(header)) + '.' + b64u(JSON.stringify(claims));
+	return input + '.' + b64u(sign(alg, key, input, options));
+}
+
+const claims = {
+	iss: 'node',
+	sub: 'interop',
+	aud: 'go',
+	exp: 2000000000,
+	iat: 1600000000,
+	scope: 'read write',
+	ratio: 1.5,
+	text: 'π ☃  ',
+};
+
+function fixtures() {
+	const cases = [];
+	for (const alg of Object.keys(algs)) {
+		const key = newKey(alg);
+		cases.push({
+			name: alg,
+			alg,
+			jwk: key.jwk,
+			token: compose(alg, key, {alg, typ: 'JWT'}, claims),
+			claims,
+		});
+	}
+
+	// claim typing
+	const key = newKey('EdDSA');
+	const typed = {
+		aud: ['go', 'rust'],
+		exp: 2000000000.25,
+		nbf: 1600000000,
+		big: 9007199254740993,
+		nested: {list: [1, 'two', null, true]},
+	};
+	cases.push({
+		name: 'claim-typing',
+		alg: 'EdDSA',
+		jwk: key.jwk,
+		token: compose('EdDSA', key, {alg: 'EdDSA', kid: 'node'}, typed),
+		claims: typed,
+	});
+
+	// signature format: ECDSA in ASN.1 DER, as opposed to R || S
+	const ec = newKey('ES256');
+	cases.push({
+		name: 'ecdsa-der',
+		alg: 'ES256',
+		jwk: ec.jwk,
+		token: compose('ES256', ec, {alg: 'ES256'}, claims, {der: true}),
+		reject: 'ECDSA signature in DER instead of R || S',
+	});
+
+	// base64 handling
+	const hs = newKey('HS256');
+	const padded = compose('HS256', hs, {alg: 'HS256'}, {sub: 'a'}).split('.');
+	padded[1] = Buffer.from(padded[1], 'base64url').toString('base64').replace(/\+/g, '-').replace(/\//g, '_');
+	cases.push({
+		name: 'base64-padding',
+		alg: 'HS256',
+		jwk: hs.jwk,
+		token: padded.join('.'),
+		reject: 'payload with "=" padding',
+	});
+	for (let i = 0; ; i++) {
+		const token = compose('HS256', hs, {alg: 'HS256'}, {sub: 'b', i});
+		const sig = token.split('.')[2];
+		if (/[-_]/.test(sig)) {
+			cases.push({
+				name: 'base64-standard-alphabet',
+				alg: 'HS256',
+				jwk: hs.jwk,
+				token: token.replace(/-/g, '+').replace(/_/g, '/'),
+				reject: 'signature in standard base64 alphabet',
+			});
+			break;
+		}
+	}
+	cases.push({
+		name: 'alg-none',
+		alg: 'HS256',
+		jwk: hs.jwk,
+		token: b64u(JSON.stringify({alg: 'none'})) + '.' + b64u(JSON.stringify(claims)) + '.',
+		reject: 'unsecured',
+	});
+
+	const out = {generator: 'Node.js ' + process.version + ' crypto', cases};
+	process.stdout.write(JSON.stringify(out, null, '\t') + '\n');
+}
+
+function verifyOne(c) {
+	const parts = c.token.split('.');
+	if (parts.length !== 3) throw new Error('not 3 parts');
+	for (const p of parts) {
+		if (!/^[A-Za-z0-9_-]*$/.test(p)) throw new Error('not base64url without padding');
+	}
+	const header = JSON.parse(Buffer.from(parts[0], 'base64url').toString('utf8'));
+	if (header.alg !== c.alg) throw new Error('header alg ' + JSON.stringify(header.alg));
+	const input = Buffer.from(parts[0] + '.' + parts[1]);
+	const sig = Buffer.from(parts[2], 'base64url');
+
+	const a = algs[c.alg];
+	let ok;
+	switch (a.kind) {
+	case 'oct': {
+		const want = crypto.createHmac(a.hash, Buffer.from(c.jwk.k, 'base64url')).update(input).digest();
+		ok = want.length === sig.length && crypto.timingSafeEqual(want, sig);
+		break;
+	}
+	case 'RSA': {
+		const key = crypto.createPublicKey({key: c.jwk, format: 'jwk'});
+		ok = crypto.verify(a.hash, input, a.pss ? {
+			key,
+			padding: crypto.constants.RSA_PKCS1_PSS_PADDING,
+			saltLength: a.pss,
+		} : key, sig);
+		break;
+	}
+	case 'EC': {
+		const key = crypto.createPublicKey({key: c.jwk, format: 'jwk'});
+		ok = crypto.verify(a.hash, input, {key, dsaEncoding: 'ieee-p1363'}, sig);
+		break;
+	}
+	case 'OKP':
+		ok = crypto.verify(null, input, crypto.createPublicKey({key: c.jwk, format: 'jwk'}), sig);
+		break;
+	}
+	if (!ok) throw new Error('signature mismatch');
+	return {header, claims: JSON.parse(Buffer.from(parts[1], 'base64url').toString('utf8'))};
+}
+
+function verify() {
+	const chunks = [];
+	process.stdin.on('data', (chunk) => chunks.push(chunk));
+	process.stdin.on('end', () => {
+		const results = JSON.parse(Buffer.concat(chunks).toString('utf8')).map((c) => {
+			try {
+				return {name: c.name, ok: true, ...verifyOne(c)};
+			} catch (e) {
+				return {name: c.name, ok: false, error: e.message};
+			}
+		});
+		process.stdout.write(JSON.stringify(results) + '\n');
+	});
+}
+
+switch (process.argv[2]) {
+case 'fixtures':
+	fixtures();
+	break;
+case 'verify':
+	verify();
+	break;
+default:
+	process.stderr.write('usage: node nodecrypto.js fixtures | verify\n');
+	process.exit(2);
+}
@@ -0,0 +1,222 @@
+package jwt
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"crypto/sha256"
+	"fmt"
+	"math/big"
+	"strings"
+	"time"
+)
+
+// Verification stages in a Trace.
+const (
+	StageParse     = "parse"     // token decoding
+	StageKeys      = "keys"      // key resolution
+	StageRefresh   = "refresh"   // key resolution after a mismatch
+	StageSignature = "signature" // one key attempt
+	StagePayload   = "payload"   // claims decoding
+	StagePolicy    = "policy"    // claims constraints
+)
+
+// Trace records verification steps for diagnostics. Traces should not be
+// shared between checks.
+type Trace struct {
+	Steps []TraceStep
+}
+
+// TraceStep is an entry in a Trace.
+type TraceStep struct {
+	Stage string // one of the Stage constants
+
+	// Signature stages identify the key attempted with the key ID when
+	// registered, and with the “JSON Web Key (JWK) Thumbprint” from RFC
+	// 7638 otherwise. Secrets are not identified other than by key ID.
+	KeyID      string
+	Thumbprint string
+
+	Err      error         // nil for pass
+	Duration time.Duration // time spent
+}
+
+// String returns a report with one line per step.
+func (t *Trace) String() string {
+	var buf strings.Builder
+	for _, step := range t.Steps {
+		buf.WriteString(step.Stage)
+		if step.KeyID != "" {
+			fmt.Fprintf(&buf, " kid=%q", step.KeyID)
+		}
+		if step.Thumbprint != "" {
+			fmt.Fprintf(&buf, " thumbprint=%s", step.Thumbprint)
+		}
+		fmt.Fprintf(&buf, " %s", step.Duration)
+		if step.Err != nil {
+			fmt.Fprintf(&buf, " error: %s", step.Err)
+		}
+		buf.WriteByte('\n')
+	}
+	return buf.String()
+}
+
+// Start returns the current time, or zero for nil.
+func (t *Trace) start() time.Time {
+	if t == nil {
+		return time.Time{}
+	}
+	return time.Now()
+}
+
+// Add records a step since start. It is a no-op on nil.
+func (t *Trace) add(stage, kid, thumbprint string, err error, start time.Time) {
+	if t == nil {
+		return
+	}
+	t.Steps = append(t.Steps, TraceStep{
+		Stage:      stage,
+		KeyID:      kid,
+		Thumbprint: thumbprint,
+		Err:        err,
+		Duration:   time.Since(start),
+	})
+}
+
+// AddKey records a signature attempt on key index i since start. Public keys
+// without key ID get a thumbprint. It is a no-op on nil.
+func (t *Trace) addKey(ids []string, i int, key interface{}, match bool, start time.Time) {
+	if t == nil {
+		return
+	}
+	var kid, thumbprint string
+	if i < len(ids) {
+		kid = ids[i]
+	}
+	if kid == "" && key != nil {
+		thumbprint, _ = Thumbprint(key)
+	}
+	var err error
+	if !match {
+		err = ErrSigMiss
+	}
+	t.add(StageSignature, kid, thumbprint, err, start)
+}
+
+// CheckTrace is like Check, with each verification step recorded in trace.
+func (r *RemoteKeys) CheckTrace(token []byte, trace *Trace) (*Claims, error) {
+	start := trace.start()
+	keys, err := r.Keys(context.Background())
+	trace.add(StageKeys, "", "", err, start)
+	if err != nil {
+		return nil, err
+	}
+	c, err := keys.check(token, trace)
+	if err != ErrSigMiss {
+		return c, err
+	}
+
+	// key rotation
+	start = trace.start()
+	refreshed, err := r.refresh(context.Background(), keys)
+	trace.add(StageRefresh, "", "", err, start)
+	if err != nil {
+		return nil, err
+	}
+	if refreshed == keys {
+		return nil, ErrSigMiss
+	}
+	return refreshed.check(token, trace)
+}
+
+// CheckTrace is like Check, with each verification step recorded in trace.
+func (v *Verifier) CheckTrace(token []byte, trace *Trace) (*Claims, error) {
+	var c *Claims
+	var err error
+	if tracer, ok := v.Keys.(interface {
+		CheckTrace([]byte, *Trace) (*Claims, error)
+	}); ok {
+		c, err = tracer.CheckTrace(token, trace)
+	} else {
+		start := trace.start()
+		c, err = v.Keys.Check(token)
+		trace.add(StageSignature, "", "", err, start)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now
+	if v.Now != nil {
+		now = v.Now
+	}
+	start := trace.start()
+	err = v.Apply(c, now())
+	trace.add(StagePolicy, "", "", err, start)
+	if err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+// Thumbprint returns the “JSON Web Key (JWK) Thumbprint” of a public key or
+// of the public part of a private key, conform RFC 7638 with SHA-256.
+func Thumbprint(key interface{}) (string, error) {
+	members, err := thumbprintMembers(key)
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256(members)
+	return encoding.EncodeToString(sum[:]), nil
+}
+
+// ThumbprintMembers returns the required members of the public JWK in
+// lexicographic order, without whitespace, as described in RFC 7638,
+// section 3.2.
+func thumbprintMembers(key interface{}) ([]byte, error) {
+	switch k := key.(type) {
+	case *ecdsa.PrivateKey:
+		return thumbprintMembers(&k.PublicKey)
+	case ed25519.PrivateKey:
+		return thumbprintMembers(k.Public())
+	case *rsa.PrivateKey:
+		return thumbprintMembers(&k.PublicKey)
+
+	case *ecdsa.PublicKey:
+		params := k.Curve.Params()
+		switch params.Name {
+		case "P-256", "P-384", "P-521":
+			break
+		default:
+			return nil, fmt.Errorf("jwt: unsupported elliptic curve %q", params.Name)
+		}
+		size := (params.BitSize + 7) / 8
+		return []byte(fmt.Sprintf(`{"crv":%q,"kty":"EC","x":%q,"y":%q}`, params.Name,
+			encoding.EncodeToString(padBytes(k.X, size)),
+			encoding.EncodeToString(padBytes(k.Y, size)))), nil
+
+	case ed25519.PublicKey:
+		return []byte(fmt.Sprintf(`{"crv":"Ed25519","kty":"OKP","x":%q}`,
+			encoding.EncodeToString(k))), nil
+
+	case *rsa.PublicKey:
+		return []byte(fmt.Sprintf(`{"e":%q,"kty":"RSA","n":%q}`,
+			encoding.EncodeToString(big.NewInt(int64(k.E)).Bytes()),
+			encoding.EncodeToString(k.N.Bytes()))), nil
+
+	default:
+		return nil, fmt.Errorf("jwt: unsupported key type %T", key)
+	}
+}
+
+// PadBytes returns the big-endian representation with leading zeros to size.
+func padBytes(i *big.Int, size int) []byte {
+	b := i.Bytes()
+	if len(b) >= size {
+		return b
+	}
+	padded := make([]byte, size)
+	copy(padded[size-len(b):], b)
+	return padded
+}
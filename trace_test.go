@@ -0,0 +1,103 @@
+//go:build !jwt_hmac_only && !jwt_no_rsa
+// +build !jwt_hmac_only,!jwt_no_rsa
+
+package jwt
+
+import (
+	"crypto/ed25519"
+	"strings"
+	"testing"
+)
+
+func TestThumbprint(t *testing.T) {
+	// example from RFC 7638, subsection 3.1
+	const jwk = `{"kty":"RSA","n":"0vx7agoebGcQSuuPiLJXZptN9nndrQmbXEps2aiAFbWhM78LhWx4cbbfAAtVT86zwu1RK7aPFFxuhDR1L6tSoc_BJECPebWKRXjBZCiFV4n3oknjhMstn64tZ_2W-5JsGY4Hc5n9yBXArwl93lqt7_RN5w6Cf0h4QyQ5v-65YGjQR0_FDW2QvzqY368QQMicAtaSqzs8KJZgnYb9c7d0zgdAZHzu6qMQvRL5hajrn1n91CbOpbISD08qNLyrdkt-bFTWhAI4vMQFh6WeZu0fM4lFd2NcRwr3XPksINHaQ-G_xBniIqbw0Ls1jF44-csFCur-kEgU8awapJzKnqDKgw","e":"AQAB"}`
+	keys := new(KeyRegister)
+	if _, err := keys.LoadJWK([]byte(jwk)); err != nil {
+		t.Fatal("JWK load error:", err)
+	}
+	got, err := Thumbprint(keys.RSAs[0])
+	if err != nil {
+		t.Fatal("thumbprint error:", err)
+	}
+	if want := "NzbLsXh8uDCcd-6MNwXF4W_7noWXFZAfHkxZsRGC9Xs"; got != want {
+		t.Errorf("got thumbprint %q, want %q", got, want)
+	}
+
+	// private keys get the thumbprint of their public key
+	private, err := Thumbprint(testKeyEd25519Private)
+	if err != nil {
+		t.Fatal("thumbprint error:", err)
+	}
+	public, err := Thumbprint(testKeyEd25519Public)
+	if err != nil {
+		t.Fatal("thumbprint error:", err)
+	}
+	if private != public {
+		t.Errorf("got private key thumbprint %q, want %q", private, public)
+	}
+
+	if _, err := Thumbprint([]byte("secret")); err == nil {
+		t.Error("no error for secret")
+	}
+}
+
+func TestKeyRegisterCheckTrace(t *testing.T) {
+	token, err := new(Claims).EdDSASign(testKeyEd25519Private)
+	if err != nil {
+		t.Fatal("sign error:", err)
+	}
+
+	_, other, err := ed25519.GenerateKey(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	keys := KeyRegister{
+		EdDSAs:   []ed25519.PublicKey{other.Public().(ed25519.PublicKey), testKeyEd25519Public},
+		EdDSAIDs: []string{"other"},
+	}
+
+	var trace Trace
+	if _, err := keys.CheckTrace(token, &trace); err != nil {
+		t.Fatal("check error:", err)
+	}
+	thumbprint, _ := Thumbprint(testKeyEd25519Public)
+	want := []TraceStep{
+		{Stage: StageParse},
+		{Stage: StageSignature, KeyID: "other", Err: ErrSigMiss},
+		{Stage: StageSignature, Thumbprint: thumbprint},
+		{Stage: StagePayload},
+	}
+	if len(trace.Steps) != len(want) {
+		t.Fatalf("got trace:\n%s", &trace)
+	}
+	for i, step := range trace.Steps {
+		step.Duration = 0
+		if step != want[i] {
+			t.Errorf("step %d: got %+v, want %+v", i, step, want[i])
+		}
+	}
+	if s := trace.String(); !strings.Contains(s, `signature kid="other"`) {
+		t.Errorf("got report %q", s)
+	}
+}
+
+func TestVerifierCheckTrace(t *testing.T) {
+	token, err := new(Claims).EdDSASign(testKeyEd25519Private)
+	if err != nil {
+		t.Fatal("sign error:", err)
+	}
+
+	v := Verifier{
+		Keys:   &KeyRegister{EdDSAs: []ed25519.PublicKey{testKeyEd25519Public}},
+		Policy: Policy{Require: []string{"sub"}},
+	}
+	var trace Trace
+	if _, err := v.CheckTrace(token, &trace); err == nil {
+		t.Fatal("no error for absent subject")
+	}
+	last := trace.Steps[len(trace.Steps)-1]
+	if last.Stage != StagePolicy || last.Err == nil {
+		t.Errorf("got last step %+v, want policy error", last)
+	}
+}
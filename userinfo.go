@@ -0,0 +1,153 @@
+package jwt
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"mime"
+	"net/http"
+	"sync"
+	"time"
+)
+
+var errUserInfoSubject = errors.New("jwt: UserInfo sub does not match the token")
+
+// UserInfo is a client for the UserInfo endpoint from “OpenID Connect Core
+// 1.0”, section 5.3. Responses are cached per subject and token, within TTL.
+//
+// Multiple goroutines may invoke methods on a UserInfo simultaneously.
+type UserInfo struct {
+	// Endpoint is the location, e.g., the UserInfoEndpoint from
+	// FetchOpenIDConfiguration.
+	Endpoint string
+
+	// Client is used for the HTTP requests. Nil defaults to
+	// http.DefaultClient.
+	Client *http.Client
+
+	// Keys verifies signed responses, i.e., those with an application/jwt
+	// content type. Use a Verifier to enforce the "iss" and "aud" claims
+	// too. Nil rejects signed responses.
+	Keys Checker
+
+	// TTL is the amount of time responses remain in use. Zero disables
+	// the cache.
+	TTL time.Duration
+
+	mutex sync.Mutex
+	cache map[string]*userInfoEntry
+	swept time.Time
+}
+
+type userInfoEntry struct {
+	claims  map[string]interface{}
+	expires time.Time
+}
+
+// Enrich fetches the UserInfo with accessToken, which must be the origin of c,
+// and it adds the claims which are absent in c to its Set. The UserInfo must
+// have the same subject as c, as required by the specification.
+func (u *UserInfo) Enrich(ctx context.Context, accessToken []byte, c *Claims) error {
+	info, err := u.Fetch(ctx, accessToken, c.Subject)
+	if err != nil {
+		return err
+	}
+	if c.Set == nil {
+		c.Set = make(map[string]interface{}, len(info))
+	}
+	for name, value := range info {
+		if !c.has(name) {
+			c.Set[name] = value
+		}
+	}
+	return nil
+}
+
+// Fetch returns the UserInfo claims for a verified access token. The "sub" claim
+// in the response must match sub.
+func (u *UserInfo) Fetch(ctx context.Context, accessToken []byte, sub string) (map[string]interface{}, error) {
+	key := sub + " " + TokenDigest(accessToken)
+	if u.TTL > 0 {
+		u.mutex.Lock()
+		e, ok := u.cache[key]
+		u.mutex.Unlock()
+		if ok && time.Now().Before(e.expires) {
+			return e.claims, nil
+		}
+	}
+
+	info, err := u.request(ctx, accessToken)
+	if err != nil {
+		return nil, err
+	}
+	if s, _ := info[subject].(string); s != sub {
+		return nil, errUserInfoSubject
+	}
+
+	if u.TTL > 0 {
+		now := time.Now()
+		u.mutex.Lock()
+		if u.cache == nil {
+			u.cache = make(map[string]*userInfoEntry)
+			u.swept = now
+		}
+		if now.Sub(u.swept) >= time.Minute {
+			for k, e := range u.cache {
+				if !now.Before(e.expires) {
+					delete(u.cache, k)
+				}
+			}
+			u.swept = now
+		}
+		u.cache[key] = &userInfoEntry{info, now.Add(u.TTL)}
+		u.mutex.Unlock()
+	}
+	return info, nil
+}
+
+func (u *UserInfo) request(ctx context.Context, accessToken []byte) (map[string]interface{}, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u.Endpoint, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Authorization", "Bearer "+string(accessToken))
+	req.Header.Set("Accept", "application/json, "+MIMEType)
+	client := u.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	body, err := ioutil.ReadAll(io.LimitReader(resp.Body, jwksLimit))
+	if err != nil {
+		return nil, fmt.Errorf("jwt: UserInfo %q unavailable: %w", u.Endpoint, err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("jwt: UserInfo %q got HTTP %q", u.Endpoint, resp.Status)
+	}
+
+	var info map[string]interface{}
+	mediaType, _, _ := mime.ParseMediaType(resp.Header.Get("Content-Type"))
+	if mediaType == MIMEType {
+		if u.Keys == nil {
+			return nil, fmt.Errorf("jwt: UserInfo %q signed without Keys to verify", u.Endpoint)
+		}
+		c, err := u.Keys.Check(body)
+		if err != nil {
+			return nil, err
+		}
+		if err := c.DecodeSet(&info); err != nil {
+			return nil, err
+		}
+	} else if err := json.Unmarshal(body, &info); err != nil {
+		return nil, fmt.Errorf("jwt: UserInfo %q response: %w", u.Endpoint, err)
+	}
+	return info, nil
+}
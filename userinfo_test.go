@@ -0,0 +1,73 @@
+//go:build !jwt_hmac_only
+// +build !jwt_hmac_only
+
+package jwt
+
+import (
+	"context"
+	"crypto/ed25519"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestUserInfo(t *testing.T) {
+	var reqCount int32
+	signed := new(Claims)
+	signed.Subject = "u1"
+	signed.Set = map[string]interface{}{"email": "alice@example.com"}
+	jwt, err := signed.EdDSASign(testKeyEd25519Private)
+	if err != nil {
+		t.Fatal("sign error:", err)
+	}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&reqCount, 1)
+		switch r.Header.Get("Authorization") {
+		case "Bearer at1":
+			w.Header().Set("Content-Type", "application/json")
+			w.Write([]byte(`{"sub":"u1","name":"Alice","iss":"other"}`))
+		case "Bearer at2":
+			w.Header().Set("Content-Type", "application/jwt")
+			w.Write(jwt)
+		default:
+			http.Error(w, "invalid token", http.StatusUnauthorized)
+		}
+	}))
+	defer srv.Close()
+
+	u := UserInfo{Endpoint: srv.URL, TTL: time.Minute}
+	c := &Claims{Registered: Registered{Subject: "u1", Issuer: "idp"}}
+	if err := u.Enrich(context.Background(), []byte("at1"), c); err != nil {
+		t.Fatal("enrich error:", err)
+	}
+	if name, _ := c.String("name"); name != "Alice" || c.Issuer != "idp" || c.Set["iss"] != nil {
+		t.Errorf("got claims %+v, want name added and issuer kept", c)
+	}
+	if _, err := u.Fetch(context.Background(), []byte("at1"), "u1"); err != nil {
+		t.Error("fetch error:", err)
+	}
+	if n := atomic.LoadInt32(&reqCount); n != 1 {
+		t.Errorf("got %d HTTP requests, want 1 with cache", n)
+	}
+	if _, err := u.Fetch(context.Background(), []byte("at1"), "u2"); err != errUserInfoSubject {
+		t.Errorf("got error %v for other subject, want %v", err, errUserInfoSubject)
+	}
+
+	if _, err := u.Fetch(context.Background(), []byte("at2"), "u1"); err == nil {
+		t.Error("signed response accepted without keys")
+	}
+	u.Keys = &KeyRegister{EdDSAs: []ed25519.PublicKey{testKeyEd25519Public}}
+	info, err := u.Fetch(context.Background(), []byte("at2"), "u1")
+	if err != nil {
+		t.Fatal("signed fetch error:", err)
+	}
+	if info["email"] != "alice@example.com" {
+		t.Errorf("got signed info %v, want email", info)
+	}
+
+	if _, err := u.Fetch(context.Background(), []byte("at3"), "u1"); err == nil {
+		t.Error("no error for HTTP 401")
+	}
+}
@@ -1,11 +1,12 @@
 package jwt
 
 import (
+	"bytes"
 	"context"
-	"crypto/ecdsa"
-	"crypto/ed25519"
-	"crypto/rsa"
 	"errors"
+	"io"
+	"io/ioutil"
+	"mime"
 	"net/http"
 	"strconv"
 	"strings"
@@ -23,24 +24,50 @@ var ErrNoHeader = errors.New("jwt: no HTTP authorization header")
 
 var errAuthSchema = errors.New("jwt: want Bearer schema")
 
-// ECDSACheckHeader applies ECDSACheck on an HTTP request.
-// Specifically it looks for a bearer token in the Authorization header.
-func ECDSACheckHeader(r *http.Request, key *ecdsa.PublicKey) (*Claims, error) {
-	token, err := tokenFromHeader(r)
+var errProxyDirect = errors.New("jwt: proxy authorization with direct authorization header")
+
+var errBodyType = errors.New("jwt: request body not a JWT media type")
+
+// BodyLimit is the maximum number of bytes read from a request body.
+const bodyLimit = 1 << 20
+
+// TokenFromBody reads the request body as a token. The content type must be
+// either application/jwt, or any other with the "+jwt" suffix, like the
+// application/secevent+jwt from SET delivery (RFC 8935). Any whitespace around
+// the token is ignored.
+func TokenFromBody(r *http.Request) ([]byte, error) {
+	mediaType, _, err := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	if err != nil || (mediaType != MIMEType && !(strings.HasPrefix(mediaType, "application/") && strings.HasSuffix(mediaType, "+jwt"))) {
+		return nil, errBodyType
+	}
+	body, err := ioutil.ReadAll(io.LimitReader(r.Body, bodyLimit))
 	if err != nil {
 		return nil, err
 	}
-	return ECDSACheck(token, key)
+	return bytes.TrimSpace(body), nil
 }
 
-// EdDSACheckHeader applies EdDSACheck on an HTTP request.
-// Specifically it looks for a bearer token in the Authorization header.
-func EdDSACheckHeader(r *http.Request, key ed25519.PublicKey) (*Claims, error) {
-	token, err := tokenFromHeader(r)
+// CheckBody applies Check of keys on the request body. See TokenFromBody for
+// details.
+func CheckBody(r *http.Request, keys Checker) (*Claims, error) {
+	token, err := TokenFromBody(r)
 	if err != nil {
 		return nil, err
 	}
-	return EdDSACheck(token, key)
+	return keys.Check(token)
+}
+
+// WriteToken sends token as the response body with status code 200 (OK). The
+// media type defaults to application/jwt when empty.
+func WriteToken(w http.ResponseWriter, mediaType string, token []byte) error {
+	if mediaType == "" {
+		mediaType = MIMEType
+	}
+	w.Header().Set("Content-Type", mediaType)
+	w.Header().Set("Content-Length", strconv.Itoa(len(token)))
+	w.Header().Set("Cache-Control", "no-store")
+	_, err := w.Write(token)
+	return err
 }
 
 // HMACCheckHeader applies HMACCheck on an HTTP request.
@@ -63,16 +90,6 @@ func (h *HMAC) CheckHeader(r *http.Request) (*Claims, error) {
 	return h.Check(token)
 }
 
-// RSACheckHeader applies RSACheck on an HTTP request.
-// Specifically it looks for a bearer token in the Authorization header.
-func RSACheckHeader(r *http.Request, key *rsa.PublicKey) (*Claims, error) {
-	token, err := tokenFromHeader(r)
-	if err != nil {
-		return nil, err
-	}
-	return RSACheck(token, key)
-}
-
 // CheckHeader applies KeyRegister.Check on an HTTP request.
 // Specifically it looks for a bearer token in the Authorization header.
 func (keys *KeyRegister) CheckHeader(r *http.Request) (*Claims, error) {
@@ -84,7 +101,24 @@ func (keys *KeyRegister) CheckHeader(r *http.Request) (*Claims, error) {
 }
 
 func tokenFromHeader(r *http.Request) ([]byte, error) {
-	h := r.Header["Authorization"]
+	return tokenFromHeaders(r, nil, nil)
+}
+
+// TokenFromHeaders looks for a token in the first of names present, with nil
+// for Authorization only. The value must start with any of schemes, with nil
+// for Bearer only.
+func tokenFromHeaders(r *http.Request, names, schemes []string) ([]byte, error) {
+	var h []string
+	if names == nil {
+		h = r.Header["Authorization"]
+	} else {
+		for _, name := range names {
+			h = r.Header[http.CanonicalHeaderKey(name)]
+			if h != nil {
+				break
+			}
+		}
+	}
 	if h == nil {
 		return nil, ErrNoHeader
 	}
@@ -95,36 +129,17 @@ func tokenFromHeader(r *http.Request) ([]byte, error) {
 	// — “Hypertext Transfer Protocol” RFC 2616, subsection 4.2
 	auth := strings.Join(h, ", ")
 
-	const prefix = "Bearer "
-	if !strings.HasPrefix(auth, prefix) {
+	if schemes == nil {
+		schemes = []string{"Bearer"}
+	}
+	for _, scheme := range schemes {
+		prefix := scheme + " "
 		// RFC 2617, subsection 1.2 defines the scheme token as case-insensitive.
-		if len(auth) < len(prefix) || !strings.EqualFold(auth[:len(prefix)], prefix) {
-			return nil, errAuthSchema
+		if len(auth) >= len(prefix) && strings.EqualFold(auth[:len(prefix)], prefix) {
+			return []byte(auth[len(prefix):]), nil
 		}
 	}
-	return []byte(auth[len(prefix):]), nil
-}
-
-// ECDSASignHeader applies ECDSASign on an HTTP request.
-// Specifically it sets a bearer token in the Authorization header.
-func (c *Claims) ECDSASignHeader(r *http.Request, alg string, key *ecdsa.PrivateKey) error {
-	token, err := c.ECDSASign(alg, key)
-	if err != nil {
-		return err
-	}
-	r.Header.Set("Authorization", "Bearer "+string(token))
-	return nil
-}
-
-// EdDSASignHeader applies ECDSASign on an HTTP request.
-// Specifically it sets a bearer token in the Authorization header.
-func (c *Claims) EdDSASignHeader(r *http.Request, key ed25519.PrivateKey) error {
-	token, err := c.EdDSASign(key)
-	if err != nil {
-		return err
-	}
-	r.Header.Set("Authorization", "Bearer "+string(token))
-	return nil
+	return nil, errAuthSchema
 }
 
 // HMACSignHeader applies HMACSign on an HTTP request.
@@ -149,17 +164,6 @@ func (h *HMAC) SignHeader(c *Claims, r *http.Request) error {
 	return nil
 }
 
-// RSASignHeader applies RSASign on an HTTP request.
-// Specifically it sets a bearer token in the Authorization header.
-func (c *Claims) RSASignHeader(r *http.Request, alg string, key *rsa.PrivateKey) error {
-	token, err := c.RSASign(alg, key)
-	if err != nil {
-		return err
-	}
-	r.Header.Set("Authorization", "Bearer "+string(token))
-	return nil
-}
-
 // Handler protects an http.Handler with security enforcements.
 // Requests are only passed to Target if the JWT checks out.
 type Handler struct {
@@ -192,6 +196,55 @@ type Handler struct {
 	// as a filter or as an extended http.HandlerFunc.
 	Func func(http.ResponseWriter, *http.Request, *Claims) (pass bool)
 
+	// When not nil, then Limiter throttles requests per LimitClaim value,
+	// after the JWT validation succeeds. Requests are rejected with status
+	// code 429 (Too Many Requests) and a Retry-After header when exceeded.
+	// The header is omitted when the Limiter gives no retry moment.
+	// Tokens without the claim are not limited.
+	Limiter Limiter
+
+	// LimitClaim names the Limiter key, e.g., "client_id". The empty
+	// string defaults to "sub".
+	LimitClaim string
+
+	// When not nil, then Authorizer is consulted after the JWT validation
+	// succeeds and before Func. Requests are rejected with status code 403
+	// (Forbidden) on ErrDenied, and with 500 (Internal Server Error) on any
+	// other error. The response omits the error details, as these may
+	// contain claims and internal addresses. See OnDeny for logging.
+	Authorizer Authorizer
+
+	// OnDeny, when not nil, receives each error from Authorizer, before
+	// the response is sent. Denials by Casbin include the request tuple.
+	OnDeny func(r *http.Request, c *Claims, err error)
+
+	// HeaderNames lists the request headers with authorization, in order
+	// of precedence. Nil defaults to Authorization only.
+	HeaderNames []string
+
+	// Schemes lists the authorization schemes accepted, such as "JWT" or
+	// "Token" for legacy clients. Nil defaults to Bearer only. The first
+	// entry goes in WWW-Authenticate challenges.
+	Schemes []string
+
+	// ProxyHeaders lists the request headers with a token from a trusted
+	// upstream proxy, in order of precedence, e.g., X-Forwarded-Access-Token
+	// from oauth2-proxy, or X-Auth-Request-Access-Token. The value is the
+	// token as is, without scheme. Proxy headers take precedence over
+	// HeaderNames. Only list headers which the proxy always sets or
+	// removes, as clients could supply them otherwise.
+	ProxyHeaders []string
+
+	// ProxyExclusive rejects requests with any of the HeaderNames when a
+	// proxy header is present.
+	ProxyExclusive bool
+
+	// AllowAnonymous passes requests without authorization to Target,
+	// with no header bindings, no context value and no Func invocation.
+	// Client headers that match HeaderPrefix are still removed. Requests
+	// with an invalid JWT remain rejected.
+	AllowAnonymous bool
+
 	// Error sends a custom response. Nil defaults to http.Error.
 	// The appropriate WWW-Authenticate value is already present.
 	Error func(w http.ResponseWriter, error string, statusCode int)
@@ -205,15 +258,64 @@ func (h *Handler) error(w http.ResponseWriter, error string, statusCode int) {
 	}
 }
 
+// FilterHeaders removes any request headers which match HeaderPrefix. The
+// return is HeaderPrefix in canonical form.
+func (h *Handler) filterHeaders(r *http.Request) (headerPrefix string) {
+	headerPrefix = http.CanonicalHeaderKey(h.HeaderPrefix)
+	if headerPrefix != "" {
+		for name := range r.Header {
+			if strings.HasPrefix(name, headerPrefix) {
+				delete(r.Header, name)
+			}
+		}
+	}
+	return headerPrefix
+}
+
+// Token returns the token from the request headers.
+func (h *Handler) token(r *http.Request) ([]byte, error) {
+	for _, name := range h.ProxyHeaders {
+		v := r.Header[http.CanonicalHeaderKey(name)]
+		if v == nil {
+			continue
+		}
+		if h.ProxyExclusive {
+			if _, err := tokenFromHeaders(r, h.HeaderNames, h.Schemes); err != ErrNoHeader {
+				return nil, errProxyDirect
+			}
+		}
+		// multiple values fail on the comma
+		return []byte(strings.TrimSpace(strings.Join(v, ", "))), nil
+	}
+	return tokenFromHeaders(r, h.HeaderNames, h.Schemes)
+}
+
+// Scheme returns the authorization scheme for challenges.
+func (h *Handler) scheme() string {
+	if len(h.Schemes) != 0 {
+		return h.Schemes[0]
+	}
+	return "Bearer"
+}
+
 // ServeHTTP honors the http.Handler interface.
 func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 	// verify claims
-	claims, err := h.Keys.CheckHeader(r)
+	var claims *Claims
+	token, err := h.token(r)
+	if err == nil {
+		claims, err = h.Keys.Check(token)
+	}
+	if err == ErrNoHeader && h.AllowAnonymous {
+		h.filterHeaders(r)
+		h.Target.ServeHTTP(w, r)
+		return
+	}
 	if err != nil {
 		if err == ErrNoHeader {
-			w.Header().Set("WWW-Authenticate", "Bearer")
+			w.Header().Set("WWW-Authenticate", h.scheme())
 		} else {
-			w.Header().Set("WWW-Authenticate", `Bearer error="invalid_token", error_description=`+strconv.QuoteToASCII(err.Error()))
+			w.Header().Set("WWW-Authenticate", h.scheme()+` error="invalid_token", error_description=`+strconv.QuoteToASCII(err.Error()))
 		}
 		h.error(w, err.Error(), http.StatusUnauthorized)
 		return
@@ -221,18 +323,42 @@ func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 
 	// verify time constraints
 	if !claims.Valid(time.Now()) {
-		w.Header().Set("WWW-Authenticate", `Bearer error="invalid_token", error_description="jwt: time constraints exceeded"`)
+		w.Header().Set("WWW-Authenticate", h.scheme()+` error="invalid_token", error_description="jwt: time constraints exceeded"`)
 		h.error(w, "jwt: time constraints exceeded", http.StatusUnauthorized)
 		return
 	}
 
-	// filter request headers
-	headerPrefix := http.CanonicalHeaderKey(h.HeaderPrefix)
-	if headerPrefix != "" {
-		for name := range r.Header {
-			if strings.HasPrefix(name, headerPrefix) {
-				delete(r.Header, name)
+	if h.Limiter != nil {
+		name := h.LimitClaim
+		if name == "" {
+			name = subject
+		}
+		if key, ok := claims.String(name); ok {
+			if pass, retryAfter := h.Limiter.Allow(key); !pass {
+				if retryAfter > 0 {
+					seconds := int64((retryAfter + time.Second - 1) / time.Second)
+					w.Header().Set("Retry-After", strconv.FormatInt(seconds, 10))
+				}
+				h.error(w, "jwt: rate limit exceeded", http.StatusTooManyRequests)
+				return
+			}
+		}
+	}
+
+	headerPrefix := h.filterHeaders(r)
+
+	if h.Authorizer != nil {
+		// error details, like the Casbin request, stay out of the response
+		if err := h.Authorizer.Authorize(r, claims); err != nil {
+			if h.OnDeny != nil {
+				h.OnDeny(r, claims, err)
 			}
+			if errors.Is(err, ErrDenied) {
+				h.error(w, ErrDenied.Error(), http.StatusForbidden)
+			} else {
+				h.error(w, "jwt: authorization unavailable", http.StatusInternalServerError)
+			}
+			return
 		}
 	}
 
@@ -252,7 +378,7 @@ func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 		s, ok := claims.String(claimName)
 		if !ok {
 			msg := "jwt: want string for claim " + claimName
-			w.Header().Set("WWW-Authenticate", `Bearer error="invalid_token", error_description=`+strconv.QuoteToASCII(msg))
+			w.Header().Set("WWW-Authenticate", h.scheme()+` error="invalid_token", error_description=`+strconv.QuoteToASCII(msg))
 			h.error(w, msg, http.StatusUnauthorized)
 			return
 		}
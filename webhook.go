@@ -0,0 +1,249 @@
+package jwt
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// DefaultWebhookHeader is the HTTP header name for webhook signatures.
+const DefaultWebhookHeader = "Webhook-Signature"
+
+// DefaultWebhookTolerance is the maximum clock difference for webhooks.
+const DefaultWebhookTolerance = 5 * time.Minute
+
+// Webhook verification errors.
+var (
+	errWebhookHeader = errors.New("jwt: no webhook signature header")
+	errWebhookFormat = errors.New("jwt: webhook signature is not a detached JWS with unencoded payload")
+	errWebhookTime   = errors.New("jwt: webhook timestamp beyond tolerance")
+	errWebhookTarget = errors.New("jwt: webhook signature for another method or URL")
+	errWebhookID     = errors.New("jwt: webhook signature without ID")
+)
+
+// WebhookJOSE is the header of webhook signatures. The "htm" and "htu"
+// parameters are borrowed from RFC 9449, subsection 4.2.
+type webhookJOSE struct {
+	Alg  string       `json:"alg"`
+	Kid  string       `json:"kid,omitempty"`
+	B64  *bool        `json:"b64"`
+	Crit []string     `json:"crit"`
+	Iat  *NumericTime `json:"iat"`
+	Jti  string       `json:"jti"`
+	Htm  string       `json:"htm"`
+	Htu  string       `json:"htu"`
+}
+
+// WebhookSigner sets a signature on HTTP requests. The signature is a JWS with
+// a detached, unencoded payload, as described in RFC 7797, in which the body
+// travels as is. The JOSE header has the time of signing ("iat"), a unique ID
+// ("jti"), and the request method ("htm") and URL ("htu").
+type WebhookSigner struct {
+	// Signer has the key, identified by KeyID. The empty string omits
+	// the key ID.
+	Signer Signer
+	KeyID  string
+
+	// Header is the HTTP header name. The empty string defaults to
+	// DefaultWebhookHeader.
+	Header string
+}
+
+// Sign sets the signature on r, for the body as sent.
+func (w *WebhookSigner) Sign(ctx context.Context, r *http.Request, body []byte) error {
+	alg := w.Signer.Alg()
+	c := &Claims{KeyID: w.KeyID}
+	c.Issued = NewNumericTime(time.Now().Truncate(time.Second))
+	if err := c.GenerateID(); err != nil {
+		return err
+	}
+	b64 := false
+	header, err := json.Marshal(&webhookJOSE{
+		Alg:  alg,
+		Kid:  w.KeyID,
+		B64:  &b64,
+		Crit: []string{"b64"},
+		Iat:  c.Issued,
+		Jti:  c.ID,
+		Htm:  webhookMethod(r),
+		Htu:  webhookURL(r),
+	})
+	if err != nil {
+		return err
+	}
+	c.RawHeader = json.RawMessage(header)
+
+	sig, err := w.Signer.Sign(ctx, webhookSigningInput(header, body))
+	if err != nil {
+		return err
+	}
+	c.RawSignature = sig
+
+	// detached content leaves the payload segment empty, as described
+	// in RFC 7515, appendix F
+	headerLen := encoding.EncodedLen(len(header))
+	value := make([]byte, headerLen+2+encoding.EncodedLen(len(sig)))
+	encoding.Encode(value, header)
+	value[headerLen] = '.'
+	value[headerLen+1] = '.'
+	encoding.Encode(value[headerLen+2:], sig)
+	c.audit(ctx, alg)
+	r.Header.Set(webhookHeader(w.Header), string(value))
+	return nil
+}
+
+// WebhookVerifier checks signatures from a WebhookSigner.
+type WebhookVerifier struct {
+	// Keys defines the trusted credentials.
+	Keys *KeyRegister
+
+	// Header is the HTTP header name. The empty string defaults to
+	// DefaultWebhookHeader.
+	Header string
+
+	// Tolerance is the maximum difference between the time of signing
+	// and the current time, in either direction. Zero defaults to
+	// DefaultWebhookTolerance.
+	Tolerance time.Duration
+
+	// Replay, when set, consumes the ID of each signature, such that any
+	// subsequent delivery of the same signature fails with ErrUsed. The
+	// IDs expire with Tolerance. Without Replay, signatures may be used
+	// again within the tolerance.
+	Replay ConsumeStore
+
+	// URL is the address of the webhook as known to the sender, e.g.,
+	// "https://example.com/hook". The empty string defaults to the URL of
+	// the request as received, which may differ behind a proxy.
+	URL string
+
+	// Now is the time source. Nil defaults to time.Now.
+	Now func() time.Time
+}
+
+// Check returns the claims if, and only if, the signature on r checks out for
+// the body as received. Signatures have no payload. Instead, the claims have
+// the JOSE header in RawHeader, with "kid", "iat" and "jti" in KeyID, Issued
+// and ID respectively.
+func (v *WebhookVerifier) Check(r *http.Request, body []byte) (*Claims, error) {
+	value := r.Header.Get(webhookHeader(v.Header))
+	if value == "" {
+		return nil, errWebhookHeader
+	}
+	i := strings.IndexByte(value, '.')
+	if i < 0 || len(value) < i+2 || value[i+1] != '.' {
+		return nil, errWebhookFormat
+	}
+	header, err := encoding.DecodeString(value[:i])
+	if err != nil {
+		return nil, errWebhookFormat
+	}
+	sig, err := encoding.DecodeString(value[i+2:])
+	if err != nil {
+		return nil, errWebhookFormat
+	}
+	var jose webhookJOSE
+	if err := json.Unmarshal(header, &jose); err != nil {
+		return nil, errWebhookFormat
+	}
+	// RFC 7797, section 6 requires "b64" to be listed as critical, such
+	// that implementations without support reject the signature.
+	if jose.B64 == nil || *jose.B64 || len(jose.Crit) != 1 || jose.Crit[0] != "b64" {
+		return nil, errWebhookFormat
+	}
+
+	c := &Claims{
+		RawHeader:    json.RawMessage(header),
+		Raw:          json.RawMessage("{}"),
+		RawSignature: sig,
+		KeyID:        jose.Kid,
+	}
+	c, err = v.Keys.verify(c, jose.Alg, webhookSigningInput(header, body), sig, nil)
+	if err != nil {
+		return nil, err
+	}
+	c.Raw, c.Set = nil, nil // detached
+	c.Issued = jose.Iat
+	c.ID = jose.Jti
+
+	now := time.Now
+	if v.Now != nil {
+		now = v.Now
+	}
+	tolerance := v.Tolerance
+	if tolerance <= 0 {
+		tolerance = DefaultWebhookTolerance
+	}
+	if c.Issued == nil {
+		return nil, errWebhookTime
+	}
+	d := now().Sub(c.Issued.Time())
+	if d > tolerance || d < -tolerance {
+		return nil, errWebhookTime
+	}
+
+	url := v.URL
+	if url == "" {
+		url = webhookURL(r)
+	}
+	if jose.Htm != webhookMethod(r) || jose.Htu != url {
+		return nil, errWebhookTarget
+	}
+
+	if v.Replay != nil {
+		if c.ID == "" {
+			return nil, errWebhookID
+		}
+		first, err := v.Replay.Consume(r.Context(), c.ID, c.Issued.Time().Add(tolerance))
+		if err != nil {
+			return nil, err
+		}
+		if !first {
+			return nil, ErrUsed
+		}
+	}
+	return c, nil
+}
+
+// WebhookSigningInput returns the JWS Signing Input with an unencoded payload.
+func webhookSigningInput(header, body []byte) []byte {
+	headerLen := encoding.EncodedLen(len(header))
+	input := make([]byte, headerLen+1+len(body))
+	encoding.Encode(input, header)
+	input[headerLen] = '.'
+	copy(input[headerLen+1:], body)
+	return input
+}
+
+func webhookHeader(name string) string {
+	if name == "" {
+		return DefaultWebhookHeader
+	}
+	return name
+}
+
+func webhookMethod(r *http.Request) string {
+	if r.Method == "" {
+		return http.MethodGet
+	}
+	return r.Method
+}
+
+// WebhookURL returns the absolute URL of r, without fragment.
+func webhookURL(r *http.Request) string {
+	scheme, host := r.URL.Scheme, r.URL.Host
+	if host == "" {
+		host = r.Host
+	}
+	if scheme == "" {
+		if r.TLS != nil {
+			scheme = "https"
+		} else {
+			scheme = "http"
+		}
+	}
+	return scheme + "://" + host + r.URL.RequestURI()
+}
@@ -0,0 +1,85 @@
+//go:build !jwt_hmac_only
+// +build !jwt_hmac_only
+
+package jwt
+
+import (
+	"context"
+	"crypto/ed25519"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestWebhook(t *testing.T) {
+	s, err := NewSigner(EdDSA, testKeyEd25519Private)
+	if err != nil {
+		t.Fatal("signer error:", err)
+	}
+	body := []byte(`{"event":"invoice.paid"}`)
+	req := httptest.NewRequest("POST", "/hook", strings.NewReader(string(body)))
+	if err := (&WebhookSigner{Signer: s}).Sign(context.Background(), req, body); err != nil {
+		t.Fatal("sign error:", err)
+	}
+
+	v := WebhookVerifier{Keys: &KeyRegister{EdDSAs: []ed25519.PublicKey{testKeyEd25519Public}}}
+	if _, err := v.Check(req, body); err != nil {
+		t.Error("check error:", err)
+	}
+	if _, err := v.Check(req, []byte(`{"event":"invoice.void"}`)); err != ErrSigMiss {
+		t.Errorf("got error %v for other body, want %v", err, ErrSigMiss)
+	}
+
+	value := req.Header.Get(DefaultWebhookHeader)
+	if i := strings.IndexByte(value, '.'); i < 0 || value[i+1] != '.' {
+		t.Errorf("got signature %q, want detached payload", value)
+	}
+	if _, err := EdDSACheck([]byte(value), testKeyEd25519Public); err == nil {
+		t.Error("unencoded payload accepted as JWT")
+	}
+
+	other := httptest.NewRequest("POST", "/other", strings.NewReader(string(body)))
+	other.Header.Set(DefaultWebhookHeader, value)
+	if _, err := v.Check(other, body); err != errWebhookTarget {
+		t.Errorf("got error %v for other URL, want %v", err, errWebhookTarget)
+	}
+	other = httptest.NewRequest("PUT", "/hook", strings.NewReader(string(body)))
+	other.Header.Set(DefaultWebhookHeader, value)
+	if _, err := v.Check(other, body); err != errWebhookTarget {
+		t.Errorf("got error %v for other method, want %v", err, errWebhookTarget)
+	}
+	v.URL = "https://example.com/hook"
+	if _, err := v.Check(req, body); err != errWebhookTarget {
+		t.Errorf("got error %v for registered URL mismatch, want %v", err, errWebhookTarget)
+	}
+	v.URL = "http://example.com/hook"
+	if _, err := v.Check(req, body); err != nil {
+		t.Error("check error with registered URL:", err)
+	}
+
+	v.Replay = new(ConsumeMemory)
+	if c, err := v.Check(req, body); err != nil {
+		t.Error("check error with replay detection:", err)
+	} else if c.ID == "" {
+		t.Error("no signature ID")
+	}
+	if _, err := v.Check(req, body); err != ErrUsed {
+		t.Errorf("got error %v for replay, want %v", err, ErrUsed)
+	}
+	v.Replay = nil
+
+	v.Now = func() time.Time { return time.Now().Add(6 * time.Minute) }
+	if _, err := v.Check(req, body); err != errWebhookTime {
+		t.Errorf("got error %v for late delivery, want %v", err, errWebhookTime)
+	}
+	v.Tolerance = 10 * time.Minute
+	if _, err := v.Check(req, body); err != nil {
+		t.Error("check error within tolerance:", err)
+	}
+
+	v.Header = "X-Signature"
+	if _, err := v.Check(req, body); err != errWebhookHeader {
+		t.Errorf("got error %v for other header, want %v", err, errWebhookHeader)
+	}
+}